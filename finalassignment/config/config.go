@@ -0,0 +1,728 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds every setting the server needs at startup.
+//
+// Values are resolved from, in increasing order of precedence: built-in
+// defaults, an optional YAML or TOML file, environment variables and
+// command line flags.
+type Config struct {
+	Database Database
+	HTTP     HTTP
+	JWT      JWT
+	Mail     Mail
+	Password Password
+	// Login is the brute-force protection of logins.
+	Login Login
+	// Verification is the email verification policy.
+	Verification Verification
+	TOTP         TOTP
+	// OIDC is the OpenID Connect provider users can login with.
+	OIDC OIDC
+	// Storage is where uploaded photos are kept.
+	Storage Storage
+	// Variants are the resized copies made of uploaded photos.
+	Variants Variants
+	// Links is how photos linked by URL are checked.
+	Links Links
+	// Args are the command line arguments left after the flags.
+	Args []string
+}
+
+type Database struct {
+	Driver   string
+	Path     string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+}
+
+type HTTP struct {
+	Port int
+	// PublicURL is where clients reach the server, used to build links in
+	// emails.
+	PublicURL string
+	// TrustedProxies are the IPs and CIDRs of reverse proxies whose
+	// X-Forwarded-For header is believed when finding the client IP.
+	TrustedProxies []string
+}
+
+type JWT struct {
+	// Secret is an HMAC key with the key ID SecretKeyID.
+	Secret string
+	// Keys are additional signing or verification keys.
+	Keys []JWTKey
+	// SigningKeyID is the ID of the key new tokens are signed with.
+	SigningKeyID string
+	TokenTTL     time.Duration
+	RefreshTTL   time.Duration
+}
+
+type Mail struct {
+	// Driver is smtp, or log or file to write messages to the log or File
+	// instead of sending them.
+	Driver       string
+	File         string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+}
+
+type Password struct {
+	// BcryptCost is the cost new password hashes are made with. Hashes with
+	// a lower cost are rehashed when their user logs in.
+	BcryptCost int
+	ResetTTL   time.Duration
+	// ResetURL is the page users choose a new password on. Reset emails link
+	// to it with the reset token appended as the token query parameter.
+	ResetURL string
+}
+
+type Login struct {
+	// MaxAttempts is how many failed logins an account gets before it is
+	// locked out, IPMaxAttempts the same for a client IP.
+	MaxAttempts   int
+	IPMaxAttempts int
+	// Backoff is how long the first lockout lasts. Every further failure
+	// doubles it, up to Lockout.
+	Backoff time.Duration
+	// Lockout is the longest lockout, and how long failures are remembered.
+	Lockout time.Duration
+}
+
+type Verification struct {
+	// Required blocks users from creating photos and comments until they
+	// verify their email.
+	Required       bool
+	TTL            time.Duration
+	ResendInterval time.Duration
+}
+
+type TOTP struct {
+	// Issuer names the account in authenticator apps.
+	Issuer string
+	// ChallengeTTL is how long users have to enter their code after their
+	// password.
+	ChallengeTTL time.Duration
+}
+
+type OIDC struct {
+	// Issuer is the URL of the provider, OpenID Connect login is off when it
+	// is empty.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback the provider sends users back to.
+	RedirectURL string
+}
+
+type Storage struct {
+	// Driver is fs to keep uploads in Path, or s3 to keep them in a bucket
+	// of an S3 compatible service.
+	Driver string
+	Path   string
+	S3     S3
+	// PublicURL is the URL uploads are linked under, followed by their
+	// storage key.
+	PublicURL string
+	// MaxUploadSize is the largest photo accepted, in bytes.
+	MaxUploadSize int64
+	// UploadTTL is how long unfinished resumable uploads are kept.
+	UploadTTL time.Duration
+}
+
+type S3 struct {
+	// Endpoint is the URL of the service. Buckets are addressed in the path,
+	// which every S3 compatible service supports.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+type Variants struct {
+	// Sizes are the longest side of each variant in pixels. Photos are
+	// never enlarged, smaller ones keep their size.
+	Sizes []int
+	// Formats are what every size is encoded as, jpeg, png or webp.
+	Formats     []string
+	JPEGQuality int
+	// StripGPS leaves the location out of the EXIF data JPEG variants of
+	// JPEG photos keep.
+	StripGPS bool
+	// Workers is how many photos are resized at once.
+	Workers int
+}
+
+type Links struct {
+	// Ingest copies linked photos into storage once they check out.
+	Ingest bool
+	// Timeout bounds a whole check, redirects and body included.
+	Timeout      time.Duration
+	MaxRedirects int
+	// MaxSize is the largest linked photo accepted, in bytes.
+	MaxSize int64
+	// RecheckInterval is how long broken links wait to be checked again.
+	RecheckInterval time.Duration
+	// AllowPrivate lets links point to loopback and private addresses,
+	// which is only safe in development.
+	AllowPrivate bool
+	// Workers is how many links are checked at once.
+	Workers int
+}
+
+// JWTKey is a key read from File: an HMAC secret for HS256, or a PEM encoded
+// private key, or a public key when it should only verify tokens, for RS256
+// and EdDSA.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	File      string
+}
+
+// SecretKeyID is the key ID of JWT.Secret. Tokens without a kid header were
+// signed with it.
+const SecretKeyID = "default"
+
+// Errors is every problem found while loading the configuration.
+type Errors []error
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = "  - " + err.Error()
+	}
+	return "invalid configuration:\n" + strings.Join(messages, "\n")
+}
+
+// setting describes a single configuration value. Its key is the dotted path
+// used in config files, e.g. "db.host"; the environment variable (DB_HOST)
+// and the flag (-db-host) are derived from it.
+type setting struct {
+	key   string
+	def   string
+	usage string
+}
+
+var settings = []setting{
+	{"db.driver", "postgres", "database driver, postgres or sqlite"},
+	{"db.path", "finalassignment.db", "sqlite database file"},
+	{"db.host", "localhost", "database host"},
+	{"db.port", "5432", "database port"},
+	{"db.user", "postgres", "database user"},
+	{"db.password", "", "database password"},
+	{"db.name", "finalassignmentdb", "database name"},
+	{"db.sslmode", "disable", "postgres sslmode"},
+	{"http.port", "8080", "port the HTTP server listens on"},
+	{"http.public_url", "http://localhost:8080", "URL clients reach the server at, used in links sent by email"},
+	{"http.trusted_proxies", "", "comma separated IPs or CIDRs of reverse proxies trusted to report the client IP"},
+	{"jwt.secret", "", "HMAC secret used to sign JWTs, at least 32 characters"},
+	{"jwt.keys", "", "comma separated kid:algorithm:file signing keys, algorithm is HS256, RS256 or EdDSA"},
+	{"jwt.signing_kid", "", "kid of the key new JWTs are signed with, defaults to the first of jwt.keys"},
+	{"jwt.token_ttl", "15m", "lifetime of issued access tokens"},
+	{"jwt.refresh_ttl", "720h", "lifetime of issued refresh tokens"},
+	{"mail.driver", "log", "how emails are delivered: smtp, or log or file to only write them out"},
+	{"mail.file", "mail.log", "file emails are appended to by the file driver"},
+	{"mail.from", "finalassignment@localhost", "sender address of emails"},
+	{"mail.smtp_host", "", "SMTP server host"},
+	{"mail.smtp_port", "587", "SMTP server port"},
+	{"mail.smtp_user", "", "SMTP username, leave empty to send without authentication"},
+	{"mail.smtp_password", "", "SMTP password"},
+	{"password.bcrypt_cost", "12", "bcrypt cost of password hashes, between 4 and 31"},
+	{"password.reset_ttl", "1h", "lifetime of password reset tokens"},
+	{"password.reset_url", "", "page linked from password reset emails, the token is appended as ?token="},
+	{"login.max_attempts", "5", "failed logins allowed per account before it is locked out"},
+	{"login.ip_max_attempts", "20", "failed logins allowed per client IP before it is locked out"},
+	{"login.backoff", "1s", "length of the first lockout, doubled by every further failure"},
+	{"login.lockout", "15m", "longest lockout, and how long failed logins are remembered"},
+	{"verification.required", "true", "block users from creating photos and comments until they verify their email"},
+	{"verification.ttl", "24h", "lifetime of email verification links"},
+	{"verification.resend_interval", "1m", "minimum time between two verification emails to the same user"},
+	{"totp.issuer", "finalassignment", "name accounts are shown under in authenticator apps"},
+	{"totp.challenge_ttl", "5m", "time allowed between the password and the two-factor code of a login"},
+	{"oidc.issuer", "", "issuer URL of the OpenID Connect provider users can login with, leave empty to turn it off"},
+	{"oidc.client_id", "", "client ID registered at the OpenID Connect provider"},
+	{"oidc.client_secret", "", "client secret registered at the OpenID Connect provider, empty for public clients"},
+	{"oidc.redirect_url", "", "callback registered at the OpenID Connect provider, defaults to http.public_url + /users/oidc/callback"},
+	{"storage.driver", "fs", "where uploaded photos are kept: fs for storage.path, or s3 for an S3 compatible bucket"},
+	{"storage.path", "uploads", "directory the fs storage driver keeps uploaded photos in"},
+	{"storage.public_url", "", "URL uploaded photos are linked under, defaults to http.public_url + /uploads"},
+	{"storage.max_upload_size", "10485760", "largest photo accepted for upload, in bytes"},
+	{"storage.upload_ttl", "24h", "how long unfinished resumable uploads are kept"},
+	{"storage.s3.endpoint", "", "URL of the S3 compatible service, such as https://s3.us-east-1.amazonaws.com"},
+	{"storage.s3.region", "us-east-1", "region of the S3 bucket"},
+	{"storage.s3.bucket", "", "S3 bucket uploaded photos are kept in"},
+	{"storage.s3.access_key_id", "", "access key ID of the S3 credentials"},
+	{"storage.s3.secret_access_key", "", "secret access key of the S3 credentials"},
+	{"variants.sizes", "150,640,1080", "comma separated sizes in pixels of the longest side of the variants made of uploaded photos"},
+	{"variants.formats", "jpeg", "comma separated formats every variant size is made in: jpeg, png or webp"},
+	{"variants.jpeg_quality", "85", "quality of JPEG variants, from 1 to 100"},
+	{"variants.strip_gps", "true", "drop the GPS location from the EXIF data of JPEG variants"},
+	{"variants.workers", "2", "how many photos are resized at once"},
+	{"links.ingest", "false", "copy linked photos into storage once they check out"},
+	{"links.timeout", "10s", "time allowed to fetch a linked photo"},
+	{"links.max_redirects", "5", "redirects followed when fetching a linked photo"},
+	{"links.max_size", "10485760", "largest linked photo accepted, in bytes"},
+	{"links.recheck_interval", "24h", "how long broken links wait to be checked again"},
+	{"links.allow_private", "false", "let links point to loopback and private addresses, for development only"},
+	{"links.workers", "2", "how many links are checked at once"},
+}
+
+var jwtAlgorithms = []string{"HS256", "RS256", "EdDSA"}
+
+var drivers = []string{"postgres", "sqlite"}
+
+var mailDrivers = []string{"log", "file", "smtp"}
+
+var storageDrivers = []string{"fs", "s3"}
+
+// variantFormats are the formats variants can be encoded in.
+var variantFormats = []string{"jpeg", "png", "webp"}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+const minSecretLength = 32
+
+func (s setting) env() string {
+	return strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+func (s setting) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// Load resolves the configuration from defaults, the file named by -config
+// or CONFIG_FILE, the environment and args. All validation errors are
+// reported together as Errors.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("finalassignment", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.key] = fs.String(s.flag(), "", fmt.Sprintf("%s (env %s)", s.usage, s.env()))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs Errors
+	values := make(map[string]string, len(settings))
+	for _, s := range settings {
+		values[s.key] = s.def
+	}
+	if *configFile != "" {
+		fileValues, err := readFile(*configFile)
+		if err != nil {
+			return nil, Errors{err}
+		}
+		for key, value := range fileValues {
+			if _, ok := values[key]; !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %q", *configFile, key))
+				continue
+			}
+			values[key] = value
+		}
+	}
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env()); ok {
+			values[s.key] = value
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag() == f.Name {
+				values[s.key] = *flagValues[s.key]
+			}
+		}
+	})
+
+	cfg := &Config{Args: fs.Args()}
+	cfg.Database.Driver = values["db.driver"]
+	cfg.Database.Path = values["db.path"]
+	cfg.Database.Host = values["db.host"]
+	cfg.Database.Port = parsePort("db.port", values, &errs)
+	cfg.Database.User = values["db.user"]
+	cfg.Database.Password = values["db.password"]
+	cfg.Database.Name = values["db.name"]
+	cfg.Database.SSLMode = values["db.sslmode"]
+	cfg.HTTP.Port = parsePort("http.port", values, &errs)
+	cfg.HTTP.PublicURL = strings.TrimSuffix(values["http.public_url"], "/")
+	cfg.HTTP.TrustedProxies = parseProxies("http.trusted_proxies", values, &errs)
+	cfg.JWT.Secret = values["jwt.secret"]
+	cfg.JWT.Keys = parseJWTKeys("jwt.keys", values, &errs)
+	cfg.JWT.SigningKeyID = values["jwt.signing_kid"]
+	cfg.JWT.TokenTTL = parseDuration("jwt.token_ttl", values, &errs)
+	cfg.JWT.RefreshTTL = parseDuration("jwt.refresh_ttl", values, &errs)
+	cfg.Mail.Driver = values["mail.driver"]
+	cfg.Mail.File = values["mail.file"]
+	cfg.Mail.From = values["mail.from"]
+	cfg.Mail.SMTPHost = values["mail.smtp_host"]
+	cfg.Mail.SMTPPort = parsePort("mail.smtp_port", values, &errs)
+	cfg.Mail.SMTPUser = values["mail.smtp_user"]
+	cfg.Mail.SMTPPassword = values["mail.smtp_password"]
+	cfg.Password.BcryptCost = parseInt("password.bcrypt_cost", values, bcrypt.MinCost, bcrypt.MaxCost, &errs)
+	cfg.Password.ResetTTL = parseDuration("password.reset_ttl", values, &errs)
+	cfg.Password.ResetURL = values["password.reset_url"]
+	cfg.Login.MaxAttempts = parseInt("login.max_attempts", values, 1, math.MaxInt32, &errs)
+	cfg.Login.IPMaxAttempts = parseInt("login.ip_max_attempts", values, 1, math.MaxInt32, &errs)
+	cfg.Login.Backoff = parseDuration("login.backoff", values, &errs)
+	cfg.Login.Lockout = parseDuration("login.lockout", values, &errs)
+	cfg.Verification.Required = parseBool("verification.required", values, &errs)
+	cfg.Verification.TTL = parseDuration("verification.ttl", values, &errs)
+	cfg.Verification.ResendInterval = parseDuration("verification.resend_interval", values, &errs)
+	cfg.TOTP.Issuer = values["totp.issuer"]
+	cfg.TOTP.ChallengeTTL = parseDuration("totp.challenge_ttl", values, &errs)
+	cfg.OIDC.Issuer = values["oidc.issuer"]
+	cfg.OIDC.ClientID = values["oidc.client_id"]
+	cfg.OIDC.ClientSecret = values["oidc.client_secret"]
+	cfg.OIDC.RedirectURL = values["oidc.redirect_url"]
+	if cfg.OIDC.RedirectURL == "" {
+		cfg.OIDC.RedirectURL = cfg.HTTP.PublicURL + "/users/oidc/callback"
+	}
+	cfg.Storage.Driver = values["storage.driver"]
+	cfg.Storage.Path = values["storage.path"]
+	cfg.Storage.PublicURL = strings.TrimSuffix(values["storage.public_url"], "/")
+	if cfg.Storage.PublicURL == "" {
+		cfg.Storage.PublicURL = cfg.HTTP.PublicURL + "/uploads"
+	}
+	cfg.Storage.MaxUploadSize = int64(parseInt("storage.max_upload_size", values, 1, math.MaxInt32, &errs))
+	cfg.Storage.UploadTTL = parseDuration("storage.upload_ttl", values, &errs)
+	cfg.Storage.S3.Endpoint = strings.TrimSuffix(values["storage.s3.endpoint"], "/")
+	cfg.Storage.S3.Region = values["storage.s3.region"]
+	cfg.Storage.S3.Bucket = values["storage.s3.bucket"]
+	cfg.Storage.S3.AccessKeyID = values["storage.s3.access_key_id"]
+	cfg.Storage.S3.SecretAccessKey = values["storage.s3.secret_access_key"]
+	cfg.Variants.Sizes = parseSizes("variants.sizes", values, &errs)
+	cfg.Variants.Formats = parseVariantFormats("variants.formats", values, &errs)
+	cfg.Variants.JPEGQuality = parseInt("variants.jpeg_quality", values, 1, 100, &errs)
+	cfg.Variants.StripGPS = parseBool("variants.strip_gps", values, &errs)
+	cfg.Variants.Workers = parseInt("variants.workers", values, 1, 64, &errs)
+	cfg.Links.Ingest = parseBool("links.ingest", values, &errs)
+	cfg.Links.Timeout = parseDuration("links.timeout", values, &errs)
+	cfg.Links.MaxRedirects = parseInt("links.max_redirects", values, 0, 20, &errs)
+	cfg.Links.MaxSize = int64(parseInt("links.max_size", values, 1, math.MaxInt32, &errs))
+	cfg.Links.RecheckInterval = parseDuration("links.recheck_interval", values, &errs)
+	cfg.Links.AllowPrivate = parseBool("links.allow_private", values, &errs)
+	cfg.Links.Workers = parseInt("links.workers", values, 1, 64, &errs)
+
+	switch cfg.Database.Driver {
+	case "postgres":
+		for _, key := range []string{"db.host", "db.user", "db.name"} {
+			if values[key] == "" {
+				errs = append(errs, fmt.Errorf("%s is required", key))
+			}
+		}
+		if !contains(sslModes, cfg.Database.SSLMode) {
+			errs = append(errs, fmt.Errorf("db.sslmode must be one of %s, got %q", strings.Join(sslModes, ", "), cfg.Database.SSLMode))
+		}
+	case "sqlite":
+		if cfg.Database.Path == "" {
+			errs = append(errs, fmt.Errorf("db.path is required"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("db.driver must be one of %s, got %q", strings.Join(drivers, ", "), cfg.Database.Driver))
+	}
+	if cfg.JWT.Secret != "" && len(cfg.JWT.Secret) < minSecretLength {
+		errs = append(errs, fmt.Errorf("jwt.secret must be at least %d characters", minSecretLength))
+	}
+	if cfg.JWT.Secret == "" && len(cfg.JWT.Keys) == 0 {
+		errs = append(errs, fmt.Errorf("jwt.secret or jwt.keys is required (set JWT_SECRET or JWT_KEYS)"))
+	}
+	if cfg.JWT.SigningKeyID == "" {
+		cfg.JWT.SigningKeyID = SecretKeyID
+		if len(cfg.JWT.Keys) > 0 {
+			cfg.JWT.SigningKeyID = cfg.JWT.Keys[0].ID
+		}
+	}
+	knownKeyIDs := make([]string, 0, len(cfg.JWT.Keys)+1)
+	if cfg.JWT.Secret != "" {
+		knownKeyIDs = append(knownKeyIDs, SecretKeyID)
+	}
+	for _, key := range cfg.JWT.Keys {
+		knownKeyIDs = append(knownKeyIDs, key.ID)
+	}
+	if len(knownKeyIDs) > 0 && !contains(knownKeyIDs, cfg.JWT.SigningKeyID) {
+		errs = append(errs, fmt.Errorf("jwt.signing_kid must be one of %s, got %q", strings.Join(knownKeyIDs, ", "), cfg.JWT.SigningKeyID))
+	}
+	switch cfg.Mail.Driver {
+	case "log":
+	case "file":
+		if cfg.Mail.File == "" {
+			errs = append(errs, fmt.Errorf("mail.file is required by the file mail driver"))
+		}
+	case "smtp":
+		if cfg.Mail.SMTPHost == "" {
+			errs = append(errs, fmt.Errorf("mail.smtp_host is required by the smtp mail driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver must be one of %s, got %q", strings.Join(mailDrivers, ", "), cfg.Mail.Driver))
+	}
+	if cfg.Login.Backoff > cfg.Login.Lockout {
+		errs = append(errs, fmt.Errorf("login.backoff must not be longer than login.lockout"))
+	}
+	if cfg.TOTP.Issuer == "" {
+		errs = append(errs, fmt.Errorf("totp.issuer is required"))
+	}
+	if cfg.Mail.From == "" {
+		errs = append(errs, fmt.Errorf("mail.from is required"))
+	}
+	if !isHTTPURL(cfg.HTTP.PublicURL) {
+		errs = append(errs, fmt.Errorf("http.public_url must be an http or https URL, got %q", cfg.HTTP.PublicURL))
+	}
+	if cfg.Password.ResetURL != "" && !isHTTPURL(cfg.Password.ResetURL) {
+		errs = append(errs, fmt.Errorf("password.reset_url must be an http or https URL, got %q", cfg.Password.ResetURL))
+	}
+	if cfg.OIDC.Issuer != "" {
+		if !isHTTPURL(cfg.OIDC.Issuer) {
+			errs = append(errs, fmt.Errorf("oidc.issuer must be an http or https URL, got %q", cfg.OIDC.Issuer))
+		}
+		if cfg.OIDC.ClientID == "" {
+			errs = append(errs, fmt.Errorf("oidc.client_id is required when oidc.issuer is set"))
+		}
+		if !isHTTPURL(cfg.OIDC.RedirectURL) {
+			errs = append(errs, fmt.Errorf("oidc.redirect_url must be an http or https URL, got %q", cfg.OIDC.RedirectURL))
+		}
+	}
+	switch cfg.Storage.Driver {
+	case "fs":
+		if cfg.Storage.Path == "" {
+			errs = append(errs, fmt.Errorf("storage.path is required by the fs storage driver"))
+		}
+	case "s3":
+		for _, key := range []string{"storage.s3.region", "storage.s3.bucket", "storage.s3.access_key_id", "storage.s3.secret_access_key"} {
+			if values[key] == "" {
+				errs = append(errs, fmt.Errorf("%s is required by the s3 storage driver", key))
+			}
+		}
+		if !isHTTPURL(cfg.Storage.S3.Endpoint) {
+			errs = append(errs, fmt.Errorf("storage.s3.endpoint must be an http or https URL, got %q", cfg.Storage.S3.Endpoint))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver must be one of %s, got %q", strings.Join(storageDrivers, ", "), cfg.Storage.Driver))
+	}
+	if !isHTTPURL(cfg.Storage.PublicURL) {
+		errs = append(errs, fmt.Errorf("storage.public_url must be an http or https URL, got %q", cfg.Storage.PublicURL))
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return cfg, nil
+}
+
+// DSN returns the libpq connection string for the database.
+func (db Database) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		quote(db.Host), quote(db.User), quote(db.Password), quote(db.Name), db.Port, db.SSLMode)
+}
+
+// Addr returns the address the HTTP server listens on.
+func (h HTTP) Addr() string {
+	return fmt.Sprintf(":%d", h.Port)
+}
+
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		var raw map[interface{}]interface{}
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		tree = stringKeys(raw)
+	case ".toml":
+		if err := toml.Unmarshal(content, &tree); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file extension %q", path, ext)
+	}
+	values := make(map[string]string)
+	flatten("", tree, values)
+	return values, nil
+}
+
+func stringKeys(raw map[interface{}]interface{}) map[string]interface{} {
+	tree := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		if nested, ok := value.(map[interface{}]interface{}); ok {
+			value = stringKeys(nested)
+		}
+		tree[fmt.Sprint(key)] = value
+	}
+	return tree
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(key, nested, values)
+			continue
+		}
+		values[key] = fmt.Sprint(value)
+	}
+}
+
+func parsePort(key string, values map[string]string, errs *Errors) int {
+	port, err := strconv.Atoi(values[key])
+	if err != nil || port < 1 || port > 65535 {
+		*errs = append(*errs, fmt.Errorf("%s must be a port number between 1 and 65535, got %q", key, values[key]))
+	}
+	return port
+}
+
+func parseInt(key string, values map[string]string, min, max int, errs *Errors) int {
+	value, err := strconv.Atoi(values[key])
+	if err != nil || value < min || value > max {
+		*errs = append(*errs, fmt.Errorf("%s must be a whole number between %d and %d, got %q", key, min, max, values[key]))
+	}
+	return value
+}
+
+func parseDuration(key string, values map[string]string, errs *Errors) time.Duration {
+	duration, err := time.ParseDuration(values[key])
+	if err != nil || duration <= 0 {
+		*errs = append(*errs, fmt.Errorf("%s must be a positive duration such as 15m or 24h, got %q", key, values[key]))
+	}
+	return duration
+}
+
+func parseBool(key string, values map[string]string, errs *Errors) bool {
+	value, err := strconv.ParseBool(values[key])
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be true or false, got %q", key, values[key]))
+	}
+	return value
+}
+
+func parseProxies(key string, values map[string]string, errs *Errors) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(values[key], ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			*errs = append(*errs, fmt.Errorf("%s entries must be IPs or CIDRs, got %q", key, proxy))
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies
+}
+
+func parseSizes(key string, values map[string]string, errs *Errors) []int {
+	var sizes []int
+	seen := map[int]bool{}
+	for _, entry := range strings.Split(values[key], ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		size, err := strconv.Atoi(entry)
+		if err != nil || size < 16 || size > 4096 {
+			*errs = append(*errs, fmt.Errorf("%s entries must be whole numbers between 16 and 4096, got %q", key, entry))
+			continue
+		}
+		if !seen[size] {
+			sizes = append(sizes, size)
+		}
+		seen[size] = true
+	}
+	if len(sizes) == 0 {
+		*errs = append(*errs, fmt.Errorf("%s needs at least one size", key))
+	}
+	return sizes
+}
+
+func parseVariantFormats(key string, values map[string]string, errs *Errors) []string {
+	var formats []string
+	for _, format := range strings.Split(values[key], ",") {
+		format = strings.TrimSpace(format)
+		if format == "" || contains(formats, format) {
+			continue
+		}
+		if !contains(variantFormats, format) {
+			*errs = append(*errs, fmt.Errorf("%s entries must be one of %s, got %q", key, strings.Join(variantFormats, ", "), format))
+			continue
+		}
+		formats = append(formats, format)
+	}
+	if len(formats) == 0 {
+		*errs = append(*errs, fmt.Errorf("%s needs at least one format", key))
+	}
+	return formats
+}
+
+func parseJWTKeys(key string, values map[string]string, errs *Errors) []JWTKey {
+	var keys []JWTKey
+	seen := map[string]bool{SecretKeyID: values["jwt.secret"] != ""}
+	for _, entry := range strings.Split(values[key], ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			*errs = append(*errs, fmt.Errorf("%s entries must look like kid:algorithm:file, got %q", key, entry))
+			continue
+		}
+		jwtKey := JWTKey{ID: parts[0], Algorithm: parts[1], File: parts[2]}
+		if !contains(jwtAlgorithms, jwtKey.Algorithm) {
+			*errs = append(*errs, fmt.Errorf("%s: algorithm of key %q must be one of %s, got %q", key, jwtKey.ID, strings.Join(jwtAlgorithms, ", "), jwtKey.Algorithm))
+		}
+		if seen[jwtKey.ID] {
+			*errs = append(*errs, fmt.Errorf("%s: kid %q is used more than once", key, jwtKey.ID))
+		}
+		seen[jwtKey.ID] = true
+		keys = append(keys, jwtKey)
+	}
+	return keys
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// quote escapes a value for a libpq key/value connection string.
+func quote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + value + "'"
+}
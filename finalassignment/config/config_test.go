@@ -0,0 +1,116 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadPrecedence checks flags override the environment, which
+// overrides the config file, which overrides the defaults, setting by
+// setting.
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeConfig(t, "config.yaml", "db:\n  host: file-host\n  port: 6000\n  password: file-password\njwt:\n  token_ttl: 1h\n")
+	tomlFile := writeConfig(t, "config.toml", "[db]\nhost = \"toml-host\"\nport = 7000\n")
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		host     string
+		port     int
+		password string
+		ttl      time.Duration
+	}{
+		{"defaults", nil, nil, "localhost", 5432, "", 15 * time.Minute},
+		{"yaml file", nil, []string{"-config", yamlFile}, "file-host", 6000, "file-password", time.Hour},
+		{"toml file", nil, []string{"-config", tomlFile}, "toml-host", 7000, "", 15 * time.Minute},
+		{"file from the environment", map[string]string{"CONFIG_FILE": yamlFile}, nil, "file-host", 6000, "file-password", time.Hour},
+		{"file flag over the environment", map[string]string{"CONFIG_FILE": yamlFile}, []string{"-config", tomlFile}, "toml-host", 7000, "", 15 * time.Minute},
+		{
+			"environment over file",
+			map[string]string{"DB_HOST": "env-host", "JWT_TOKEN_TTL": "2h"},
+			[]string{"-config", yamlFile},
+			"env-host", 6000, "file-password", 2 * time.Hour,
+		},
+		{
+			// An empty variable is set all the same.
+			"empty environment over file",
+			map[string]string{"DB_PASSWORD": ""},
+			[]string{"-config", yamlFile},
+			"file-host", 6000, "", time.Hour,
+		},
+		{
+			"flag over environment and file",
+			map[string]string{"DB_HOST": "env-host", "DB_PORT": "6500"},
+			[]string{"-config", yamlFile, "-db-host", "flag-host", "-jwt-token-ttl", "3h"},
+			"flag-host", 6500, "file-password", 3 * time.Hour,
+		},
+		{"flag over default", nil, []string{"-db-port", "6543"}, "localhost", 6543, "", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", testSecret)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := config.Load(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Database.Host != tt.host || cfg.Database.Port != tt.port || cfg.Database.Password != tt.password || cfg.JWT.TokenTTL != tt.ttl {
+				t.Errorf("got host %q, port %d, password %q and token TTL %s, want %q, %d, %q and %s",
+					cfg.Database.Host, cfg.Database.Port, cfg.Database.Password, cfg.JWT.TokenTTL,
+					tt.host, tt.port, tt.password, tt.ttl)
+			}
+		})
+	}
+}
+
+// TestLoadErrors checks every invalid setting is reported at once, wherever
+// it came from.
+func TestLoadErrors(t *testing.T) {
+	file := writeConfig(t, "config.yaml", "db:\n  hots: typo\n  sslmode: sometimes\n")
+	t.Setenv("DB_PORT", "not a port")
+	t.Setenv("JWT_SECRET", "short")
+	_, err := config.Load([]string{"-config", file, "-jwt-token-ttl", "soon", "-mail-driver", "pigeon"})
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want Errors", err)
+	}
+	want := []string{
+		`unknown setting "db.hots"`,
+		`db.port must be a port number between 1 and 65535, got "not a port"`,
+		`jwt.token_ttl must be a positive duration such as 15m or 24h, got "soon"`,
+		`db.sslmode must be one of`,
+		`jwt.secret must be at least 32 characters`,
+		`mail.driver must be one of`,
+	}
+	if len(errs) != len(want) {
+		t.Errorf("got %d errors, want %d:\n%v", len(errs), len(want), err)
+	}
+	for _, message := range want {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("the errors don't include %q:\n%v", message, err)
+		}
+	}
+
+	if _, err := config.Load([]string{"-config", filepath.Join(t.TempDir(), "config.ini")}); err == nil {
+		t.Error("an unsupported config file was accepted")
+	}
+}
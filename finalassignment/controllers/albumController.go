@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/policy"
+	"github.com/gin-gonic/gin"
+)
+
+type AlbumHandler struct {
+	albums database.AlbumRepository
+	photos database.PhotoRepository
+}
+
+func NewAlbumHandler(albums database.AlbumRepository, photos database.PhotoRepository) *AlbumHandler {
+	return &AlbumHandler{albums: albums, photos: photos}
+}
+
+// CreateAlbum godoc
+// @Summary      Create an album
+// @Description  Create an album associated with the logged in user. The cover photo has to be one of their photos. Users have to verify their email first, unless verification.required is off.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        album body dto.Album true "JSON of the album to be made."
+// @Success      201  {object}  responses.CreateAlbum
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums [post]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) CreateAlbum(ctx *gin.Context) {
+	var newAlbum dto.Album
+	if err := ctx.ShouldBindJSON(&newAlbum); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&newAlbum); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	if newAlbum.CoverPhotoID != nil && !h.ownPhotos(ctx, []uint{*newAlbum.CoverPhotoID}) {
+		return
+	}
+	album, err := h.albums.CreateAlbum(middlewares.CurrentPrincipal(ctx).UserID, &newAlbum)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, responses.CreateAlbum{
+		Album:     albumResponse(album),
+		CreatedAt: album.CreatedAt,
+	})
+}
+
+// GetAllAlbums godoc
+// @Summary      Get albums
+// @Description  Get a page of the public albums and those of the logged in user, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param        query query dto.ListQuery false "Paging, sorting and filters"
+// @Success      200  {object}  responses.GetAllAlbums
+// @Header       200  {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
+// @Failure		 400 {object} responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) GetAllAlbums(ctx *gin.Context) {
+	var query dto.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	options, ok := listOptions(ctx, query)
+	if !ok {
+		return
+	}
+	albums, next, err := h.albums.GetAllAlbums(middlewares.CurrentPrincipal(ctx).UserID, options)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	albumsResponse := make([]responses.GetAlbum, len(albums))
+	for i, album := range albums {
+		albumsResponse[i].Set(album)
+	}
+	setNextCursor(ctx, options, next)
+	ctx.JSON(http.StatusOK, responses.GetAllAlbums{
+		Albums: albumsResponse,
+	})
+}
+
+// GetAlbum godoc
+// @Summary      Get an album
+// @Description  Get an album along with its user and its photos in their order. Private albums are only found by their user.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param		 albumId path uint true "ID number of the album"
+// @Success      200  {object}  responses.GetAlbum
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums/{albumId} [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) GetAlbum(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("albumId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	album, err := h.albums.GetAlbumWithUser(uint(parsedID))
+	if err == nil && !visible(middlewares.CurrentPrincipal(ctx), album.Album) {
+		err = database.ErrNotFound
+	}
+	var photos []models.PhotoWithUser
+	if err == nil {
+		photos, err = h.albums.GetAlbumPhotos(uint(parsedID))
+	}
+	if err != nil {
+		abortAlbumError(ctx, err, parsedID)
+		return
+	}
+	var response responses.GetAlbum
+	response.Set(album)
+	response.Photos = make([]responses.GetPhoto, len(photos))
+	for i, photo := range photos {
+		response.Photos[i].Set(photo)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UpdateAlbum godoc
+// @Summary      Update an album
+// @Description  Update an album associated with logged in user. The visibility is kept when left out.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param		 albumId path uint true "ID number of the album"
+// @Param        album body dto.Album true "New JSON of the album."
+// @Success      200  {object}  responses.UpdateAlbum
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums/{albumId} [put]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) UpdateAlbum(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("albumId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	var albumDto dto.Album
+	if err := ctx.ShouldBindJSON(&albumDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&albumDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	if !h.authorizeAlbum(ctx, parsedID, policy.Update) {
+		return
+	}
+	if albumDto.CoverPhotoID != nil && !h.ownPhotos(ctx, []uint{*albumDto.CoverPhotoID}) {
+		return
+	}
+	album, err := h.albums.UpdateAlbum(uint(parsedID), &albumDto)
+	if err != nil {
+		abortAlbumError(ctx, err, parsedID)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.UpdateAlbum{
+		Album:     albumResponse(album),
+		UpdatedAt: album.UpdatedAt,
+	})
+}
+
+// DeleteAlbum godoc
+// @Summary      Delete an album
+// @Description  Delete an album associated with logged in user, its photos are kept. Moderators can delete any album.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param		 albumId path uint true "ID number of the album to be deleted"
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums/{albumId} [delete]
+// @Router       /admin/albums/{albumId} [delete]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) DeleteAlbum(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("albumId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if !h.authorizeAlbum(ctx, parsedID, policy.Delete) {
+		return
+	}
+	if err := h.albums.DeleteAlbum(uint(parsedID)); err != nil {
+		abortAlbumError(ctx, err, parsedID)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "Your album has been successfully deleted",
+	})
+}
+
+// AddAlbumPhotos godoc
+// @Summary      Add photos to an album
+// @Description  Add photos of the logged in user to the end of their album, in the order given. Photos in the album already stay where they are.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param		 albumId path uint true "ID number of the album"
+// @Param        photos body dto.AlbumPhotos true "IDs of the photos to be added."
+// @Success      200  {object}  responses.AlbumPhotos
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums/{albumId}/photos [post]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) AddAlbumPhotos(ctx *gin.Context) {
+	parsedID, photosDto, ok := h.bindAlbumPhotos(ctx)
+	if !ok || !h.ownPhotos(ctx, photosDto.PhotoIDs) {
+		return
+	}
+	ids, err := h.albums.AddAlbumPhotos(uint(parsedID), photosDto.PhotoIDs)
+	h.respondAlbumPhotos(ctx, parsedID, ids, err)
+}
+
+// RemoveAlbumPhotos godoc
+// @Summary      Remove photos from an album
+// @Description  Remove photos from an album of the logged in user. The photos themselves are kept, and the ones not in the album are ignored.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param		 albumId path uint true "ID number of the album"
+// @Param        photos body dto.AlbumPhotos true "IDs of the photos to be removed."
+// @Success      200  {object}  responses.AlbumPhotos
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums/{albumId}/photos [delete]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) RemoveAlbumPhotos(ctx *gin.Context) {
+	parsedID, photosDto, ok := h.bindAlbumPhotos(ctx)
+	if !ok {
+		return
+	}
+	ids, err := h.albums.RemoveAlbumPhotos(uint(parsedID), photosDto.PhotoIDs)
+	h.respondAlbumPhotos(ctx, parsedID, ids, err)
+}
+
+// MoveAlbumPhotos godoc
+// @Summary      Reorder the photos of an album
+// @Description  Move photos of an album of the logged in user, in the order given, to a position counting from 1. The other photos keep their order around them, and positions past the end move the photos to the end.
+// @Tags         albums
+// @Accept       json
+// @Produce      json
+// @Param		 albumId path uint true "ID number of the album"
+// @Param        order body dto.AlbumPhotoOrder true "IDs of the photos to be moved and where to."
+// @Success      200  {object}  responses.AlbumPhotos
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /albums/{albumId}/photos/order [put]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *AlbumHandler) MoveAlbumPhotos(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("albumId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	var orderDto dto.AlbumPhotoOrder
+	if err := ctx.ShouldBindJSON(&orderDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&orderDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	if !h.authorizeAlbum(ctx, parsedID, policy.Update) {
+		return
+	}
+	ids, err := h.albums.MoveAlbumPhotos(uint(parsedID), orderDto.PhotoIDs, orderDto.Position)
+	h.respondAlbumPhotos(ctx, parsedID, ids, err)
+}
+
+// bindAlbumPhotos reads the photos added to or removed from the album in
+// the path, once the logged in user is found to own it, aborting the
+// request if not.
+func (h *AlbumHandler) bindAlbumPhotos(ctx *gin.Context) (uint64, dto.AlbumPhotos, bool) {
+	var photosDto dto.AlbumPhotos
+	parsedID, err := strconv.ParseUint(ctx.Param("albumId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return parsedID, photosDto, false
+	}
+	if err := ctx.ShouldBindJSON(&photosDto); err != nil {
+		abortBadRequest(err, ctx)
+		return parsedID, photosDto, false
+	}
+	if err := validate.Struct(&photosDto); err != nil {
+		validationAbort(err, ctx)
+		return parsedID, photosDto, false
+	}
+	return parsedID, photosDto, h.authorizeAlbum(ctx, parsedID, policy.Update)
+}
+
+func (h *AlbumHandler) respondAlbumPhotos(ctx *gin.Context, albumID uint64, ids []uint, err error) {
+	if err != nil {
+		abortAlbumError(ctx, err, albumID)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.AlbumPhotos{
+		AlbumID:  uint(albumID),
+		PhotoIDs: ids,
+	})
+}
+
+// authorizeAlbum reports whether the logged in user may do action on album
+// albumID, aborting the request if not.
+func (h *AlbumHandler) authorizeAlbum(ctx *gin.Context, albumID uint64, action policy.Action) bool {
+	principal := middlewares.CurrentPrincipal(ctx)
+	album, err := h.albums.GetSingleAlbum(uint(albumID))
+	if err == nil && !visible(principal, album) && !policy.Can(principal, action, policy.Album(album)) {
+		// Private albums are not found rather than forbidden to others.
+		err = database.ErrNotFound
+	}
+	if err == nil {
+		err = policy.Authorize(principal, action, policy.Album(album))
+	}
+	if err != nil {
+		abortAlbumError(ctx, err, albumID)
+		return false
+	}
+	return true
+}
+
+// ownPhotos reports whether every photo of photoIDs exists and may be
+// updated by the logged in user, aborting the request if not. Albums only
+// hold photos of their user.
+func (h *AlbumHandler) ownPhotos(ctx *gin.Context, photoIDs []uint) bool {
+	photos, err := h.photos.GetPhotos(photoIDs)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return false
+	}
+	found := make(map[uint]models.Photo, len(photos))
+	for _, photo := range photos {
+		found[photo.ID] = photo
+	}
+	principal := middlewares.CurrentPrincipal(ctx)
+	for _, photoID := range photoIDs {
+		photo, ok := found[photoID]
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Photo with ID %d is not found.", photoID),
+			})
+			return false
+		}
+		if err := policy.Authorize(principal, policy.Update, policy.Photo(photo)); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return false
+		}
+	}
+	return true
+}
+
+func abortAlbumError(ctx *gin.Context, err error, albumID uint64) {
+	if errors.Is(err, database.ErrNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+			ErrorMessage: fmt.Sprintf("Album with ID %d is not found.", albumID),
+		})
+		return
+	}
+	if errors.Is(err, database.ErrIllegalUpdate) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorMessage{
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, database.ErrNotInAlbum) {
+		abortBadRequest(err, ctx)
+		return
+	}
+	ctx.AbortWithError(http.StatusInternalServerError, err)
+}
+
+// visible reports whether principal may see album, private albums are only
+// shown to their user.
+func visible(principal middlewares.Principal, album models.Album) bool {
+	return album.Visibility != models.AlbumPrivate || principal.UserID == album.UserID
+}
+
+func albumResponse(album models.Album) responses.Album {
+	return responses.Album{
+		ID:           album.ID,
+		Title:        album.Title,
+		Description:  album.Description,
+		CoverPhotoID: album.CoverPhotoID,
+		Visibility:   album.Visibility,
+		UserID:       album.UserID,
+	}
+}
@@ -0,0 +1,44 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+// TestGetAlbum checks an album lists its photos in their order, shaped like
+// those of the photo endpoints.
+func TestGetAlbum(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	other := s.user("other", models.RoleUser)
+	first := s.create("/photos/", owner, newPhoto("first"))
+	second := s.create("/photos/", owner, newPhoto("second"))
+	albumID := s.create("/albums/", owner, map[string]string{"title": "trip", "visibility": "private"})
+	path := fmt.Sprint("/albums/", albumID)
+	expectStatus(t, s.do("POST", path+"/photos", owner, map[string]interface{}{"photo_ids": []uint{second, first}}), http.StatusOK)
+
+	rec := s.do("GET", path, owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var album struct {
+		Title  string `json:"title"`
+		Photos []struct {
+			ID    uint   `json:"id"`
+			Title string `json:"title"`
+			User  struct {
+				Username string `json:"username"`
+			}
+		} `json:"photos"`
+	}
+	decode(t, rec, &album)
+	if album.Title != "trip" || len(album.Photos) != 2 ||
+		album.Photos[0].ID != second || album.Photos[1].ID != first ||
+		album.Photos[0].Title != "second" || album.Photos[0].User.Username != "owner" {
+		t.Errorf("got %s", rec.Body)
+	}
+
+	// Private albums are only found by their user.
+	expectError(t, s.do("GET", path, other, nil), http.StatusNotFound, fmt.Sprintf("Album with ID %d is not found.", albumID))
+}
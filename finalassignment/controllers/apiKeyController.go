@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeys database.APIKeyRepository
+}
+
+func NewAPIKeyHandler(apiKeys database.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{apiKeys: apiKeys}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Create an API key for the logged in user. Scripts send it as "Authorization: ApiKey <key>" to act as the user on photos, comments and social medias, within the scopes of the key. The key is only shown in this response.
+// @Tags         apiKeys
+// @Accept       json
+// @Produce      json
+// @Param        apiKey body dto.APIKey true "Name and scopes of the key."
+// @Success      201  {object}  responses.CreateAPIKey
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/apikeys [post]
+// @Security	 BearerAuth
+func (h *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	var apiKeyDto dto.APIKey
+	if err := ctx.ShouldBindJSON(&apiKeyDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&apiKeyDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	key, hash, err := token.GenerateAPIKey()
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	apiKey := models.APIKey{
+		UserID:  middlewares.CurrentPrincipal(ctx).UserID,
+		Name:    apiKeyDto.Name,
+		Prefix:  key[:token.APIKeyPrefixLength],
+		KeyHash: hash,
+		Scope:   strings.Join(apiKeyDto.Scopes, " "),
+	}
+	if err := h.apiKeys.CreateAPIKey(&apiKey); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	response := responses.CreateAPIKey{Key: key}
+	response.Set(apiKey)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetAPIKeys godoc
+// @Summary      Get API keys
+// @Description  Get the API keys of the logged in user. The keys themselves are not shown, only their prefix.
+// @Tags         apiKeys
+// @Produce      json
+// @Success      200  {object}  []responses.APIKey
+// @Failure      500  {object}  nil
+// @Router       /users/apikeys [get]
+// @Security	 BearerAuth
+func (h *APIKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	apiKeys, err := h.apiKeys.GetUserAPIKeys(middlewares.CurrentPrincipal(ctx).UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	apiKeysResponse := make([]responses.APIKey, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		apiKeysResponse[i].Set(apiKey)
+	}
+	ctx.JSON(http.StatusOK, apiKeysResponse)
+}
+
+// DeleteAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Revoke an API key of the logged in user. Requests using it are rejected from now on.
+// @Tags         apiKeys
+// @Produce      json
+// @Param		 apiKeyId path uint true "ID number of the API key"
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/apikeys/{apiKeyId} [delete]
+// @Security	 BearerAuth
+func (h *APIKeyHandler) DeleteAPIKey(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("apiKeyId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := h.apiKeys.DeleteUserAPIKey(middlewares.CurrentPrincipal(ctx).UserID, uint(parsedID)); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("API key with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "Your API key has been revoked.",
+	})
+}
@@ -0,0 +1,131 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/token"
+)
+
+// apiKey creates an API key with scopes for the user of accessToken and
+// returns its ID and the key.
+func (s *server) apiKey(accessToken string, scopes ...string) (uint, string) {
+	s.t.Helper()
+	rec := s.do("POST", "/users/apikeys", accessToken, map[string]interface{}{"name": "script", "scopes": scopes})
+	expectStatus(s.t, rec, http.StatusCreated)
+	var created struct {
+		ID     uint   `json:"id"`
+		Prefix string `json:"prefix"`
+		Key    string `json:"key"`
+	}
+	decode(s.t, rec, &created)
+	if !strings.HasPrefix(created.Key, "fa_") || created.Prefix != created.Key[:token.APIKeyPrefixLength] {
+		s.t.Fatalf("created key %q with prefix %q", created.Key, created.Prefix)
+	}
+	return created.ID, created.Key
+}
+
+// doWithKey is do authorized by an API key.
+func (s *server) doWithKey(method, path, apiKey string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+	return s.send(req, "")
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	s := newServer(t)
+	accessToken := s.user("alice", models.RoleUser)
+	_, key := s.apiKey(accessToken, models.ScopePhotosRead, models.ScopeCommentsWrite)
+	photoID := s.create("/photos/", accessToken, newPhoto("first"))
+
+	expectStatus(t, s.doWithKey("GET", "/photos/", key, nil), http.StatusOK)
+	expectStatus(t, s.doWithKey("GET", fmt.Sprint("/photos/", photoID), key, nil), http.StatusOK)
+	rec := s.doWithKey("POST", fmt.Sprint("/photos/", photoID, "/comments"), key, map[string]string{"message": "nice"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	// Everything else needs a scope the key doesn't have.
+	tests := []struct {
+		method, path, scope string
+	}{
+		{"POST", "/photos/", models.ScopePhotosWrite},
+		{"DELETE", fmt.Sprint("/photos/", photoID), models.ScopePhotosWrite},
+		{"GET", "/comments/", models.ScopeCommentsRead},
+		{"GET", "/socialmedias/", models.ScopeSocialMediasRead},
+		{"POST", "/socialmedias/", models.ScopeSocialMediasWrite},
+	}
+	for _, tt := range tests {
+		expectError(t, s.doWithKey(tt.method, tt.path, key, newPhoto("second")), http.StatusForbidden,
+			fmt.Sprintf("The API key needs the %s scope.", tt.scope))
+	}
+	expectStatus(t, s.do("GET", fmt.Sprint("/photos/", photoID), accessToken, nil), http.StatusOK)
+
+	// Keys can't manage keys or the account.
+	expectError(t, s.doWithKey("GET", "/users/apikeys", key, nil), http.StatusUnauthorized, middlewares.ErrKeyForbidden.Error())
+	expectError(t, s.doWithKey("DELETE", "/users", key, nil), http.StatusUnauthorized, middlewares.ErrKeyForbidden.Error())
+}
+
+func TestAPIKeyRejected(t *testing.T) {
+	s := newServer(t)
+	accessToken := s.user("alice", models.RoleUser)
+	bobsToken := s.user("bob", models.RoleUser)
+	keyID, key := s.apiKey(accessToken, models.ScopePhotosRead)
+	_, other := s.apiKey(accessToken, models.ScopePhotosRead)
+	expectStatus(t, s.doWithKey("GET", "/photos/", key, nil), http.StatusOK)
+
+	// Only the prefix of a key is stored in the clear, and it is not enough.
+	forged := key[:token.APIKeyPrefixLength] + strings.Repeat("A", len(key)-token.APIKeyPrefixLength)
+	expectError(t, s.doWithKey("GET", "/photos/", forged, nil), http.StatusUnauthorized, middlewares.ErrInvalidKey.Error())
+	expectError(t, s.doWithKey("GET", "/photos/", key+"x", nil), http.StatusUnauthorized, middlewares.ErrInvalidKey.Error())
+
+	// The key is listed by its prefix, with when it was last used.
+	rec := s.do("GET", "/users/apikeys", accessToken, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), key) {
+		t.Errorf("the key is listed in %s", rec.Body)
+	}
+	var keys []struct {
+		ID         uint       `json:"id"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+	}
+	decode(t, rec, &keys)
+	if len(keys) != 2 || keys[0].ID != keyID || keys[0].LastUsedAt == nil || keys[1].LastUsedAt != nil {
+		t.Errorf("got keys %s", rec.Body)
+	}
+
+	// Only the owner can revoke a key.
+	path := fmt.Sprint("/users/apikeys/", keyID)
+	expectStatus(t, s.do("DELETE", path, bobsToken, nil), http.StatusNotFound)
+	expectStatus(t, s.do("DELETE", path, accessToken, nil), http.StatusOK)
+	expectError(t, s.doWithKey("GET", "/photos/", key, nil), http.StatusUnauthorized, middlewares.ErrInvalidKey.Error())
+	expectStatus(t, s.do("DELETE", path, accessToken, nil), http.StatusNotFound)
+	expectStatus(t, s.doWithKey("GET", "/photos/", other, nil), http.StatusOK)
+
+	// Keys of suspended users stop working until the suspension is lifted.
+	user, err := s.repos.Users.GetUserByEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := s.repos.Users.SuspendUser(user.ID, &now); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, s.doWithKey("GET", "/photos/", other, nil), http.StatusForbidden, middlewares.ErrSuspended.Error())
+	if _, err := s.repos.Users.SuspendUser(user.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, s.doWithKey("GET", "/photos/", other, nil), http.StatusOK)
+}
@@ -9,25 +9,40 @@ import (
 	"finalassignment.id/finalassignment/controllers/responses"
 	"finalassignment.id/finalassignment/database"
 	"finalassignment.id/finalassignment/dto"
-	"finalassignment.id/finalassignment/models"
-	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/policy"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// maxReplyDepth is how deep replies can be nested below a top-level
+// comment.
+const maxReplyDepth = 5
+
+type CommentHandler struct {
+	comments database.CommentRepository
+	photos   database.PhotoRepository
+}
+
+func NewCommentHandler(comments database.CommentRepository, photos database.PhotoRepository) *CommentHandler {
+	return &CommentHandler{comments: comments, photos: photos}
+}
+
 // CreateComment godoc
 // @Summary      Create a Comment
-// @Description  Create a Comment associated with the logged in user.
+// @Description  Create a Comment associated with the logged in user, or a reply to another comment on the same photo with parent_id. Users have to verify their email first, unless verification.required is off.
 // @Tags         comments
 // @Accept       json
 // @Produce      json
 // @Param        comment body dto.Comment true "JSON of the comment to be made. Caption is not mandatory."
 // @Success      201  {object}  responses.CreateComment
 // @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /comments [post]
 // @Security	 BearerAuth
-func CreateComment(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) CreateComment(ctx *gin.Context) {
 	var newComment dto.Comment
 	if err := ctx.ShouldBindJSON(&newComment); err != nil {
 		abortBadRequest(err, ctx)
@@ -37,12 +52,83 @@ func CreateComment(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
+	h.createComment(ctx, &newComment)
+}
+
+// CreatePhotoComment godoc
+// @Summary      Comment on a photo
+// @Description  Create a comment on a photo associated with the logged in user, or a reply to another comment on it with parent_id. Users have to verify their email first, unless verification.required is off.
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param		 photoId path uint true "ID number of the photo"
+// @Param        comment body dto.PhotoComment true "JSON of the comment to be made."
+// @Success      201  {object}  responses.CreateComment
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /photos/{photoId}/comments [post]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) CreatePhotoComment(ctx *gin.Context) {
+	photoID, err := strconv.ParseUint(ctx.Param("photoId"), 10, 0)
 	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
+		abortBadRequest(err, ctx)
+		return
+	}
+	var newComment dto.PhotoComment
+	if err := ctx.ShouldBindJSON(&newComment); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&newComment); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	h.createComment(ctx, &dto.Comment{
+		Message:  newComment.Message,
+		PhotoID:  uint(photoID),
+		ParentID: newComment.ParentID,
+	})
+}
+
+// createComment makes the comment once the photo it is on and the comment
+// it replies to are found, aborting the request if not.
+func (h *CommentHandler) createComment(ctx *gin.Context, commentDto *dto.Comment) {
+	if !h.findPhoto(ctx, commentDto.PhotoID) {
 		return
 	}
-	comment, err := database.CreateComment(userID, &newComment)
+	if commentDto.ParentID != 0 {
+		parent, err := h.comments.GetSingleComment(commentDto.ParentID)
+		if err == nil && parent.DeletedAt != nil {
+			err = database.ErrNotFound
+		}
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+					ErrorMessage: fmt.Sprintf("Comment with ID %d is not found.", commentDto.ParentID),
+				})
+				return
+			}
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if parent.PhotoID != commentDto.PhotoID {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: "Replies have to be on the photo of the comment they reply to.",
+			})
+			return
+		}
+		if parent.Depth >= maxReplyDepth {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Replies can be nested at most %d deep.", maxReplyDepth),
+			})
+			return
+		}
+	}
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	comment, err := h.comments.CreateComment(userID, commentDto)
 	if err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -52,60 +138,152 @@ func CreateComment(ctx *gin.Context) {
 		Message:   comment.Message,
 		PhotoID:   comment.PhotoID,
 		UserID:    userID,
+		ParentID:  comment.ParentID,
+		Depth:     comment.Depth,
 		CreatedAt: comment.CreatedAt,
 	})
 }
 
+// findPhoto reports whether photo photoID exists, aborting the request if
+// not.
+func (h *CommentHandler) findPhoto(ctx *gin.Context, photoID uint) bool {
+	if _, err := h.photos.GetSinglePhoto(photoID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Photo with ID %d is not found.", photoID),
+			})
+			return false
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return false
+	}
+	return true
+}
+
 // GetComments godoc
 // @Summary      Get comments
-// @Description  Get comments.
+// @Description  Get a page of comments, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.
 // @Tags         comments
 // @Accept       json
 // @Produce      json
+// @Param        query query dto.CommentListQuery false "Paging, sorting and filters"
 // @Success      200  {object}  []responses.GetComment
+// @Header       200  {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure		 400 {object} responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /comments [get]
 // @Security	 BearerAuth
-func GetAllComments(ctx *gin.Context) {
-	comments, err := database.GetAllComments()
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) GetAllComments(ctx *gin.Context) {
+	var query dto.CommentListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	options, ok := listOptions(ctx, query.ListQuery)
+	if !ok {
+		return
+	}
+	options.PhotoID = query.PhotoID
+	h.listComments(ctx, options)
+}
+
+// GetPhotoComments godoc
+// @Summary      Get the comments of a photo
+// @Description  Get a page of the top-level comments of a photo, or of the replies to one of them with parent_id, oldest first unless sorted otherwise. Comments deleted while they had replies are kept without their message and user, with deleted_at set.
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param		 photoId path uint true "ID number of the photo"
+// @Param        query query dto.PhotoCommentListQuery false "Paging, sorting and filters"
+// @Success      200  {object}  []responses.GetComment
+// @Header       200  {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
+// @Failure		 400 {object} responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /photos/{photoId}/comments [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) GetPhotoComments(ctx *gin.Context) {
+	photoID, err := strconv.ParseUint(ctx.Param("photoId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	var query dto.PhotoCommentListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	options, ok := listOptions(ctx, query.ListQuery)
+	if !ok || !h.findPhoto(ctx, uint(photoID)) {
+		return
+	}
+	options.PhotoID = uint(photoID)
+	options.ParentID = query.ParentID
+	options.TopLevel = query.ParentID == 0
+	h.listComments(ctx, options)
+}
+
+func (h *CommentHandler) listComments(ctx *gin.Context, options database.ListOptions) {
+	comments, next, err := h.comments.GetAllComments(options)
 	if err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 	commentsResponse := make([]responses.GetComment, len(comments))
-	users := make(map[uint]models.User)
-	photos := make(map[uint]models.Photo)
 	for i, comment := range comments {
 		commentsResponse[i].Set(comment)
-		user, ok := users[comment.UserID]
-		if !ok {
-			user, err = database.GetUserWithoutPreload(comment.UserID)
-			if err != nil {
-				ctx.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-			users[comment.UserID] = user
-		}
-		commentsResponse[i].User = responses.UserComment{
-			ID:       user.ID,
-			Email:    user.Email,
-			Username: user.Username,
-		}
-		photo, ok := photos[comment.PhotoID]
-		if !ok {
-			photo, err = database.GetSinglePhoto(comment.PhotoID)
-			if err != nil {
-				ctx.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-			photos[comment.PhotoID] = photo
-		}
-		commentsResponse[i].Photo = photo
 	}
+	setNextCursor(ctx, options, next)
 	ctx.JSON(http.StatusOK, commentsResponse)
 }
 
+// GetComment godoc
+// @Summary      Get a comment
+// @Description  Get a comment along with its user and the photo it is on.
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param		 commentId path uint true "ID number of the comment"
+// @Success      200  {object}  responses.GetComment
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /comments/{commentId} [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) GetComment(ctx *gin.Context) {
+	commentID := ctx.Param("commentId")
+	parsedID, err := strconv.ParseUint(commentID, 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	comment, err := h.comments.GetCommentWithUserAndPhoto(uint(parsedID))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Comment with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	var response responses.GetComment
+	response.Set(comment)
+	ctx.JSON(http.StatusOK, response)
+}
+
 // UpdateComment godoc
 // @Summary      Update a comment
 // @Description  Update a comment associated with logged in user.
@@ -121,7 +299,8 @@ func GetAllComments(ctx *gin.Context) {
 // @Failure      500  {object}  nil
 // @Router       /comments/{commentId} [put]
 // @Security	 BearerAuth
-func UpdateComment(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) UpdateComment(ctx *gin.Context) {
 	commentID := ctx.Param("commentId")
 	parsedID, err := strconv.ParseUint(commentID, 10, 0)
 	if err != nil {
@@ -137,14 +316,18 @@ func UpdateComment(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
+	comment, err := h.comments.GetSingleComment(uint(parsedID))
+	if err == nil && comment.DeletedAt != nil {
+		err = database.ErrNotFound
+	}
+	if err == nil {
+		err = policy.Authorize(middlewares.CurrentPrincipal(ctx), policy.Update, policy.Comment(comment))
+	}
+	if err == nil {
+		comment, err = h.comments.UpdateComment(uint(parsedID), &commentDto)
 	}
-	comment, err := database.UpdateComment(uint(parsedID), userID, &commentDto)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, database.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
 				ErrorMessage: fmt.Sprintf("Comment with ID %d is not found.", parsedID),
 			})
@@ -164,7 +347,7 @@ func UpdateComment(ctx *gin.Context) {
 
 // DeleteComment godoc
 // @Summary      Delete a comment
-// @Description  Delete a comment associated with logged in user.
+// @Description  Delete a comment associated with logged in user. Moderators can delete any comment. A comment with replies is kept without its message and user until they are deleted too.
 // @Tags         comments
 // @Accept       json
 // @Produce      json
@@ -175,21 +358,28 @@ func UpdateComment(ctx *gin.Context) {
 // @Failure      404  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /comments/{commentId} [delete]
+// @Router       /admin/comments/{commentId} [delete]
 // @Security	 BearerAuth
-func DeleteComment(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *CommentHandler) DeleteComment(ctx *gin.Context) {
 	commentID := ctx.Param("commentId")
 	parsedID, err := strconv.ParseUint(commentID, 10, 0)
 	if err != nil {
 		abortBadRequest(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
+	comment, err := h.comments.GetSingleComment(uint(parsedID))
+	if err == nil && comment.DeletedAt != nil {
+		err = database.ErrNotFound
 	}
-	if err := database.DeleteComment(uint(parsedID), userID); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	if err == nil {
+		err = policy.Authorize(middlewares.CurrentPrincipal(ctx), policy.Delete, policy.Comment(comment))
+	}
+	if err == nil {
+		err = h.comments.DeleteComment(uint(parsedID))
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
 				ErrorMessage: fmt.Sprintf("Comment with ID %d is not found.", parsedID),
 			})
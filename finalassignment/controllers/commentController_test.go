@@ -0,0 +1,181 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+func TestCommentCRUD(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	photoID := s.create("/photos/", owner, newPhoto("photo"))
+	commentID := s.create("/comments/", owner, map[string]interface{}{"message": "first", "photo_id": photoID})
+	path := fmt.Sprint("/comments/", commentID)
+
+	expectStatus(t, s.do("PUT", path, owner, map[string]interface{}{"message": "edited"}), http.StatusOK)
+	rec := s.do("GET", fmt.Sprint("/comments/?photo_id=", photoID), owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var list []struct {
+		ID      uint   `json:"id"`
+		Message string `json:"message"`
+		Photo   struct {
+			Title string `json:"title"`
+		}
+	}
+	decode(t, rec, &list)
+	if len(list) != 1 || list[0].Message != "edited" || list[0].Photo.Title != "photo" {
+		t.Errorf("got %+v", list)
+	}
+
+	expectStatus(t, s.do("DELETE", path, owner, nil), http.StatusOK)
+	expectError(t, s.do("GET", path, owner, nil), http.StatusNotFound, fmt.Sprintf("Comment with ID %d is not found.", commentID))
+}
+
+func TestCommentOnMissingPhoto(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	rec := s.do("POST", "/comments/", owner, map[string]interface{}{"message": "lost", "photo_id": 42})
+	expectError(t, rec, http.StatusNotFound, "Photo with ID 42 is not found.")
+}
+
+func TestCommentOwnership(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	other := s.user("other", models.RoleUser)
+	moderator := s.user("moderator", models.RoleModerator)
+	photoID := s.create("/photos/", other, newPhoto("theirs"))
+	commentID := s.create("/comments/", owner, map[string]interface{}{"message": "mine", "photo_id": photoID})
+	path := fmt.Sprint("/comments/", commentID)
+
+	// Owning the photo doesn't make its comments yours.
+	expectError(t, s.do("PUT", path, other, map[string]interface{}{"message": "stolen"}), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	expectError(t, s.do("DELETE", path, other, nil), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	comment, err := s.repos.Comments.GetSingleComment(commentID)
+	if err != nil || comment.Message != "mine" {
+		t.Fatalf("got %+v, %v", comment, err)
+	}
+	expectStatus(t, s.do("DELETE", "/admin"+path, moderator, nil), http.StatusOK)
+	if _, err := s.repos.Comments.GetSingleComment(commentID); err != database.ErrNotFound {
+		t.Errorf("got %v after deleting, want ErrNotFound", err)
+	}
+}
+
+type comment struct {
+	ID         uint       `json:"id"`
+	Message    string     `json:"message"`
+	ParentID   *uint      `json:"parent_id"`
+	Depth      uint       `json:"depth"`
+	ReplyCount uint       `json:"reply_count"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+	User       struct {
+		ID uint `json:"id"`
+	}
+}
+
+// comments lists the comments at path.
+func (s *server) comments(accessToken, path string) []comment {
+	s.t.Helper()
+	rec := s.do("GET", path, accessToken, nil)
+	expectStatus(s.t, rec, http.StatusOK)
+	var list []comment
+	decode(s.t, rec, &list)
+	return list
+}
+
+// reply answers the comment parentID on the photo photoID.
+func (s *server) reply(accessToken string, photoID, parentID uint, message string) uint {
+	s.t.Helper()
+	return s.create(fmt.Sprint("/photos/", photoID, "/comments"), accessToken, map[string]interface{}{"message": message, "parent_id": parentID})
+}
+
+func TestCommentReplies(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	photoID := s.create("/photos/", owner, newPhoto("photo"))
+	otherPhotoID := s.create("/photos/", owner, newPhoto("other"))
+	topID := s.create(fmt.Sprint("/photos/", photoID, "/comments"), owner, map[string]string{"message": "top"})
+	firstID := s.reply(owner, photoID, topID, "first")
+	s.create("/comments/", owner, map[string]interface{}{"message": "second", "photo_id": photoID, "parent_id": topID})
+	s.reply(owner, photoID, firstID, "nested")
+
+	// Replies stay on the photo of their parent.
+	path := fmt.Sprint("/photos/", otherPhotoID, "/comments")
+	message := "Replies have to be on the photo of the comment they reply to."
+	expectError(t, s.do("POST", path, owner, map[string]interface{}{"message": "lost", "parent_id": topID}), http.StatusBadRequest, message)
+	expectError(t, s.do("POST", "/comments/", owner, map[string]interface{}{"message": "lost", "photo_id": otherPhotoID, "parent_id": topID}), http.StatusBadRequest, message)
+	expectError(t, s.do("POST", path, owner, map[string]interface{}{"message": "lost", "parent_id": 42}), http.StatusNotFound, "Comment with ID 42 is not found.")
+
+	top := s.comments(owner, fmt.Sprint("/photos/", photoID, "/comments"))
+	if len(top) != 1 || top[0].ID != topID || top[0].ReplyCount != 2 || top[0].Depth != 0 || top[0].ParentID != nil {
+		t.Errorf("got top-level comments %+v", top)
+	}
+	replies := s.comments(owner, fmt.Sprint("/photos/", photoID, "/comments?parent_id=", topID))
+	if len(replies) != 2 || replies[0].ID != firstID || replies[0].ReplyCount != 1 || replies[0].Depth != 1 || *replies[0].ParentID != topID || replies[1].ReplyCount != 0 {
+		t.Errorf("got replies %+v", replies)
+	}
+	if others := s.comments(owner, fmt.Sprint("/photos/", otherPhotoID, "/comments")); len(others) != 0 {
+		t.Errorf("got comments %+v on the other photo", others)
+	}
+}
+
+func TestCommentMaxDepth(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	photoID := s.create("/photos/", owner, newPhoto("photo"))
+	parentID := s.create(fmt.Sprint("/photos/", photoID, "/comments"), owner, map[string]string{"message": "depth 0"})
+	for depth := 1; depth <= 5; depth++ {
+		parentID = s.reply(owner, photoID, parentID, fmt.Sprint("depth ", depth))
+	}
+	rec := s.do("POST", fmt.Sprint("/photos/", photoID, "/comments"), owner, map[string]interface{}{"message": "too deep", "parent_id": parentID})
+	expectError(t, rec, http.StatusBadRequest, "Replies can be nested at most 5 deep.")
+}
+
+// TestCommentTombstone checks a comment deleted while it has replies is
+// kept without its message and user, and goes away with its last reply.
+func TestCommentTombstone(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	other := s.user("other", models.RoleUser)
+	photoID := s.create("/photos/", owner, newPhoto("photo"))
+	photoComments := fmt.Sprint("/photos/", photoID, "/comments")
+	topID := s.create(photoComments, owner, map[string]string{"message": "top"})
+	firstID := s.reply(other, photoID, topID, "first")
+	secondID := s.reply(other, photoID, topID, "second")
+
+	expectStatus(t, s.do("DELETE", fmt.Sprint("/comments/", topID), owner, nil), http.StatusOK)
+	top := s.comments(owner, photoComments)
+	if len(top) != 1 || top[0].ID != topID || top[0].DeletedAt == nil || top[0].Message != "" || top[0].User.ID != 0 || top[0].ReplyCount != 2 {
+		t.Fatalf("got top-level comments %+v, want the tombstone", top)
+	}
+	rec := s.do("GET", fmt.Sprint("/comments/", topID), owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var tombstone comment
+	decode(t, rec, &tombstone)
+	if tombstone.DeletedAt == nil || tombstone.Message != "" {
+		t.Errorf("got %+v, want the tombstone", tombstone)
+	}
+	if replies := s.comments(owner, fmt.Sprint(photoComments, "?parent_id=", topID)); len(replies) != 2 {
+		t.Errorf("got replies %+v, want both kept", replies)
+	}
+
+	// Tombstones can't be replied to, edited or deleted again.
+	notFound := fmt.Sprintf("Comment with ID %d is not found.", topID)
+	expectError(t, s.do("POST", photoComments, other, map[string]interface{}{"message": "late", "parent_id": topID}), http.StatusNotFound, notFound)
+	expectError(t, s.do("PUT", fmt.Sprint("/comments/", topID), owner, map[string]string{"message": "back"}), http.StatusNotFound, notFound)
+	expectError(t, s.do("DELETE", fmt.Sprint("/comments/", topID), owner, nil), http.StatusNotFound, notFound)
+
+	expectStatus(t, s.do("DELETE", fmt.Sprint("/comments/", firstID), other, nil), http.StatusOK)
+	if top := s.comments(owner, photoComments); len(top) != 1 || top[0].ReplyCount != 1 {
+		t.Errorf("got top-level comments %+v, want the tombstone with one reply", top)
+	}
+	expectStatus(t, s.do("DELETE", fmt.Sprint("/comments/", secondID), other, nil), http.StatusOK)
+	if top := s.comments(owner, photoComments); len(top) != 0 {
+		t.Errorf("got top-level comments %+v, want the tombstone gone with its last reply", top)
+	}
+	expectError(t, s.do("GET", fmt.Sprint("/comments/", topID), owner, nil), http.StatusNotFound, notFound)
+}
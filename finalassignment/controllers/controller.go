@@ -10,8 +10,6 @@ import (
 
 var validate = validator.New()
 
-const uniqueViolationErr = "23505"
-
 func validationAbort(err error, ctx *gin.Context) {
 	var errorMessage string
 	for _, err := range err.(validator.ValidationErrors) {
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"net/http"
+
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS godoc
+// @Summary      Get the JSON Web Key Set
+// @Description  Public keys other services can verify our RS256 and EdDSA access tokens with. Tokens name their key in the kid header. HMAC keys are never published.
+// @Tags         keys
+// @Produce      json
+// @Success      200  {object}  token.JWKS
+// @Router       /.well-known/jwks.json [get]
+func GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, token.PublicKeys())
+}
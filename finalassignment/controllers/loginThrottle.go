@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+	"github.com/gin-gonic/gin"
+)
+
+// loginKeys returns the keys failed logins to email from the client of ctx
+// are counted under, one for the account and one for the client IP, with the
+// failures each is allowed.
+func (h *UserHandler) loginKeys(ctx *gin.Context, email string) map[string]int {
+	return map[string]int{
+		accountLoginKey(email): h.login.MaxAttempts,
+		"ip:" + ctx.ClientIP(): h.login.IPMaxAttempts,
+	}
+}
+
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+// resetKeys are like loginKeys for password reset requests, which are
+// counted apart so asking for resets doesn't lock anyone out of logging in.
+func (h *UserHandler) resetKeys(ctx *gin.Context, email string) map[string]int {
+	return map[string]int{
+		accountResetKey(email):       h.login.MaxAttempts,
+		"reset:ip:" + ctx.ClientIP(): h.login.IPMaxAttempts,
+	}
+}
+
+func accountResetKey(email string) string {
+	return "reset:" + accountLoginKey(email)
+}
+
+// abortLoginLocked aborts with 429 and returns true when the account of
+// email or the client IP is locked out by earlier failed logins.
+func (h *UserHandler) abortLoginLocked(ctx *gin.Context, email string) bool {
+	return h.abortLocked(ctx, h.loginKeys(ctx, email), "Too many failed logins, please wait before trying again.")
+}
+
+// abortLocked aborts with 429 and message and returns true when any of keys
+// is locked out.
+func (h *UserHandler) abortLocked(ctx *gin.Context, keys map[string]int, message string) bool {
+	now := time.Now()
+	var wait time.Duration
+	for key, maxAttempts := range keys {
+		attempt, err := h.loginAttempts.GetLoginAttempt(key)
+		if errors.Is(err, database.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return true
+		}
+		if locked := h.lockedUntil(attempt, maxAttempts).Sub(now); locked > wait {
+			wait = locked
+		}
+	}
+	if wait <= 0 {
+		return false
+	}
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, responses.ErrorMessage{
+		ErrorMessage: message,
+	})
+	return true
+}
+
+// recordLoginFailure counts a failed login to email from the client of ctx.
+func (h *UserHandler) recordLoginFailure(ctx *gin.Context, email string) error {
+	return h.recordFailure(h.loginKeys(ctx, email))
+}
+
+func (h *UserHandler) recordFailure(keys map[string]int) error {
+	now := time.Now()
+	for key := range keys {
+		if _, err := h.loginAttempts.RecordLoginFailure(key, now, now.Add(-h.login.Lockout)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockedUntil returns when an attempt with maxAttempts allowed failures
+// stops being locked out. The first lockout lasts the backoff and each
+// further failure doubles it, up to the configured lockout.
+func (h *UserHandler) lockedUntil(attempt models.LoginAttempt, maxAttempts int) time.Time {
+	if attempt.Failures < maxAttempts {
+		return time.Time{}
+	}
+	lockout := h.login.Backoff
+	for i := maxAttempts; i < attempt.Failures && lockout < h.login.Lockout; i++ {
+		lockout *= 2
+	}
+	if lockout > h.login.Lockout {
+		lockout = h.login.Lockout
+	}
+	return attempt.LastFailureAt.Add(lockout)
+}
@@ -0,0 +1,117 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+const tooManyLogins = "Too many failed logins, please wait before trying again."
+
+// loginFrom logs in to email with password from the client IP ip.
+func (s *server) loginFrom(ip, email, password string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	body, err := json.Marshal(map[string]string{"email": email, "password": password})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/users/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":1234"
+	return s.send(req, "")
+}
+
+// fail logs in to email with a wrong password from ip times times.
+func (s *server) fail(ip, email string, times int) {
+	s.t.Helper()
+	for i := 0; i < times; i++ {
+		expectError(s.t, s.loginFrom(ip, email, "wrong1"), http.StatusBadRequest, "Email or password is incorrect.")
+	}
+}
+
+func expectLocked(t *testing.T, rec *httptest.ResponseRecorder, retryAfter string) {
+	t.Helper()
+	expectError(t, rec, http.StatusTooManyRequests, tooManyLogins)
+	if got := rec.Header().Get("Retry-After"); got != retryAfter {
+		t.Errorf("got Retry-After %q, want %q", got, retryAfter)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "3", "-login-backoff", "10s", "-login-lockout", "1m")
+	s.user("alice", models.RoleUser)
+	s.user("bob", models.RoleUser)
+
+	s.fail("192.0.2.1", "alice@example.com", 3)
+	// Even the right password is refused while the account is locked, from
+	// any client.
+	expectLocked(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), "10")
+	expectLocked(t, s.loginFrom("192.0.2.2", "ALICE@example.com", "secret1"), "10")
+	expectStatus(t, s.loginFrom("192.0.2.1", "bob@example.com", "secret1"), http.StatusOK)
+
+	// Accounts that don't exist are locked out the same way.
+	s.fail("192.0.2.1", "nobody@example.com", 3)
+	expectLocked(t, s.loginFrom("192.0.2.1", "nobody@example.com", "secret1"), "10")
+}
+
+// TestLoginBackoff checks every failure past the allowed ones doubles the
+// lockout, up to the configured longest.
+func TestLoginBackoff(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "3", "-login-backoff", "10s", "-login-lockout", "1m")
+	s.user("alice", models.RoleUser)
+	s.fail("192.0.2.1", "alice@example.com", 3)
+	expectLocked(t, s.loginFrom("192.0.2.1", "alice@example.com", "wrong1"), "10")
+
+	// Failures can't be made while locked out, so they are recorded as if
+	// the lockouts had passed.
+	for _, retryAfter := range []string{"20", "40", "60", "60"} {
+		now := time.Now()
+		if _, err := s.repos.LoginAttempts.RecordLoginFailure("account:alice@example.com", now, now.Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		expectLocked(t, s.loginFrom("192.0.2.2", "alice@example.com", "secret1"), retryAfter)
+	}
+}
+
+func TestLoginLockoutExpires(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "2", "-login-backoff", "50ms", "-login-lockout", "1s")
+	s.user("alice", models.RoleUser)
+	s.fail("192.0.2.1", "alice@example.com", 2)
+	expectLocked(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), "1")
+
+	time.Sleep(60 * time.Millisecond)
+	expectStatus(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), http.StatusOK)
+}
+
+func TestLoginIPLockout(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "10", "-login-ip-max-attempts", "4", "-login-backoff", "10s", "-login-lockout", "1m")
+	s.user("alice", models.RoleUser)
+
+	// Failures against different accounts add up for the client.
+	for _, name := range []string{"a", "b", "c", "d"} {
+		s.fail("192.0.2.1", name+"@example.com", 1)
+	}
+	expectLocked(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), "10")
+	expectStatus(t, s.loginFrom("192.0.2.2", "alice@example.com", "secret1"), http.StatusOK)
+}
+
+// TestLoginSuccessResets checks logging in forgets the failures of the
+// account, but not those of the client.
+func TestLoginSuccessResets(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "3", "-login-ip-max-attempts", "5", "-login-backoff", "10s", "-login-lockout", "1m")
+	s.user("alice", models.RoleUser)
+
+	s.fail("192.0.2.1", "alice@example.com", 2)
+	expectStatus(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), http.StatusOK)
+	s.fail("192.0.2.1", "alice@example.com", 2)
+	expectStatus(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), http.StatusOK)
+
+	// The client is at 4 of its 5 failures.
+	s.fail("192.0.2.1", "alice@example.com", 1)
+	expectLocked(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), "10")
+}
@@ -0,0 +1,360 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/oidc"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcFlowCookie = "oidc_flow"
+	// oidcFlowTTL is how long users have to login at the provider.
+	oidcFlowTTL = 10 * time.Minute
+)
+
+// StartOIDCLogin godoc
+// @Summary      Login with the identity provider
+// @Description  Redirect the browser to the OpenID Connect provider to login. The provider redirects back to /users/oidc/callback, which logs in the user linked to the identity, or registers a new user. Only available when OpenID Connect login is configured.
+// @Tags         users
+// @Success      302
+// @Failure      500  {object}  nil
+// @Failure      502  {object}  responses.ErrorMessage
+// @Router       /users/oidc/login [get]
+func (h *UserHandler) StartOIDCLogin(ctx *gin.Context) {
+	authURL, ok := h.startOIDCFlow(ctx, 0)
+	if !ok {
+		return
+	}
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// StartOIDCLink godoc
+// @Summary      Link an identity
+// @Description  Start linking an account at the OpenID Connect provider to the logged in user, so they can login with it. Open the returned URL in the browser that made this request; the provider redirects back to /users/oidc/callback, which links the identity.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  responses.AuthorizationURL
+// @Failure      500  {object}  nil
+// @Failure      502  {object}  responses.ErrorMessage
+// @Router       /users/oidc/link [post]
+// @Security	 BearerAuth
+func (h *UserHandler) StartOIDCLink(ctx *gin.Context) {
+	authURL, ok := h.startOIDCFlow(ctx, middlewares.CurrentPrincipal(ctx).UserID)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.AuthorizationURL{AuthorizationURL: authURL})
+}
+
+// startOIDCFlow keeps a new flow in a cookie and returns the URL of the
+// provider to send the user to. It aborts and returns false on failure.
+func (h *UserHandler) startOIDCFlow(ctx *gin.Context, linkUserID uint) (string, bool) {
+	flow := token.OIDCFlow{LinkUserID: linkUserID}
+	var err error
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *value, _, err = token.GenerateOpaqueToken(); err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return "", false
+		}
+	}
+	authURL, err := h.identityProvider.AuthCodeURL(flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		abortProviderError(ctx, err)
+		return "", false
+	}
+	flowToken, err := token.GenerateOIDCFlowToken(flow, oidcFlowTTL)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return "", false
+	}
+	h.setOIDCFlowCookie(ctx, flowToken, int(oidcFlowTTL.Seconds()))
+	return authURL, true
+}
+
+// setOIDCFlowCookie sets the flow cookie, a negative maxAge deletes it. The
+// cookie has to be Lax rather than Strict to be sent along with the redirect
+// from the provider.
+func (h *UserHandler) setOIDCFlowCookie(ctx *gin.Context, value string, maxAge int) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcFlowCookie, value, maxAge, "/users/oidc", "", strings.HasPrefix(h.publicURL, "https://"), true)
+}
+
+// OIDCCallback godoc
+// @Summary      Finish a login with the identity provider
+// @Description  The OpenID Connect provider redirects here. When linking, the identity is linked to the user who started it. Otherwise the user linked to the identity is logged in; if there is none a new user is registered with the email and username from the provider, unless the email is already registered. Logins answer like /users/login.
+// @Tags         users
+// @Produce      json
+// @Param        code query string false "Authorization code"
+// @Param        state query string true "State of the login"
+// @Success      200  {object}  responses.UserLogin
+// @Success      201  {object}  responses.Identity
+// @Success      202  {object}  responses.LoginChallenge
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      401  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      409  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Failure      502  {object}  responses.ErrorMessage
+// @Router       /users/oidc/callback [get]
+func (h *UserHandler) OIDCCallback(ctx *gin.Context) {
+	flowToken, _ := ctx.Cookie(oidcFlowCookie)
+	flow, err := token.ParseOIDCFlowToken(flowToken)
+	if err == nil && ctx.Query("state") != flow.State {
+		err = token.ErrInvalidOIDCFlow
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	// The state matched, the flow is used up whatever happens next.
+	h.setOIDCFlowCookie(ctx, "", -1)
+	if providerError := ctx.Query("error"); providerError != "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+			ErrorMessage: fmt.Sprintf("The identity provider refused the login: %s.", providerError),
+		})
+		return
+	}
+	claims, err := h.identityProvider.Exchange(ctx.Query("code"), flow.Verifier, flow.Nonce)
+	if err != nil {
+		for _, authErr := range []error{oidc.ErrInvalidIDToken, oidc.ErrCodeRejected} {
+			if errors.Is(err, authErr) {
+				ctx.Error(err)
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+					ErrorMessage: authErr.Error(),
+				})
+				return
+			}
+		}
+		abortProviderError(ctx, err)
+		return
+	}
+	if flow.LinkUserID != 0 {
+		h.linkIdentity(ctx, flow.LinkUserID, claims)
+		return
+	}
+	identity, err := h.identities.GetIdentity(h.identityProvider.Issuer(), claims.Subject)
+	var user models.User
+	if err == nil {
+		user, err = h.users.GetUserWithoutPreload(identity.UserID)
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		var ok bool
+		if user, ok = h.registerOIDCUser(ctx, claims); !ok {
+			return
+		}
+		err = nil
+	}
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	h.completeLogin(ctx, user)
+}
+
+// linkIdentity links the identity claims are about to the user with userID.
+// Linking an identity the user has already is not an error.
+func (h *UserHandler) linkIdentity(ctx *gin.Context, userID uint, claims *oidc.Claims) {
+	user, err := h.users.GetUserWithoutPreload(userID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: token.ErrInvalidOIDCFlow.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if abortSuspended(ctx, user) {
+		return
+	}
+	identity := models.UserIdentity{
+		UserID:  userID,
+		Issuer:  h.identityProvider.Issuer(),
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}
+	status := http.StatusCreated
+	err = h.identities.CreateIdentity(&identity)
+	if errors.Is(err, database.ErrDuplicate) {
+		identity, err = h.identities.GetIdentity(identity.Issuer, identity.Subject)
+		if err == nil && identity.UserID != userID {
+			ctx.AbortWithStatusJSON(http.StatusConflict, responses.ErrorMessage{
+				ErrorMessage: "This identity is linked to another user.",
+			})
+			return
+		}
+		status = http.StatusOK
+	}
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	var response responses.Identity
+	response.Set(identity)
+	ctx.JSON(status, response)
+}
+
+// registerOIDCUser registers a user without a password for the identity
+// claims are about. It aborts and returns false on failure.
+func (h *UserHandler) registerOIDCUser(ctx *gin.Context, claims *oidc.Claims) (models.User, bool) {
+	if claims.Email == "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "The identity provider did not share an email, which is needed to register.",
+		})
+		return models.User{}, false
+	}
+	// Taking over an account by its email is only safe for emails the
+	// provider has verified, and not even then for providers anyone can
+	// register at, so existing users have to link the identity themselves.
+	_, err := h.users.GetUserByEmail(claims.Email)
+	if err == nil {
+		ctx.AbortWithStatusJSON(http.StatusConflict, responses.ErrorMessage{
+			ErrorMessage: fmt.Sprintf("The email %s is already registered. If it is yours, login and link the identity with /users/oidc/link.", claims.Email),
+		})
+		return models.User{}, false
+	}
+	if !errors.Is(err, database.ErrNotFound) {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return models.User{}, false
+	}
+	username := claims.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(claims.Email, "@")
+	}
+	user := models.User{
+		Username: username,
+		Email:    claims.Email,
+		Role:     models.RoleUser,
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	err = h.users.CreateUser(&user)
+	// Take another username when the one from the provider is in use.
+	for attempt := 0; errors.Is(err, database.ErrDuplicate) && attempt < 5; attempt++ {
+		var suffix string
+		if suffix, _, err = token.GenerateOpaqueToken(); err != nil {
+			break
+		}
+		user.Username = username + "-" + strings.ToLower(suffix[:6])
+		err = h.users.CreateUser(&user)
+	}
+	if err == nil {
+		err = h.identities.CreateIdentity(&models.UserIdentity{
+			UserID:  user.ID,
+			Issuer:  h.identityProvider.Issuer(),
+			Subject: claims.Subject,
+			Email:   claims.Email,
+		})
+		if err != nil {
+			if err := h.users.DeleteUserById(user.ID); err != nil {
+				ctx.Error(err)
+			}
+		}
+	}
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return models.User{}, false
+	}
+	if user.EmailVerifiedAt == nil {
+		if _, err := h.sendVerification(user); err != nil {
+			ctx.Error(err)
+		}
+	}
+	return user, true
+}
+
+// GetIdentities godoc
+// @Summary      Get linked identities
+// @Description  Get the identities at the OpenID Connect provider linked to the logged in user.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  []responses.Identity
+// @Failure      500  {object}  nil
+// @Router       /users/identities [get]
+// @Security	 BearerAuth
+func (h *UserHandler) GetIdentities(ctx *gin.Context) {
+	identities, err := h.identities.GetUserIdentities(middlewares.CurrentPrincipal(ctx).UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	identitiesResponse := make([]responses.Identity, len(identities))
+	for i, identity := range identities {
+		identitiesResponse[i].Set(identity)
+	}
+	ctx.JSON(http.StatusOK, identitiesResponse)
+}
+
+// DeleteIdentity godoc
+// @Summary      Unlink an identity
+// @Description  Unlink an identity from the logged in user. Users without a password can't unlink their last identity, as they could not login anymore.
+// @Tags         users
+// @Produce      json
+// @Param		 identityId path uint true "ID number of the identity"
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/identities/{identityId} [delete]
+// @Security	 BearerAuth
+func (h *UserHandler) DeleteIdentity(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("identityId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	user, err := h.users.GetUserWithoutPreload(userID)
+	var identities []models.UserIdentity
+	if err == nil {
+		identities, err = h.identities.GetUserIdentities(userID)
+	}
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if user.Password == "" && len(identities) == 1 && identities[0].ID == uint(parsedID) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "This is the only way to login to your account. Set a password with /users/password/forgot before unlinking it.",
+		})
+		return
+	}
+	if err := h.identities.DeleteUserIdentity(userID, uint(parsedID)); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Identity with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "The identity has been unlinked.",
+	})
+}
+
+// abortProviderError aborts with 502 when the provider could not be reached
+// or answered nonsense.
+func abortProviderError(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	ctx.AbortWithStatusJSON(http.StatusBadGateway, responses.ErrorMessage{
+		ErrorMessage: "The identity provider could not be reached, please try again later.",
+	})
+}
@@ -0,0 +1,159 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/oidc"
+	"finalassignment.id/finalassignment/utils/oidc/oidctest"
+	"finalassignment.id/finalassignment/utils/token"
+)
+
+func newOIDCServer(t *testing.T) (*server, *oidctest.Issuer) {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+	issuer.Subject, issuer.Email, issuer.EmailVerified = "subject-1", "oidc@example.com", true
+	return newServer(t, "-oidc-issuer", issuer.URL, "-oidc-client-id", "photos"), issuer
+}
+
+// oidcFlow is a browser going through a login, or a link when accessToken
+// is set: it starts the flow, logs in at the issuer and follows the redirect
+// back to the callback, after tamper changed its query when given.
+func (s *server) oidcFlow(issuer *oidctest.Issuer, accessToken string, tamper func(url.Values)) *httptest.ResponseRecorder {
+	s.t.Helper()
+	authURL, cookies := s.startOIDCFlow(accessToken)
+	callback, err := issuer.Login(authURL)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	query := callback.Query()
+	if tamper != nil {
+		tamper(query)
+	}
+	return s.oidcCallback(query, cookies)
+}
+
+func (s *server) startOIDCFlow(accessToken string) (string, []*http.Cookie) {
+	s.t.Helper()
+	if accessToken == "" {
+		rec := s.do("GET", "/users/oidc/login", "", nil)
+		expectStatus(s.t, rec, http.StatusFound)
+		return rec.Header().Get("Location"), rec.Result().Cookies()
+	}
+	rec := s.do("POST", "/users/oidc/link", accessToken, nil)
+	expectStatus(s.t, rec, http.StatusOK)
+	var body struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	decode(s.t, rec, &body)
+	return body.AuthorizationURL, rec.Result().Cookies()
+}
+
+func (s *server) oidcCallback(query url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/users/oidc/callback?"+query.Encode(), nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return s.send(req, "")
+}
+
+func TestOIDCRegistersAndLogsIn(t *testing.T) {
+	s, issuer := newOIDCServer(t)
+	issuer.PreferredUsername = "oidcuser"
+	rec := s.oidcFlow(issuer, "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	user, err := s.repos.Users.GetUserByEmail("oidc@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "oidcuser" || user.Password != "" || user.EmailVerifiedAt == nil {
+		t.Errorf("got user %+v", user)
+	}
+	// The second login finds the user by the identity.
+	expectStatus(t, s.oidcFlow(issuer, "", nil), http.StatusOK)
+	identities, err := s.repos.Identities.GetUserIdentities(user.ID)
+	if err != nil || len(identities) != 1 || identities[0].Subject != "subject-1" {
+		t.Errorf("got identities %+v, %v", identities, err)
+	}
+}
+
+func TestOIDCStateMismatch(t *testing.T) {
+	s, issuer := newOIDCServer(t)
+	rec := s.oidcFlow(issuer, "", func(query url.Values) { query.Set("state", "forged") })
+	expectError(t, rec, http.StatusBadRequest, token.ErrInvalidOIDCFlow.Error())
+
+	// A callback without the cookie of the browser that started the flow.
+	authURL, _ := s.startOIDCFlow("")
+	callback, err := issuer.Login(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, s.oidcCallback(callback.Query(), nil), http.StatusBadRequest, token.ErrInvalidOIDCFlow.Error())
+	if _, err := s.repos.Users.GetUserByEmail("oidc@example.com"); err == nil {
+		t.Error("a user was registered by a forged callback")
+	}
+}
+
+func TestOIDCNonceMismatch(t *testing.T) {
+	s, issuer := newOIDCServer(t)
+	issuer.Nonce = "replayed"
+	expectError(t, s.oidcFlow(issuer, "", nil), http.StatusUnauthorized, oidc.ErrInvalidIDToken.Error())
+}
+
+func TestOIDCChecksPKCE(t *testing.T) {
+	s, issuer := newOIDCServer(t)
+	// A code stolen from another login can't be redeemed with the verifier
+	// of this one.
+	otherURL, _ := s.startOIDCFlow("")
+	stolen, err := issuer.Login(otherURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := s.oidcFlow(issuer, "", func(query url.Values) { query.Set("code", stolen.Query().Get("code")) })
+	expectError(t, rec, http.StatusUnauthorized, oidc.ErrCodeRejected.Error())
+}
+
+func TestOIDCEmailAlreadyRegistered(t *testing.T) {
+	s, issuer := newOIDCServer(t)
+	issuer.Email = "owner@example.com"
+	owner := s.user("owner", models.RoleUser)
+	rec := s.oidcFlow(issuer, "", nil)
+	expectError(t, rec, http.StatusConflict,
+		"The email owner@example.com is already registered. If it is yours, login and link the identity with /users/oidc/link.")
+
+	// Once the owner linked the identity, it logs them in.
+	expectStatus(t, s.oidcFlow(issuer, owner, nil), http.StatusCreated)
+	rec = s.oidcFlow(issuer, "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var login struct {
+		Token string `json:"token"`
+	}
+	decode(t, rec, &login)
+	expectStatus(t, s.do("GET", "/users/identities", login.Token, nil), http.StatusOK)
+}
+
+func TestOIDCLinkConflict(t *testing.T) {
+	s, issuer := newOIDCServer(t)
+	expectStatus(t, s.oidcFlow(issuer, "", nil), http.StatusOK)
+	other := s.user("other", models.RoleUser)
+	rec := s.oidcFlow(issuer, other, nil)
+	expectError(t, rec, http.StatusConflict, "This identity is linked to another user.")
+
+	issuer.Subject = "subject-2"
+	expectStatus(t, s.oidcFlow(issuer, other, nil), http.StatusCreated)
+	// Linking it again changes nothing.
+	rec = s.oidcFlow(issuer, other, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var identity map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &identity); err != nil || identity["subject"] != "subject-2" {
+		t.Errorf("got %s, %v", rec.Body, err)
+	}
+}
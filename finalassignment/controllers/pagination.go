@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 20
+
+// nextCursorHeader holds the cursor of the next page of a list, which keeps
+// the list in the body as it was before paging.
+const nextCursorHeader = "X-Next-Cursor"
+
+var (
+	errInvalidCursor  = errors.New("The cursor is invalid.")
+	errCursorSortUsed = errors.New("The cursor was made for another sort, keep the sort of the first page.")
+)
+
+// cursor is what the opaque cursors of the list endpoints hold. It carries
+// the sort so a page can't continue a list sorted differently.
+type cursor struct {
+	Sort string    `json:"s"`
+	Time time.Time `json:"t"`
+	ID   uint      `json:"i"`
+}
+
+// listOptions turns query into database.ListOptions. It aborts with 400 and
+// returns false when the cursor is invalid.
+func listOptions(ctx *gin.Context, query dto.ListQuery) (database.ListOptions, bool) {
+	options := database.ListOptions{
+		Limit:         query.Limit,
+		UserID:        query.UserID,
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+	}
+	if options.Limit == 0 {
+		options.Limit = defaultPageSize
+	}
+	sort := query.Sort
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor)
+		if err == nil && sort != "" && sort != after.Sort {
+			err = errCursorSortUsed
+		}
+		if err != nil {
+			abortBadRequest(err, ctx)
+			return options, false
+		}
+		sort = after.Sort
+		options.After = &database.Cursor{Time: after.Time, ID: after.ID}
+	}
+	options.Desc = strings.HasPrefix(sort, "-")
+	options.SortBy = strings.TrimPrefix(sort, "-")
+	if options.SortBy == "" {
+		options.SortBy = database.SortCreatedAt
+	}
+	return options, true
+}
+
+// encodeCursor returns the cursor of the page after next, empty when there
+// is none.
+func encodeCursor(options database.ListOptions, next *database.Cursor) string {
+	if next == nil {
+		return ""
+	}
+	sort := options.SortBy
+	if options.Desc {
+		sort = "-" + sort
+	}
+	encoded, _ := json.Marshal(cursor{Sort: sort, Time: next.Time, ID: next.ID})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// setNextCursor sends the cursor of the page after next in nextCursorHeader,
+// unless there is none.
+func setNextCursor(ctx *gin.Context, options database.ListOptions, next *database.Cursor) {
+	if encoded := encodeCursor(options, next); encoded != "" {
+		ctx.Header(nextCursorHeader, encoded)
+	}
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	var after cursor
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(decoded, &after)
+	}
+	if err != nil || after.ID == 0 {
+		return after, errInvalidCursor
+	}
+	switch after.Sort {
+	case database.SortCreatedAt, "-" + database.SortCreatedAt, database.SortUpdatedAt, "-" + database.SortUpdatedAt:
+		return after, nil
+	}
+	return after, errInvalidCursor
+}
@@ -0,0 +1,76 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+type item struct {
+	ID uint `json:"id"`
+}
+
+// page gets path and returns the printed IDs of the items it lists, as
+// decoded by items, and its X-Next-Cursor header.
+func (s *server) page(accessToken, path string, items func(*httptest.ResponseRecorder) []item) (string, string) {
+	s.t.Helper()
+	rec := s.do("GET", path, accessToken, nil)
+	expectStatus(s.t, rec, http.StatusOK)
+	var ids []uint
+	for _, item := range items(rec) {
+		ids = append(ids, item.ID)
+	}
+	return fmt.Sprint(ids), rec.Header().Get("X-Next-Cursor")
+}
+
+// TestListPages checks lists keep their bodies and page through the
+// X-Next-Cursor header.
+func TestListPages(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	var photoIDs, commentIDs, socmedIDs []uint
+	for i := 0; i < 3; i++ {
+		photoIDs = append(photoIDs, s.create("/photos/", owner, newPhoto(fmt.Sprint("photo", i))))
+		commentIDs = append(commentIDs, s.create(fmt.Sprint("/photos/", photoIDs[0], "/comments"), owner, map[string]string{"message": "nice"}))
+		socmedIDs = append(socmedIDs, s.create("/socialmedias/", owner, newSocialMedia(fmt.Sprint("socmed", i))))
+	}
+	array := func(rec *httptest.ResponseRecorder) []item {
+		var items []item
+		decode(t, rec, &items)
+		return items
+	}
+	socialMedias := func(rec *httptest.ResponseRecorder) []item {
+		var list struct {
+			SocialMedias []item `json:"social_medias"`
+		}
+		decode(t, rec, &list)
+		return list.SocialMedias
+	}
+
+	tests := []struct {
+		path  string
+		items func(*httptest.ResponseRecorder) []item
+		want  []uint
+	}{
+		{"/photos/", array, photoIDs},
+		{"/comments/", array, commentIDs},
+		{fmt.Sprint("/photos/", photoIDs[0], "/comments"), array, commentIDs},
+		{"/socialmedias/", socialMedias, socmedIDs},
+	}
+	for _, tt := range tests {
+		first, cursor := s.page(owner, tt.path+"?limit=2", tt.items)
+		if first != fmt.Sprint(tt.want[:2]) || cursor == "" {
+			t.Fatalf("%s: got %v with cursor %q, want %v and a cursor", tt.path, first, cursor, tt.want[:2])
+		}
+		last, cursor := s.page(owner, tt.path+"?limit=2&cursor="+url.QueryEscape(cursor), tt.items)
+		if last != fmt.Sprint(tt.want[2:]) || cursor != "" {
+			t.Errorf("%s: got %v with cursor %q on the last page, want %v and none", tt.path, last, cursor, tt.want[2:])
+		}
+	}
+
+	expectError(t, s.do("GET", "/photos/?cursor=nonsense", owner, nil), http.StatusBadRequest, "The cursor is invalid.")
+}
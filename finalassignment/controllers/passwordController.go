@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/mailer"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Change the password of the logged in user. Every token issued to them is revoked, so they have to login again.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        password body dto.PasswordChange true "The current password and the new one. Minimum password length is 6."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      429  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/password [put]
+// @Security	 BearerAuth
+func (h *UserHandler) ChangePassword(ctx *gin.Context) {
+	var passwordDto dto.PasswordChange
+	if err := ctx.ShouldBindJSON(&passwordDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&passwordDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	user, err := h.users.GetUserWithoutPreload(middlewares.CurrentPrincipal(ctx).UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	// Guessing the current password is guessing the login password, so it
+	// is limited like logins are.
+	if h.abortLoginLocked(ctx, user.Email) {
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(passwordDto.CurrentPassword)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrHashTooShort) {
+			if err := h.recordLoginFailure(ctx, user.Email); err != nil {
+				ctx.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: "The current password is incorrect.",
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.setPassword(user.ID, passwordDto.NewPassword); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.loginAttempts.ClearLoginFailures(accountLoginKey(user.Email)); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "Your password has been changed, please login again.",
+	})
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a single use password reset token to the user with the given email. The response is the same whether or not the email is registered.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        email body dto.PasswordForgot true "Email of the user who forgot their password."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      429  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/password/forgot [post]
+func (h *UserHandler) ForgotPassword(ctx *gin.Context) {
+	var forgotDto dto.PasswordForgot
+	if err := ctx.ShouldBindJSON(&forgotDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&forgotDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	// Every request counts, whether or not the email is registered, so the
+	// limit gives nothing away either.
+	resetKeys := h.resetKeys(ctx, forgotDto.Email)
+	if h.abortLocked(ctx, resetKeys, "Too many password reset requests, please wait before trying again.") {
+		return
+	}
+	if err := h.recordFailure(resetKeys); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	response := responses.Message{
+		Message: "If the email is registered, a password reset token has been sent to it.",
+	}
+	user, err := h.users.GetUserByEmail(forgotDto.Email)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.JSON(http.StatusOK, response)
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	resetToken, hash, err := token.GenerateOpaqueToken()
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	err = h.passwordResets.CreatePasswordResetToken(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(h.passwords.ResetTTL),
+	})
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.mailer.Send(h.passwordResetMessage(user, resetToken)); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Choose a new password with a token sent by /users/password/forgot. Every token issued to the user is revoked.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        reset body dto.PasswordReset true "The reset token and the new password. Minimum password length is 6."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/password/reset [post]
+func (h *UserHandler) ResetPassword(ctx *gin.Context) {
+	var resetDto dto.PasswordReset
+	if err := ctx.ShouldBindJSON(&resetDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&resetDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	resetToken, err := h.passwordResets.UsePasswordResetToken(token.HashOpaqueToken(resetDto.Token))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: "The password reset token is invalid.",
+			})
+			return
+		}
+		if errors.Is(err, database.ErrPasswordResetTokenExpired) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.setPassword(resetToken.UserID, resetDto.NewPassword); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	// The user proved they own the email, so its lockouts are lifted.
+	user, err := h.users.GetUserWithoutPreload(resetToken.UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	for _, key := range []string{accountLoginKey(user.Email), accountResetKey(user.Email)} {
+		if err := h.loginAttempts.ClearLoginFailures(key); err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "Your password has been reset, please login with the new password.",
+	})
+}
+
+// setPassword stores a new password for userID and revokes every token
+// issued to them.
+func (h *UserHandler) setPassword(userID uint, password string) error {
+	passwordHash, err := h.hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := h.revokeAllTokens(userID); err != nil {
+		return err
+	}
+	return h.users.UpdatePassword(userID, passwordHash)
+}
+
+func (h *UserHandler) hashPassword(password string) (string, error) {
+	passwordBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.passwords.BcryptCost)
+	return string(passwordBytes), err
+}
+
+func (h *UserHandler) passwordResetMessage(user models.User, resetToken string) mailer.Message {
+	instructions := fmt.Sprintf("send the token below to POST /users/password/reset along with your new password:\n\n%s", resetToken)
+	if h.passwords.ResetURL != "" {
+		link, _ := url.Parse(h.passwords.ResetURL)
+		query := link.Query()
+		query.Set("token", resetToken)
+		link.RawQuery = query.Encode()
+		instructions = fmt.Sprintf("open this link:\n\n%s", link)
+	}
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. "+
+			"If it was you, %s\n\nThe token expires in %s and can only be used once. "+
+			"If it wasn't you, ignore this email and your password stays the same.\n",
+			user.Username, instructions, h.passwords.ResetTTL),
+	}
+}
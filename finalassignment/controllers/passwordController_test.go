@@ -0,0 +1,152 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+)
+
+const tooManyResets = "Too many password reset requests, please wait before trying again."
+
+func (s *server) forgot(email string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	s.mail.Reset()
+	return s.do("POST", "/users/password/forgot", "", map[string]string{"email": email})
+}
+
+// resetToken returns the token of the password reset email sent last.
+func (s *server) resetToken() string {
+	s.t.Helper()
+	const before = "along with your new password:\r\n\r\n"
+	mail := s.mail.String()
+	i := strings.LastIndex(mail, before)
+	if i < 0 {
+		s.t.Fatalf("no password reset token in %q", mail)
+	}
+	resetToken, _, _ := strings.Cut(mail[i+len(before):], "\r\n")
+	return resetToken
+}
+
+func (s *server) reset(resetToken, password string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.do("POST", "/users/password/reset", "", map[string]string{"token": resetToken, "new_password": password})
+}
+
+func (s *server) changePassword(accessToken, current, password string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.do("PUT", "/users/password", accessToken, map[string]string{"current_password": current, "new_password": password})
+}
+
+func (s *server) forgotFrom(ip, email string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	body, err := json.Marshal(map[string]string{"email": email})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/users/password/forgot", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":1234"
+	return s.send(req, "")
+}
+
+// TestPasswordReset checks a reset token works once and signs out every
+// session of the user.
+func TestPasswordReset(t *testing.T) {
+	s := newServer(t)
+	s.user("alice", models.RoleUser)
+	accessToken, refreshToken := s.login("alice")
+	bobsToken := s.user("bob", models.RoleUser)
+
+	expectStatus(t, s.forgot("alice@example.com"), http.StatusOK)
+	resetToken := s.resetToken()
+	expectError(t, s.reset("unknown", "secret2"), http.StatusBadRequest, "The password reset token is invalid.")
+	expectStatus(t, s.reset(resetToken, "secret2"), http.StatusOK)
+
+	expectError(t, s.do("GET", "/users/sessions", accessToken, nil), http.StatusUnauthorized, middlewares.ErrTokenRevoked.Error())
+	expectStatus(t, s.refresh(refreshToken), http.StatusUnauthorized)
+	expectStatus(t, s.do("GET", "/users/sessions", bobsToken, nil), http.StatusOK)
+	expectError(t, s.do("POST", "/users/login", "", map[string]string{"email": "alice@example.com", "password": "secret1"}),
+		http.StatusBadRequest, "Email or password is incorrect.")
+	expectStatus(t, s.do("POST", "/users/login", "", map[string]string{"email": "alice@example.com", "password": "secret2"}), http.StatusOK)
+
+	// The token can't be used again.
+	expectError(t, s.reset(resetToken, "secret3"), http.StatusBadRequest, "The password reset token has expired or has already been used.")
+
+	// Using one token uses up the others sent before it.
+	expectStatus(t, s.forgot("alice@example.com"), http.StatusOK)
+	first := s.resetToken()
+	expectStatus(t, s.forgot("alice@example.com"), http.StatusOK)
+	expectStatus(t, s.reset(s.resetToken(), "secret3"), http.StatusOK)
+	expectError(t, s.reset(first, "secret4"), http.StatusBadRequest, "The password reset token has expired or has already been used.")
+
+	// Unknown emails get the same answer, and no email.
+	expectStatus(t, s.forgot("nobody@example.com"), http.StatusOK)
+	if s.mail.Len() != 0 {
+		t.Errorf("an email was sent to an unknown address: %s", s.mail.String())
+	}
+}
+
+func TestPasswordResetExpired(t *testing.T) {
+	s := newServer(t, "-password-reset-ttl", "50ms")
+	s.user("alice", models.RoleUser)
+	expectStatus(t, s.forgot("alice@example.com"), http.StatusOK)
+	resetToken := s.resetToken()
+
+	time.Sleep(60 * time.Millisecond)
+	expectError(t, s.reset(resetToken, "secret2"), http.StatusBadRequest, "The password reset token has expired or has already been used.")
+	expectStatus(t, s.do("POST", "/users/login", "", map[string]string{"email": "alice@example.com", "password": "secret1"}), http.StatusOK)
+}
+
+// TestForgotPasswordLimit checks reset requests are limited apart from
+// logins, and count whether or not the email is registered.
+func TestForgotPasswordLimit(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "2", "-login-ip-max-attempts", "3", "-login-backoff", "10s", "-login-lockout", "1m")
+	s.user("alice", models.RoleUser)
+
+	expectStatus(t, s.forgotFrom("192.0.2.1", "alice@example.com"), http.StatusOK)
+	resetToken := s.resetToken()
+	expectStatus(t, s.forgotFrom("192.0.2.2", "alice@example.com"), http.StatusOK)
+	rec := s.forgotFrom("192.0.2.3", "alice@example.com")
+	expectError(t, rec, http.StatusTooManyRequests, tooManyResets)
+	if got := rec.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("got Retry-After %q, want 10", got)
+	}
+	expectStatus(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret1"), http.StatusOK)
+
+	// The requests of a client add up, for any email.
+	expectStatus(t, s.forgotFrom("192.0.2.2", "nobody@example.com"), http.StatusOK)
+	expectStatus(t, s.forgotFrom("192.0.2.2", "someone@example.com"), http.StatusOK)
+	expectError(t, s.forgotFrom("192.0.2.2", "anyone@example.com"), http.StatusTooManyRequests, tooManyResets)
+
+	// Resetting lifts the lockout of the account.
+	expectStatus(t, s.reset(resetToken, "secret2"), http.StatusOK)
+	expectStatus(t, s.forgotFrom("192.0.2.3", "alice@example.com"), http.StatusOK)
+}
+
+func TestChangePassword(t *testing.T) {
+	s := newServer(t, "-login-max-attempts", "3", "-login-backoff", "10s", "-login-lockout", "1m")
+	accessToken := s.user("alice", models.RoleUser)
+	other, refreshToken := s.login("alice")
+
+	expectError(t, s.changePassword(accessToken, "wrong1", "secret2"), http.StatusBadRequest, "The current password is incorrect.")
+	expectStatus(t, s.changePassword(accessToken, "secret1", "secret2"), http.StatusOK)
+	for _, signedOut := range []string{accessToken, other} {
+		expectError(t, s.do("GET", "/users/sessions", signedOut, nil), http.StatusUnauthorized, middlewares.ErrTokenRevoked.Error())
+	}
+	expectStatus(t, s.refresh(refreshToken), http.StatusUnauthorized)
+
+	// Wrong current passwords count as failed logins.
+	accessToken, _ = tokens(t, s.loginFrom("192.0.2.1", "alice@example.com", "secret2"))
+	s.fail("192.0.2.1", "alice@example.com", 2)
+	expectError(t, s.changePassword(accessToken, "wrong1", "secret3"), http.StatusBadRequest, "The current password is incorrect.")
+	rec := s.changePassword(accessToken, "secret2", "secret3")
+	expectLocked(t, rec, "10")
+	expectLocked(t, s.loginFrom("192.0.2.2", "alice@example.com", "secret2"), "10")
+}
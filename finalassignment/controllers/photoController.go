@@ -7,27 +7,56 @@ import (
 	"strconv"
 	"time"
 
+	"finalassignment.id/finalassignment/config"
 	"finalassignment.id/finalassignment/controllers/responses"
 	"finalassignment.id/finalassignment/database"
 	"finalassignment.id/finalassignment/dto"
-	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/policy"
+	"finalassignment.id/finalassignment/utils/links"
+	"finalassignment.id/finalassignment/utils/storage"
+	"finalassignment.id/finalassignment/utils/variants"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+type PhotoHandler struct {
+	photos  database.PhotoRepository
+	uploads database.PhotoUploadRepository
+	blobs   storage.BlobStore
+	worker  *variants.Worker
+	links   *links.Checker
+	storage config.Storage
+}
+
+func NewPhotoHandler(photos database.PhotoRepository, uploads database.PhotoUploadRepository, blobs storage.BlobStore, worker *variants.Worker, checker *links.Checker, cfg config.Storage) *PhotoHandler {
+	return &PhotoHandler{photos: photos, uploads: uploads, blobs: blobs, worker: worker, links: checker, storage: cfg}
+}
+
 // CreatePhoto godoc
 // @Summary      Create a Photo
-// @Description  Create a Photo associated with the logged in user identified by bearer token.
+// @Description  Create a Photo associated with the logged in user identified by bearer token. Users have to verify their email first, unless verification.required is off.
+// @Description  Either link an image by photo_url, name a finished resumable upload by upload_id, or send the image itself as the photo field of a multipart/form-data body along with title and caption.
 // @Tags         photos
-// @Accept       json
+// @Accept       json,mpfd
 // @Produce      json
 // @Param        user body dto.Photo true "JSON of the photo to be made. Caption is not mandatory."
 // @Success      201  {object}  responses.CreatePhoto
 // @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      409  {object}  responses.ErrorMessage
+// @Failure      413  {object}  responses.ErrorMessage
+// @Failure      415  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /photos [post]
 // @Security	 BearerAuth
-func CreatePhoto(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) CreatePhoto(ctx *gin.Context) {
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		h.createPhotoFromForm(ctx)
+		return
+	}
 	var newPhoto dto.Photo
 	if err := ctx.ShouldBindJSON(&newPhoto); err != nil {
 		abortBadRequest(err, ctx)
@@ -37,23 +66,54 @@ func CreatePhoto(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
+	var file models.PhotoFile
+	if newPhoto.UploadID != "" {
+		var ok bool
+		if file, ok = h.finishUpload(ctx, newPhoto.UploadID); !ok {
+			return
+		}
+		newPhoto.PhotoUrl = h.fileURL(file.StorageKey)
+	} else if err := h.links.Validate(newPhoto.PhotoUrl); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: err.Error(),
+		})
 		return
 	}
-	ID, err := database.CreatePhoto(userID, &newPhoto)
+	h.createPhoto(ctx, &newPhoto, file)
+}
+
+// createPhoto saves the photo and responds with it. The stored file is
+// deleted again if the photo cannot be saved.
+func (h *PhotoHandler) createPhoto(ctx *gin.Context, newPhoto *dto.Photo, file models.PhotoFile) {
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	ID, err := h.photos.CreatePhoto(userID, newPhoto, file)
 	if err != nil {
+		if file.StorageKey != "" {
+			if err := h.blobs.Delete(ctx.Request.Context(), file.StorageKey); err != nil {
+				ctx.Error(err)
+			}
+		}
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	variantsStatus, linkStatus := "", ""
+	if file.StorageKey != "" {
+		variantsStatus = models.VariantsPending
+		h.worker.Enqueue(ID)
+	} else {
+		linkStatus = models.LinkPending
+		h.links.Enqueue(ID)
+	}
 	ctx.JSON(http.StatusCreated, responses.CreatePhoto{
 		Photo: responses.Photo{
-			ID:       ID,
-			Title:    newPhoto.Title,
-			Caption:  newPhoto.Caption,
-			PhotoUrl: newPhoto.PhotoUrl,
-			UserID:   userID,
+			ID:             ID,
+			Title:          newPhoto.Title,
+			Caption:        newPhoto.Caption,
+			PhotoUrl:       newPhoto.PhotoUrl,
+			UserID:         userID,
+			PhotoFile:      file,
+			VariantsStatus: variantsStatus,
+			LinkStatus:     linkStatus,
 		},
 		CreatedAt: time.Now(),
 	})
@@ -61,39 +121,126 @@ func CreatePhoto(ctx *gin.Context) {
 
 // GetPhotos godoc
 // @Summary      Get photos
-// @Description  Get photos.
+// @Description  Get a page of photos, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.
 // @Tags         photos
 // @Accept       json
 // @Produce      json
+// @Param        query query dto.ListQuery false "Paging, sorting and filters"
 // @Success      200  {object}  []responses.GetPhoto
+// @Header       200  {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure		 400 {object} responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /photos [get]
 // @Security	 BearerAuth
-func GetAllPhotos(ctx *gin.Context) {
-	photos, err := database.GetAllPhotos()
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) GetAllPhotos(ctx *gin.Context) {
+	var query dto.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	options, ok := listOptions(ctx, query)
+	if !ok {
+		return
+	}
+	h.listPhotos(ctx, options)
+}
+
+// GetTagPhotos godoc
+// @Summary      Get the photos of a tag
+// @Description  Get a page of the photos with a hashtag in their caption, oldest first unless sorted otherwise. The name is matched regardless of case, with or without the #.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param		 name path string true "Name of the tag"
+// @Param        query query dto.ListQuery false "Paging, sorting and filters"
+// @Success      200  {object}  []responses.GetPhoto
+// @Header       200  {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
+// @Failure		 400 {object} responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /tags/{name}/photos [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) GetTagPhotos(ctx *gin.Context) {
+	tag := database.NormalizeTag(ctx.Param("name"))
+	if !database.IsTag(tag) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: fmt.Sprintf("#%s is not a hashtag.", tag),
+		})
+		return
+	}
+	var query dto.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	options, ok := listOptions(ctx, query)
+	if !ok {
+		return
+	}
+	options.Tag = tag
+	h.listPhotos(ctx, options)
+}
+
+func (h *PhotoHandler) listPhotos(ctx *gin.Context, options database.ListOptions) {
+	photos, next, err := h.photos.GetAllPhotos(options)
 	if err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 	photosResponse := make([]responses.GetPhoto, len(photos))
-	userDtos := make(map[uint]dto.UserUpdate)
 	for i, photo := range photos {
 		photosResponse[i].Set(photo)
-		userDto, ok := userDtos[photo.UserID]
-		if !ok {
-			userDto, err = database.GetUsernameAndEmail(photo.UserID)
-			if err != nil {
-				ctx.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-			userDtos[photo.UserID] = userDto
-		}
-		photosResponse[i].User = userDto
 	}
+	setNextCursor(ctx, options, next)
 	ctx.JSON(http.StatusOK, photosResponse)
 }
 
+// GetPhoto godoc
+// @Summary      Get a photo
+// @Description  Get a photo along with the user who posted it.
+// @Tags         photos
+// @Accept       json
+// @Produce      json
+// @Param		 photoId path uint true "ID number of the photo"
+// @Success      200  {object}  responses.GetPhoto
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /photos/{photoId} [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) GetPhoto(ctx *gin.Context) {
+	photoID := ctx.Param("photoId")
+	parsedID, err := strconv.ParseUint(photoID, 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	photo, err := h.photos.GetPhotoWithUser(uint(parsedID))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Photo with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	var response responses.GetPhoto
+	response.Set(photo)
+	ctx.JSON(http.StatusOK, response)
+}
+
 // UpdatePhoto godoc
 // @Summary      Update a photo
 // @Description  Update a photo associated with logged in user.
@@ -109,7 +256,8 @@ func GetAllPhotos(ctx *gin.Context) {
 // @Failure      500  {object}  nil
 // @Router       /photos/{photoId} [put]
 // @Security	 BearerAuth
-func UpdatePhoto(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) UpdatePhoto(ctx *gin.Context) {
 	photoID := ctx.Param("photoId")
 	parsedID, err := strconv.ParseUint(photoID, 10, 0)
 	if err != nil {
@@ -125,19 +273,42 @@ func UpdatePhoto(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
+	if photoDto.UploadID != "" {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "Uploads can only be made into new photos.",
+		})
 		return
 	}
-	updatedAt, err := database.UpdatePhoto(uint(parsedID), userID, &photoDto)
+	var updatedAt time.Time
+	var photoVariants []models.PhotoVariant
+	photo, err := h.photos.GetSinglePhoto(uint(parsedID))
+	if err == nil {
+		err = policy.Authorize(middlewares.CurrentPrincipal(ctx), policy.Update, policy.Photo(photo))
+	}
+	// Photos copied from their link can be sent back with either URL.
+	replaced := photoDto.PhotoUrl != photo.PhotoUrl && photoDto.PhotoUrl != photo.SourceUrl
+	if err == nil && replaced {
+		err = h.links.Validate(photoDto.PhotoUrl)
+	}
+	if err == nil && replaced {
+		photoVariants, err = h.photos.GetPhotoVariants(uint(parsedID))
+	}
+	if err == nil {
+		updatedAt, err = h.photos.UpdatePhoto(uint(parsedID), &photoDto)
+	}
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, database.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
 				ErrorMessage: fmt.Sprintf("Photo with ID %d is not found.", parsedID),
 			})
 			return
 		}
+		if errors.Is(err, links.ErrBlockedURL) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
 		if errors.Is(err, database.ErrIllegalUpdate) {
 			ctx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorMessage{
 				ErrorMessage: err.Error(),
@@ -147,13 +318,22 @@ func UpdatePhoto(ctx *gin.Context) {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	photoURL, file, variantsStatus, linkStatus := photo.PhotoUrl, photo.PhotoFile, photo.VariantsStatus, photo.LinkStatus
+	if replaced {
+		h.deleteFiles(ctx, photo, photoVariants)
+		h.links.Enqueue(uint(parsedID))
+		photoURL, file, variantsStatus, linkStatus = photoDto.PhotoUrl, models.PhotoFile{}, "", models.LinkPending
+	}
 	ctx.JSON(http.StatusOK, responses.UpdatePhoto{
 		Photo: responses.Photo{
-			ID:       uint(parsedID),
-			Title:    photoDto.Title,
-			Caption:  photoDto.Caption,
-			PhotoUrl: photoDto.PhotoUrl,
-			UserID:   userID,
+			ID:             uint(parsedID),
+			Title:          photoDto.Title,
+			Caption:        photoDto.Caption,
+			PhotoUrl:       photoURL,
+			UserID:         photo.UserID,
+			PhotoFile:      file,
+			VariantsStatus: variantsStatus,
+			LinkStatus:     linkStatus,
 		},
 		UpdatedAt: updatedAt,
 	})
@@ -161,7 +341,7 @@ func UpdatePhoto(ctx *gin.Context) {
 
 // DeletePhoto godoc
 // @Summary      Delete a photo
-// @Description  Delete a photo associated with logged in user.
+// @Description  Delete a photo associated with logged in user. Moderators can delete any photo.
 // @Tags         photos
 // @Accept       json
 // @Produce      json
@@ -172,21 +352,29 @@ func UpdatePhoto(ctx *gin.Context) {
 // @Failure      404  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /photos/{photoId} [delete]
+// @Router       /admin/photos/{photoId} [delete]
 // @Security	 BearerAuth
-func DeletePhoto(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) DeletePhoto(ctx *gin.Context) {
 	photoID := ctx.Param("photoId")
 	parsedID, err := strconv.ParseUint(photoID, 10, 0)
 	if err != nil {
 		abortBadRequest(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
+	var photoVariants []models.PhotoVariant
+	photo, err := h.photos.GetSinglePhoto(uint(parsedID))
+	if err == nil {
+		err = policy.Authorize(middlewares.CurrentPrincipal(ctx), policy.Delete, policy.Photo(photo))
 	}
-	if err := database.DeletePhoto(uint(parsedID), userID); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	if err == nil {
+		photoVariants, err = h.photos.GetPhotoVariants(uint(parsedID))
+	}
+	if err == nil {
+		err = h.photos.DeletePhoto(uint(parsedID))
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
 				ErrorMessage: fmt.Sprintf("Photo with ID %d is not found.", parsedID),
 			})
@@ -201,7 +389,25 @@ func DeletePhoto(ctx *gin.Context) {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	h.deleteFiles(ctx, photo, photoVariants)
 	ctx.JSON(http.StatusOK, responses.Message{
 		Message: "Your photo has been successfully deleted",
 	})
 }
+
+// deleteFiles deletes the stored file of an uploaded photo and the files of
+// its variants.
+func (h *PhotoHandler) deleteFiles(ctx *gin.Context, photo models.Photo, photoVariants []models.PhotoVariant) {
+	keys := []string{photo.StorageKey}
+	for _, variant := range photoVariants {
+		keys = append(keys, variant.StorageKey)
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := h.blobs.Delete(ctx.Request.Context(), key); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+func newPhoto(title string) map[string]interface{} {
+	return map[string]interface{}{"title": title, "caption": "caption", "photo_url": "https://example.com/photo.jpg"}
+}
+
+func TestPhotoCRUD(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	photoID := s.create("/photos/", owner, newPhoto("first"))
+	path := fmt.Sprint("/photos/", photoID)
+
+	rec := s.do("GET", path, owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var photo struct {
+		Title string `json:"title"`
+		User  struct {
+			Username string `json:"username"`
+		}
+	}
+	decode(t, rec, &photo)
+	if photo.Title != "first" || photo.User.Username != "owner" {
+		t.Errorf("got %+v", photo)
+	}
+
+	rec = s.do("PUT", path, owner, newPhoto("renamed"))
+	expectStatus(t, rec, http.StatusOK)
+	rec = s.do("GET", "/photos/", owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var list []struct {
+		ID    uint   `json:"id"`
+		Title string `json:"title"`
+	}
+	decode(t, rec, &list)
+	if len(list) != 1 || list[0].ID != photoID || list[0].Title != "renamed" {
+		t.Errorf("got %+v", list)
+	}
+
+	rec = s.do("DELETE", path, owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	expectError(t, s.do("GET", path, owner, nil), http.StatusNotFound, fmt.Sprintf("Photo with ID %d is not found.", photoID))
+	expectError(t, s.do("DELETE", path, owner, nil), http.StatusNotFound, fmt.Sprintf("Photo with ID %d is not found.", photoID))
+}
+
+func TestPhotoOwnership(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	other := s.user("other", models.RoleUser)
+	moderator := s.user("moderator", models.RoleModerator)
+	photoID := s.create("/photos/", owner, newPhoto("mine"))
+	path := fmt.Sprint("/photos/", photoID)
+
+	expectError(t, s.do("PUT", path, other, newPhoto("stolen")), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	expectError(t, s.do("DELETE", path, other, nil), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	// Moderators can only delete photos of others through the admin routes.
+	expectError(t, s.do("PUT", path, moderator, newPhoto("moderated")), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	photo, err := s.repos.Photos.GetSinglePhoto(photoID)
+	if err != nil || photo.Title != "mine" {
+		t.Fatalf("got %+v, %v", photo, err)
+	}
+	expectStatus(t, s.do("DELETE", "/admin"+path, other, nil), http.StatusForbidden)
+	expectStatus(t, s.do("DELETE", "/admin"+path, moderator, nil), http.StatusOK)
+	if _, err := s.repos.Photos.GetSinglePhoto(photoID); err != database.ErrNotFound {
+		t.Errorf("got %v after deleting, want ErrNotFound", err)
+	}
+}
+
+func TestPhotoValidation(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	expectStatus(t, s.do("POST", "/photos/", owner, map[string]interface{}{"title": "no url"}), http.StatusBadRequest)
+	expectStatus(t, s.do("POST", "/photos/", "", newPhoto("anonymous")), http.StatusBadRequest)
+	expectStatus(t, s.do("GET", "/photos/abc", owner, nil), http.StatusBadRequest)
+}
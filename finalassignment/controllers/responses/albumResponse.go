@@ -0,0 +1,63 @@
+package responses
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+type Album struct {
+	ID           uint   `json:"id" example:"1"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	CoverPhotoID *uint  `json:"cover_photo_id,omitempty" example:"1"`
+	Visibility   string `json:"visibility" example:"public"`
+	UserID       uint   `json:"user_id" example:"1"`
+}
+
+type CreateAlbum struct {
+	Album
+	CreatedAt time.Time `json:"created_at" example:"2019-11-09T21:21:46+00:00"`
+}
+
+type UpdateAlbum struct {
+	Album
+	UpdatedAt time.Time `json:"updated_at" example:"2019-11-09T21:21:46+00:00"`
+}
+
+type GetAllAlbums struct {
+	Albums []GetAlbum `json:"albums"`
+}
+
+type GetAlbum struct {
+	models.Album
+	User          UserAlbum
+	CoverPhotoUrl string `json:"cover_photo_url,omitempty" example:"https://subdomain.domain.dom.ge/path?arg=1"`
+	// Photos are the photos of the album in their order. They are only
+	// listed for a single album.
+	Photos []GetPhoto `json:"photos,omitempty"`
+}
+
+type UserAlbum struct {
+	ID       uint   `json:"id" example:"1"`
+	Username string `json:"username"`
+}
+
+// AlbumPhotos are the IDs of the photos of an album in their order.
+type AlbumPhotos struct {
+	AlbumID  uint   `json:"album_id" example:"1"`
+	PhotoIDs []uint `json:"photo_ids" example:"3,1,2"`
+}
+
+func (getAlbum *GetAlbum) Set(album models.AlbumWithUser) {
+	getAlbum.Album = album.Album
+	getAlbum.User = UserAlbum{
+		ID:       album.User.ID,
+		Username: album.User.Username,
+	}
+	getAlbum.CoverPhotoUrl = ""
+	if album.CoverPhoto != nil {
+		getAlbum.CoverPhotoUrl = album.CoverPhoto.PhotoUrl
+	}
+	getAlbum.Photos = nil
+}
@@ -0,0 +1,31 @@
+package responses
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+type APIKey struct {
+	ID         uint       `json:"id" example:"1"`
+	Name       string     `json:"name" example:"backup script"`
+	Prefix     string     `json:"prefix" example:"fa_Xq3vT9bL"`
+	Scopes     []string   `json:"scopes" example:"photos:read,comments:write"`
+	CreatedAt  time.Time  `json:"created_at" example:"2019-11-09T21:21:46+00:00"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2019-11-09T21:21:46+00:00"`
+}
+
+type CreateAPIKey struct {
+	APIKey
+	// Key is only returned once.
+	Key string `json:"key" example:"fa_Xq3vT9bLm0c8YHn2d5QeWJ1uK7rAzP4sGtVfxE6iNoB"`
+}
+
+func (response *APIKey) Set(apiKey models.APIKey) {
+	response.ID = apiKey.ID
+	response.Name = apiKey.Name
+	response.Prefix = apiKey.Prefix
+	response.Scopes = apiKey.Scopes()
+	response.CreatedAt = apiKey.CreatedAt
+	response.LastUsedAt = apiKey.LastUsedAt
+}
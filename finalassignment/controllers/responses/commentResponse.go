@@ -11,12 +11,18 @@ type CreateComment struct {
 	Message   string    `json:"message"`
 	PhotoID   uint      `json:"photo_id" example:"1"`
 	UserID    uint      `json:"user_id" example:"1"`
+	ParentID  *uint     `json:"parent_id,omitempty" example:"1"`
+	Depth     uint      `json:"depth"`
 	CreatedAt time.Time `json:"created_at" example:"2019-11-09T21:21:46+00:00"`
 }
 
 type GetComment struct {
 	CreateComment
-	UpdatedAt time.Time `json:"updated_at" example:"2019-11-09T21:21:46+00:00"`
+	UpdatedAt  time.Time `json:"updated_at" example:"2019-11-09T21:21:46+00:00"`
+	ReplyCount uint      `json:"reply_count"`
+	// DeletedAt is set on comments deleted while they had replies, which
+	// are kept without their message and user.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 	User      UserComment
 	Photo     models.Photo
 }
@@ -27,11 +33,21 @@ type UserComment struct {
 	Username string `json:"username"`
 }
 
-func (getComment *GetComment) Set(comment models.Comment) {
+func (getComment *GetComment) Set(comment models.CommentWithUserAndPhoto) {
 	getComment.ID = comment.ID
 	getComment.CreatedAt = comment.CreatedAt
 	getComment.UpdatedAt = comment.UpdatedAt
 	getComment.UserID = comment.UserID
 	getComment.PhotoID = comment.PhotoID
+	getComment.ParentID = comment.ParentID
+	getComment.Depth = comment.Depth
+	getComment.ReplyCount = comment.ReplyCount
+	getComment.DeletedAt = comment.DeletedAt
 	getComment.Message = comment.Message
+	getComment.User = UserComment{
+		ID:       comment.User.ID,
+		Email:    comment.User.Email,
+		Username: comment.User.Username,
+	}
+	getComment.Photo = comment.Photo
 }
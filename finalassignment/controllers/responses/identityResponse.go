@@ -0,0 +1,27 @@
+package responses
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+type Identity struct {
+	ID        uint      `json:"id" example:"1"`
+	Issuer    string    `json:"issuer" example:"https://accounts.example.com"`
+	Subject   string    `json:"subject" example:"248289761001"`
+	Email     string    `json:"email" example:"name@org.dom.ge"`
+	CreatedAt time.Time `json:"created_at" example:"2019-11-09T21:21:46+00:00"`
+}
+
+type AuthorizationURL struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.example.com/authorize?client_id=photos&code_challenge=..."`
+}
+
+func (response *Identity) Set(identity models.UserIdentity) {
+	response.ID = identity.ID
+	response.Issuer = identity.Issuer
+	response.Subject = identity.Subject
+	response.Email = identity.Email
+	response.CreatedAt = identity.CreatedAt
+}
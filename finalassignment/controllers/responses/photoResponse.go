@@ -13,6 +13,9 @@ type Photo struct {
 	Caption  string `json:"caption"`
 	PhotoUrl string `json:"photo_url" example:"https://subdomain.domain.dom.ge/path?arg=1"`
 	UserID   uint   `json:"user_id" example:"1"`
+	models.PhotoFile
+	VariantsStatus string `json:"variants_status,omitempty" example:"pending"`
+	LinkStatus     string `json:"link_status,omitempty" example:"pending"`
 }
 
 type CreatePhoto struct {
@@ -23,6 +26,22 @@ type CreatePhoto struct {
 type GetPhoto struct {
 	models.Photo
 	User dto.UserUpdate
+	// Variants are the resized copies of an uploaded photo by name, such as
+	// 640_jpeg, once its variants_status is ready.
+	Variants map[string]PhotoVariant `json:"variants,omitempty"`
+}
+
+type PhotoVariant struct {
+	URL string `json:"url" example:"http://localhost:8080/uploads/photos/0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11_640_jpeg.jpg"`
+	models.PhotoFile
+}
+
+type PhotoUpload struct {
+	UploadID string `json:"upload_id" example:"0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11"`
+	Size     int64  `json:"size" example:"204800"`
+	// Received is the offset the next chunk has to start at.
+	Received  int64     `json:"received" example:"65536"`
+	ExpiresAt time.Time `json:"expires_at" example:"2019-11-10T21:21:46+00:00"`
 }
 
 type UpdatePhoto struct {
@@ -30,7 +49,7 @@ type UpdatePhoto struct {
 	UpdatedAt time.Time `json:"updated_at" example:"2019-11-09T21:21:46+00:00"`
 }
 
-func (getPhoto *GetPhoto) Set(photo models.Photo) {
+func (getPhoto *GetPhoto) Set(photo models.PhotoWithUser) {
 	getPhoto.ID = photo.ID
 	getPhoto.CreatedAt = photo.CreatedAt
 	getPhoto.UpdatedAt = photo.UpdatedAt
@@ -38,4 +57,16 @@ func (getPhoto *GetPhoto) Set(photo models.Photo) {
 	getPhoto.Caption = photo.Caption
 	getPhoto.PhotoUrl = photo.PhotoUrl
 	getPhoto.UserID = photo.UserID
+	getPhoto.PhotoFile = photo.PhotoFile
+	getPhoto.VariantsStatus = photo.VariantsStatus
+	getPhoto.PhotoLink = photo.PhotoLink
+	getPhoto.Variants = nil
+	if len(photo.Variants) > 0 {
+		getPhoto.Variants = make(map[string]PhotoVariant, len(photo.Variants))
+		for _, variant := range photo.Variants {
+			getPhoto.Variants[variant.Name] = PhotoVariant{URL: variant.URL, PhotoFile: variant.PhotoFile}
+		}
+	}
+	getPhoto.User.Username = photo.User.Username
+	getPhoto.User.Email = photo.User.Email
 }
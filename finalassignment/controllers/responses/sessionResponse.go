@@ -0,0 +1,28 @@
+package responses
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+type Session struct {
+	ID         uint      `json:"id" example:"1"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0"`
+	IP         string    `json:"ip" example:"203.0.113.7"`
+	CreatedAt  time.Time `json:"created_at" example:"2019-11-09T21:21:46+00:00"`
+	LastSeenAt time.Time `json:"last_seen_at" example:"2019-11-09T21:21:46+00:00"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2019-12-09T21:21:46+00:00"`
+	// Current is true for the session of the request.
+	Current bool `json:"current" example:"true"`
+}
+
+func (response *Session) Set(session models.Session, currentID uint) {
+	response.ID = session.ID
+	response.UserAgent = session.UserAgent
+	response.IP = session.IP
+	response.CreatedAt = session.CreatedAt
+	response.LastSeenAt = session.LastSeenAt
+	response.ExpiresAt = session.ExpiresAt
+	response.Current = session.ID == currentID
+}
@@ -36,11 +36,15 @@ type UserSocialMedia struct {
 	Username string `json:"username"`
 }
 
-func (getSocmed *GetSocialMedia) Set(socmed models.SocialMedia) {
+func (getSocmed *GetSocialMedia) Set(socmed models.SocialMediaWithUser) {
 	getSocmed.ID = socmed.ID
 	getSocmed.CreatedAt = socmed.CreatedAt
 	getSocmed.UpdatedAt = socmed.UpdatedAt
 	getSocmed.Name = socmed.Name
 	getSocmed.SocialMediaUrl = socmed.SocialMediaUrl
 	getSocmed.UserID = socmed.UserID
+	getSocmed.User = UserSocialMedia{
+		ID:       socmed.User.ID,
+		Username: socmed.User.Username,
+	}
 }
@@ -0,0 +1,22 @@
+package responses
+
+import "finalassignment.id/finalassignment/models"
+
+type Tag struct {
+	Name       string `json:"name" example:"sunset"`
+	PhotoCount int64  `json:"photo_count" example:"3"`
+}
+
+type GetAllTags struct {
+	Tags []Tag `json:"tags"`
+}
+
+func (getAllTags *GetAllTags) Set(counts []models.TagCount) {
+	getAllTags.Tags = make([]Tag, len(counts))
+	for i, count := range counts {
+		getAllTags.Tags[i] = Tag{
+			Name:       count.Name,
+			PhotoCount: count.PhotoCount,
+		}
+	}
+}
@@ -17,6 +17,30 @@ type UserUpdate struct {
 	UpdatedAt time.Time `json:"updated_at" example:"2019-11-09T21:21:46+00:00"`
 }
 
+// UserProfile is what anyone logged in can see of a user.
+type UserProfile struct {
+	ID        uint      `json:"id" example:"1"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at" example:"2019-11-09T21:21:46+00:00"`
+}
+
 type UserLogin struct {
-	Token string `json:"token" example:"header.payload.signature"`
+	Token        string `json:"token" example:"header.payload.signature"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+}
+
+type LoginChallenge struct {
+	ChallengeToken string `json:"challenge_token" example:"header.payload.signature"`
+	ExpiresIn      int64  `json:"expires_in" example:"300"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/finalassignment:name@org.dom.ge?secret=JBSWY3DPEHPK3PXP&issuer=finalassignment"`
+}
+
+type RecoveryCodes struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
 }
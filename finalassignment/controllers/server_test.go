@@ -0,0 +1,160 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/database/memory"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/routers"
+	"finalassignment.id/finalassignment/utils/links"
+	"finalassignment.id/finalassignment/utils/mailer"
+	"finalassignment.id/finalassignment/utils/storage"
+	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/utils/variants"
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+// server is the API on top of in-memory repositories. Its background
+// workers are not started, so photos stay pending unless a test runs them.
+type server struct {
+	t       *testing.T
+	cfg     *config.Config
+	repos   database.Repositories
+	blobs   storage.BlobStore
+	worker  *variants.Worker
+	checker *links.Checker
+	mail    bytes.Buffer
+	router  *gin.Engine
+}
+
+// newServer starts a server configured by args on top of the defaults, with
+// the cheapest password hashes and uploads kept in a temporary directory.
+func newServer(t *testing.T, args ...string) *server {
+	t.Helper()
+	args = append([]string{"-jwt-secret", testSecret, "-password-bcrypt-cost", "4", "-storage-path", t.TempDir()}, args...)
+	cfg, err := config.Load(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := token.LoadKeys(cfg.JWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.Configure(keys, cfg.JWT.TokenTTL, cfg.JWT.RefreshTTL)
+	s := &server{t: t, cfg: cfg, repos: memory.New()}
+	if s.blobs, err = storage.New(cfg.Storage); err != nil {
+		t.Fatal(err)
+	}
+	s.worker = variants.NewWorker(s.repos.Photos, s.blobs, cfg.Variants, cfg.Storage.PublicURL)
+	s.checker = links.NewChecker(s.repos.Photos, s.blobs, s.worker, cfg.Links, cfg.Storage.PublicURL)
+	s.router = routers.StartServer(s.repos, mailer.NewWriterMailer(&s.mail, cfg.Mail.From), s.blobs, s.worker, s.checker, cfg)
+	return s
+}
+
+// do sends body as JSON, authorized by accessToken unless it is empty.
+func (s *server) do(method, path, accessToken string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	return s.send(req, accessToken)
+}
+
+func (s *server) send(req *http.Request, accessToken string) *httptest.ResponseRecorder {
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// user registers a verified user named name with the role and returns their
+// access token.
+func (s *server) user(name, role string) string {
+	s.t.Helper()
+	email := name + "@example.com"
+	rec := s.do("POST", "/users/register", "", map[string]interface{}{
+		"username": name, "email": email, "password": "secret1", "age": 20,
+	})
+	expectStatus(s.t, rec, http.StatusCreated)
+	user, err := s.repos.Users.GetUserByEmail(email)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if err := s.repos.Users.VerifyEmail(user.ID, time.Now()); err != nil {
+		s.t.Fatal(err)
+	}
+	if role != models.RoleUser {
+		if _, err := s.repos.Users.UpdateUserRole(user.ID, role); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	rec = s.do("POST", "/users/login", "", map[string]interface{}{"email": email, "password": "secret1"})
+	expectStatus(s.t, rec, http.StatusOK)
+	var login struct {
+		Token string `json:"token"`
+	}
+	decode(s.t, rec, &login)
+	return login.Token
+}
+
+// create posts body to path and returns the ID of what was created.
+func (s *server) create(path, accessToken string, body interface{}) uint {
+	s.t.Helper()
+	rec := s.do("POST", path, accessToken, body)
+	expectStatus(s.t, rec, http.StatusCreated)
+	var created struct {
+		ID uint `json:"id"`
+	}
+	decode(s.t, rec, &created)
+	return created.ID
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+}
+
+// expectError checks the status and error message of a failed request.
+func expectError(t *testing.T, rec *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+	expectStatus(t, rec, status)
+	var body struct {
+		ErrorMessage string `json:"error_message"`
+	}
+	decode(t, rec, &body)
+	if body.ErrorMessage != message {
+		t.Errorf("got error %q, want %q", body.ErrorMessage, message)
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSessions godoc
+// @Summary      Get active sessions
+// @Description  Get the sessions the logged in user is logged in with, most recently used first. The session of the bearer token is marked current.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  []responses.Session
+// @Failure      500  {object}  nil
+// @Router       /users/sessions [get]
+// @Security	 BearerAuth
+func (h *UserHandler) GetSessions(ctx *gin.Context) {
+	principal := middlewares.CurrentPrincipal(ctx)
+	sessions, err := h.sessions.GetUserSessions(principal.UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	sessionsResponse := make([]responses.Session, len(sessions))
+	for i, session := range sessions {
+		sessionsResponse[i].Set(session, principal.SessionID)
+	}
+	ctx.JSON(http.StatusOK, sessionsResponse)
+}
+
+// DeleteSession godoc
+// @Summary      Sign out a session
+// @Description  Sign out a session of the logged in user, e.g. on a lost device. Its access tokens are rejected and its refresh token revoked from now on.
+// @Tags         users
+// @Produce      json
+// @Param		 sessionId path uint true "ID number of the session"
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/sessions/{sessionId} [delete]
+// @Security	 BearerAuth
+func (h *UserHandler) DeleteSession(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("sessionId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	session, err := h.sessions.RevokeSession(middlewares.CurrentPrincipal(ctx).UserID, uint(parsedID))
+	if err == nil {
+		err = h.refreshTokens.RevokeRefreshTokensOfFamily(session.FamilyID)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Session with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "The session has been signed out.",
+	})
+}
@@ -0,0 +1,91 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+)
+
+// loginWith logs name in from a client with userAgent and returns their
+// access and refresh tokens.
+func (s *server) loginWith(name, userAgent string) (string, string) {
+	s.t.Helper()
+	body, err := json.Marshal(map[string]string{"email": name + "@example.com", "password": "secret1"})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/users/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	rec := s.send(req, "")
+	expectStatus(s.t, rec, http.StatusOK)
+	return tokens(s.t, rec)
+}
+
+type session struct {
+	ID        uint   `json:"id"`
+	UserAgent string `json:"user_agent"`
+	Current   bool   `json:"current"`
+}
+
+func (s *server) sessions(accessToken string) []session {
+	s.t.Helper()
+	rec := s.do("GET", "/users/sessions", accessToken, nil)
+	expectStatus(s.t, rec, http.StatusOK)
+	var sessions []session
+	decode(s.t, rec, &sessions)
+	return sessions
+}
+
+// TestDeleteSession checks signing out a session revokes its access and
+// refresh tokens, including those it was refreshed into, and nothing else.
+func TestDeleteSession(t *testing.T) {
+	s := newServer(t)
+	s.user("alice", models.RoleUser)
+	bobsToken := s.user("bob", models.RoleUser)
+	_, phoneRefresh := s.loginWith("alice", "phone")
+	laptopAccess, laptopRefresh := s.loginWith("alice", "laptop")
+	tabletAccess, tabletRefresh := s.loginWith("alice", "tablet")
+
+	// The phone refreshes once, its tokens stay in the same session.
+	rec := s.refresh(phoneRefresh)
+	expectStatus(t, rec, http.StatusOK)
+	phoneAccess, phoneRefresh := tokens(t, rec)
+
+	var phone, laptop session
+	for _, session := range s.sessions(laptopAccess) {
+		switch session.UserAgent {
+		case "phone":
+			phone = session
+		case "laptop":
+			laptop = session
+		}
+	}
+	if phone.ID == 0 || !laptop.Current || phone.Current {
+		t.Fatalf("got phone session %+v and laptop session %+v", phone, laptop)
+	}
+
+	path := fmt.Sprint("/users/sessions/", phone.ID)
+	expectStatus(t, s.do("DELETE", path, bobsToken, nil), http.StatusNotFound)
+	expectStatus(t, s.do("DELETE", path, laptopAccess, nil), http.StatusOK)
+	expectStatus(t, s.do("DELETE", path, laptopAccess, nil), http.StatusNotFound)
+
+	expectError(t, s.do("GET", "/users/sessions", phoneAccess, nil), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+	expectError(t, s.refresh(phoneRefresh), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+	for _, session := range s.sessions(laptopAccess) {
+		if session.ID == phone.ID {
+			t.Errorf("the signed out session is still listed")
+		}
+	}
+
+	// The other sessions keep working, refreshes included.
+	expectStatus(t, s.do("GET", "/users/sessions", tabletAccess, nil), http.StatusOK)
+	expectStatus(t, s.refresh(laptopRefresh), http.StatusOK)
+	expectStatus(t, s.refresh(tabletRefresh), http.StatusOK)
+}
@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"finalassignment.id/finalassignment/controllers/responses"
 	"finalassignment.id/finalassignment/database"
 	"finalassignment.id/finalassignment/dto"
-	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/policy"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+type SocialMediaHandler struct {
+	socialMedias database.SocialMediaRepository
+}
+
+func NewSocialMediaHandler(socialMedias database.SocialMediaRepository) *SocialMediaHandler {
+	return &SocialMediaHandler{socialMedias: socialMedias}
+}
+
 // CreateSocialMedia godoc
 // @Summary      Create a social media
 // @Description  Create a social media associated with the logged in user.
@@ -26,7 +35,8 @@ import (
 // @Failure      500  {object}  nil
 // @Router       /socialmedias [post]
 // @Security	 BearerAuth
-func CreateSocialMedia(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *SocialMediaHandler) CreateSocialMedia(ctx *gin.Context) {
 	var newSocmed dto.SocialMedia
 	if err := ctx.ShouldBindJSON(&newSocmed); err != nil {
 		abortBadRequest(err, ctx)
@@ -36,12 +46,8 @@ func CreateSocialMedia(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-	socmed, err := database.CreateSocialMedia(userID, &newSocmed)
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	socmed, err := h.socialMedias.CreateSocialMedia(userID, &newSocmed)
 	if err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -57,44 +63,84 @@ func CreateSocialMedia(ctx *gin.Context) {
 
 // GetSocialMedias godoc
 // @Summary      Get social medias
-// @Description  Get social medias.
+// @Description  Get a page of social medias, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.
 // @Tags         socialMedias
 // @Accept       json
 // @Produce      json
+// @Param        query query dto.ListQuery false "Paging, sorting and filters"
 // @Success      200  {object}  responses.GetAllSocialMedias
+// @Header       200  {string}  X-Next-Cursor  "Cursor of the next page, left out on the last page"
 // @Failure		 400 {object} responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /socialmedias [get]
 // @Security	 BearerAuth
-func GetAllSocialMedias(ctx *gin.Context) {
-	socmeds, err := database.GetAllSocialMedias()
+// @Security	 ApiKeyAuth
+func (h *SocialMediaHandler) GetAllSocialMedias(ctx *gin.Context) {
+	var query dto.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	options, ok := listOptions(ctx, query)
+	if !ok {
+		return
+	}
+	socmeds, next, err := h.socialMedias.GetAllSocialMedias(options)
 	if err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 	socmedsResponse := make([]responses.GetSocialMedia, len(socmeds))
-	userDtos := make(map[uint]dto.UserUpdate)
 	for i, socmed := range socmeds {
 		socmedsResponse[i].Set(socmed)
-		userDto, ok := userDtos[socmed.UserID]
-		if !ok {
-			userDto, err = database.GetUsernameAndEmail(socmed.UserID)
-			if err != nil {
-				ctx.AbortWithError(http.StatusInternalServerError, err)
-				return
-			}
-			userDtos[socmed.UserID] = userDto
-		}
-		socmedsResponse[i].User = responses.UserSocialMedia{
-			ID:       socmed.UserID,
-			Username: userDto.Username,
-		}
 	}
+	setNextCursor(ctx, options, next)
 	ctx.JSON(http.StatusOK, responses.GetAllSocialMedias{
 		SocialMedias: socmedsResponse,
 	})
 }
 
+// GetSocialMedia godoc
+// @Summary      Get a social media
+// @Description  Get a social media along with its user.
+// @Tags         socialMedias
+// @Accept       json
+// @Produce      json
+// @Param		 socialMediaId path uint true "ID number of the social media"
+// @Success      200  {object}  responses.GetSocialMedia
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /socialmedias/{socialMediaId} [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *SocialMediaHandler) GetSocialMedia(ctx *gin.Context) {
+	socmedID := ctx.Param("socialMediaId")
+	parsedID, err := strconv.ParseUint(socmedID, 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	socmed, err := h.socialMedias.GetSocialMediaWithUser(uint(parsedID))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Social media with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	var response responses.GetSocialMedia
+	response.Set(socmed)
+	ctx.JSON(http.StatusOK, response)
+}
+
 // UpdateSocialMedia godoc
 // @Summary      Update a social media
 // @Description  Update a social media associated with logged in user.
@@ -110,7 +156,8 @@ func GetAllSocialMedias(ctx *gin.Context) {
 // @Failure      500  {object}  nil
 // @Router       /socialmedias/{socialMediaId} [put]
 // @Security	 BearerAuth
-func UpdateSocialMedia(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *SocialMediaHandler) UpdateSocialMedia(ctx *gin.Context) {
 	socialMediaID := ctx.Param("socialMediaId")
 	parsedID, err := strconv.ParseUint(socialMediaID, 10, 0)
 	if err != nil {
@@ -126,14 +173,16 @@ func UpdateSocialMedia(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
+	var updatedAt time.Time
+	socmed, err := h.socialMedias.GetSingleSocialMedia(uint(parsedID))
+	if err == nil {
+		err = policy.Authorize(middlewares.CurrentPrincipal(ctx), policy.Update, policy.SocialMedia(socmed))
+	}
+	if err == nil {
+		updatedAt, err = h.socialMedias.UpdateSocialMedia(uint(parsedID), &socialMediaDto)
 	}
-	updatedAt, err := database.UpdateSocialMedia(uint(parsedID), userID, &socialMediaDto)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, database.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
 				ErrorMessage: fmt.Sprintf("Social media with ID %d is not found.", parsedID),
 			})
@@ -152,14 +201,14 @@ func UpdateSocialMedia(ctx *gin.Context) {
 		ID:             uint(parsedID),
 		Name:           socialMediaDto.Name,
 		SocialMediaUrl: socialMediaDto.SocialMediaUrl,
-		UserID:         userID,
+		UserID:         socmed.UserID,
 		UpdatedAt:      updatedAt,
 	})
 }
 
 // DeleteSocialMedia godoc
 // @Summary      Delete a social media
-// @Description  Delete a social media associated with logged in user.
+// @Description  Delete a social media associated with logged in user. Moderators can delete any social media.
 // @Tags         socialMedias
 // @Accept       json
 // @Produce      json
@@ -170,21 +219,25 @@ func UpdateSocialMedia(ctx *gin.Context) {
 // @Failure      404  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /socialmedias/{socialMediaId} [delete]
+// @Router       /admin/socialmedias/{socialMediaId} [delete]
 // @Security	 BearerAuth
-func DeleteSocialMedia(ctx *gin.Context) {
+// @Security	 ApiKeyAuth
+func (h *SocialMediaHandler) DeleteSocialMedia(ctx *gin.Context) {
 	socmedID := ctx.Param("socialMediaId")
 	parsedID, err := strconv.ParseUint(socmedID, 10, 0)
 	if err != nil {
 		abortBadRequest(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
+	socmed, err := h.socialMedias.GetSingleSocialMedia(uint(parsedID))
+	if err == nil {
+		err = policy.Authorize(middlewares.CurrentPrincipal(ctx), policy.Delete, policy.SocialMedia(socmed))
+	}
+	if err == nil {
+		err = h.socialMedias.DeleteSocialMedia(uint(parsedID))
 	}
-	if err := database.DeleteSocialMedia(uint(parsedID), userID); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
 				ErrorMessage: fmt.Sprintf("Social media with ID %d is not found.", parsedID),
 			})
@@ -0,0 +1,56 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+func newSocialMedia(name string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "social_media_url": "https://example.com/" + name}
+}
+
+func TestSocialMediaCRUD(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	socmedID := s.create("/socialmedias/", owner, newSocialMedia("first"))
+	path := fmt.Sprint("/socialmedias/", socmedID)
+
+	expectStatus(t, s.do("PUT", path, owner, newSocialMedia("renamed")), http.StatusOK)
+	rec := s.do("GET", path, owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var socmed struct {
+		Name           string `json:"name"`
+		SocialMediaUrl string `json:"social_media_url"`
+	}
+	decode(t, rec, &socmed)
+	if socmed.Name != "renamed" || socmed.SocialMediaUrl != "https://example.com/renamed" {
+		t.Errorf("got %+v", socmed)
+	}
+
+	expectStatus(t, s.do("DELETE", path, owner, nil), http.StatusOK)
+	expectError(t, s.do("GET", path, owner, nil), http.StatusNotFound, fmt.Sprintf("Social media with ID %d is not found.", socmedID))
+}
+
+func TestSocialMediaOwnership(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	other := s.user("other", models.RoleUser)
+	moderator := s.user("moderator", models.RoleModerator)
+	socmedID := s.create("/socialmedias/", owner, newSocialMedia("mine"))
+	path := fmt.Sprint("/socialmedias/", socmedID)
+
+	expectError(t, s.do("PUT", path, other, newSocialMedia("stolen")), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	expectError(t, s.do("DELETE", path, other, nil), http.StatusForbidden, database.ErrIllegalUpdate.Error())
+	socmed, err := s.repos.SocialMedias.GetSingleSocialMedia(socmedID)
+	if err != nil || socmed.Name != "mine" {
+		t.Fatalf("got %+v, %v", socmed, err)
+	}
+	expectStatus(t, s.do("DELETE", "/admin"+path, moderator, nil), http.StatusOK)
+	if _, err := s.repos.SocialMedias.GetSingleSocialMedia(socmedID); err != database.ErrNotFound {
+		t.Errorf("got %v after deleting, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTagCount       = 10
+	defaultTrendingWindow = 24 * time.Hour
+)
+
+type TagHandler struct {
+	tags database.TagRepository
+}
+
+func NewTagHandler(tags database.TagRepository) *TagHandler {
+	return &TagHandler{tags: tags}
+}
+
+// GetTags godoc
+// @Summary      Get tags
+// @Description  Get the tags starting with a prefix, with or without the #, on the most photos first, to complete hashtags as they are typed. Without a prefix the most used tags are listed.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        query query dto.TagQuery false "Prefix and number of tags"
+// @Success      200  {object}  responses.GetAllTags
+// @Failure		 400 {object} responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /tags [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *TagHandler) GetTags(ctx *gin.Context) {
+	var query dto.TagQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultTagCount
+	}
+	tags, err := h.tags.GetTags(database.NormalizeTag(query.Prefix), query.Limit)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	var response responses.GetAllTags
+	response.Set(tags)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTrendingTags godoc
+// @Summary      Get trending tags
+// @Description  Get the tags put on the most photos within a window of time up to now, along with the number of those photos. A tag counts for a photo from when it first appeared in the caption of the photo.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        query query dto.TrendingTagQuery false "Window and number of tags"
+// @Success      200  {object}  responses.GetAllTags
+// @Failure		 400 {object} responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /tags/trending [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *TagHandler) GetTrendingTags(ctx *gin.Context) {
+	var query dto.TrendingTagQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&query); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	if query.Window == 0 {
+		query.Window = defaultTrendingWindow
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultTagCount
+	}
+	tags, err := h.tags.GetTrendingTags(time.Now().Add(-query.Window), query.Limit)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	var response responses.GetAllTags
+	response.Set(tags)
+	ctx.JSON(http.StatusOK, response)
+}
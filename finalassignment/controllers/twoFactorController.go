@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/utils/totp"
+	"github.com/gin-gonic/gin"
+)
+
+const recoveryCodeCount = 10
+
+var errIncorrectCode = errors.New("The code is incorrect.")
+
+// LoginTwoFactor godoc
+// @Summary      Finish a two-factor login
+// @Description  Exchange the challenge token returned by /users/login and a code from the authenticator app, or an unused recovery code, for an access token and a refresh token. Wrong codes count as failed logins, and a challenge can only be exchanged once.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        login body dto.LoginTwoFactor true "Challenge token and code."
+// @Success      200  {object}  responses.UserLogin
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      401  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      429  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/login/2fa [post]
+func (h *UserHandler) LoginTwoFactor(ctx *gin.Context) {
+	var loginDto dto.LoginTwoFactor
+	if err := ctx.ShouldBindJSON(&loginDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&loginDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	userID, challengeID, challengeExpiresAt, err := token.ParseLoginChallenge(loginDto.ChallengeToken)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	user, err := h.users.GetUserWithoutPreload(userID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+				ErrorMessage: token.ErrInvalidChallenge.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if abortSuspended(ctx, user) || h.abortLoginLocked(ctx, user.Email) {
+		return
+	}
+	credential, err := h.twoFactor.GetTOTPCredential(user.ID)
+	if err == nil && credential.ConfirmedAt == nil {
+		err = database.ErrNotFound
+	}
+	if err == nil {
+		err = h.checkSecondFactor(credential, loginDto.Code)
+	}
+	if errors.Is(err, errIncorrectCode) {
+		if err := h.recordLoginFailure(ctx, user.Email); err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			// Two-factor login was turned off after the challenge was issued.
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+				ErrorMessage: token.ErrInvalidChallenge.Error(),
+			})
+			return
+		}
+		if errors.Is(err, errIncorrectCode) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	// A challenge is answered once, even if another valid code comes along.
+	fresh, err := h.revocations.ConsumeToken(challengeID, challengeExpiresAt)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if !fresh {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+			ErrorMessage: token.ErrInvalidChallenge.Error(),
+		})
+		return
+	}
+	h.startSession(ctx, user)
+}
+
+// EnrollTwoFactor godoc
+// @Summary      Start enabling two-factor login
+// @Description  Generate a new TOTP secret for the logged in user. Add the otpauth URI to an authenticator app, then send a code from it to /users/2fa/confirm to turn two-factor login on.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  responses.TwoFactorEnrollment
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/2fa/enroll [post]
+// @Security	 BearerAuth
+func (h *UserHandler) EnrollTwoFactor(ctx *gin.Context) {
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	credential, err := h.twoFactor.GetTOTPCredential(userID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err == nil && credential.ConfirmedAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "Two-factor login is already enabled, disable it first to enroll again.",
+		})
+		return
+	}
+	user, err := h.users.GetUserWithoutPreload(userID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.twoFactor.SaveTOTPCredential(&models.TOTPCredential{UserID: userID, Secret: secret}); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.TwoFactorEnrollment{
+		Secret:     secret,
+		OtpauthURI: totp.URI(h.totp.Issuer, user.Email, secret),
+	})
+}
+
+// ConfirmTwoFactor godoc
+// @Summary      Enable two-factor login
+// @Description  Turn two-factor login on with a code from the authenticator app enrolled by /users/2fa/enroll. The response holds recovery codes that can each be used once instead of a code; they are not shown again.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        code body dto.TwoFactorCode true "Code from the authenticator app."
+// @Success      200  {object}  responses.RecoveryCodes
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/2fa/confirm [post]
+// @Security	 BearerAuth
+func (h *UserHandler) ConfirmTwoFactor(ctx *gin.Context) {
+	var codeDto dto.TwoFactorCode
+	if err := ctx.ShouldBindJSON(&codeDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&codeDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	credential, err := h.twoFactor.GetTOTPCredential(userID)
+	if err == nil && credential.ConfirmedAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "Two-factor login is already enabled.",
+		})
+		return
+	}
+	var counter int64
+	if err == nil {
+		var ok bool
+		if counter, ok = totp.Validate(credential.Secret, codeDto.Code, time.Now()); !ok {
+			err = errIncorrectCode
+		}
+	}
+	var codes []string
+	if err == nil {
+		codes, err = totp.GenerateRecoveryCodes(recoveryCodeCount)
+	}
+	if err == nil {
+		hashes := make([]string, len(codes))
+		for i, code := range codes {
+			hashes[i] = token.HashOpaqueToken(totp.NormalizeRecoveryCode(code))
+		}
+		err = h.twoFactor.ConfirmTOTP(userID, counter, hashes)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: "Enroll with /users/2fa/enroll first.",
+			})
+			return
+		}
+		if errors.Is(err, errIncorrectCode) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.RecoveryCodes{
+		Message:       "Two-factor login has been enabled. Keep the recovery codes somewhere safe, they will not be shown again.",
+		RecoveryCodes: codes,
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable two-factor login
+// @Description  Turn two-factor login off for the logged in user. A code from the authenticator app or a recovery code is required.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        code body dto.TwoFactorCode true "Code from the authenticator app or a recovery code."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/2fa [delete]
+// @Security	 BearerAuth
+func (h *UserHandler) DisableTwoFactor(ctx *gin.Context) {
+	var codeDto dto.TwoFactorCode
+	if err := ctx.ShouldBindJSON(&codeDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&codeDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	credential, err := h.twoFactor.GetTOTPCredential(userID)
+	if err == nil && credential.ConfirmedAt == nil {
+		err = database.ErrNotFound
+	}
+	if err == nil {
+		err = h.checkSecondFactor(credential, codeDto.Code)
+	}
+	if err == nil {
+		err = h.twoFactor.DeleteTOTPCredential(userID)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: "Two-factor login is not enabled.",
+			})
+			return
+		}
+		if errors.Is(err, errIncorrectCode) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "Two-factor login has been disabled.",
+	})
+}
+
+// checkSecondFactor accepts a TOTP code that has not been used yet or an
+// unused recovery code, returning errIncorrectCode for anything else.
+func (h *UserHandler) checkSecondFactor(credential models.TOTPCredential, code string) error {
+	if counter, ok := totp.Validate(credential.Secret, code, time.Now()); ok {
+		fresh, err := h.twoFactor.UseTOTPCounter(credential.UserID, counter)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return errIncorrectCode
+		}
+		return nil
+	}
+	used, err := h.twoFactor.UseRecoveryCode(credential.UserID, token.HashOpaqueToken(totp.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errIncorrectCode
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/utils/totp"
+)
+
+// enableTwoFactor turns two-factor login on for the user of accessToken and
+// returns the secret, the time step of the code that confirmed it and the
+// recovery codes.
+func (s *server) enableTwoFactor(accessToken string) (string, int64, []string) {
+	s.t.Helper()
+	rec := s.do("POST", "/users/2fa/enroll", accessToken, nil)
+	expectStatus(s.t, rec, http.StatusOK)
+	var enrollment struct {
+		Secret string `json:"secret"`
+	}
+	decode(s.t, rec, &enrollment)
+	counter := totp.Counter(time.Now())
+	rec = s.do("POST", "/users/2fa/confirm", accessToken, map[string]string{"code": s.totpCode(enrollment.Secret, counter)})
+	expectStatus(s.t, rec, http.StatusOK)
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	decode(s.t, rec, &confirmed)
+	return enrollment.Secret, counter, confirmed.RecoveryCodes
+}
+
+func (s *server) totpCode(secret string, counter int64) string {
+	s.t.Helper()
+	code, err := totp.Code(secret, counter)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return code
+}
+
+// challenge logs name in with their password and returns the challenge
+// token answered instead of tokens.
+func (s *server) challenge(name string) string {
+	s.t.Helper()
+	rec := s.do("POST", "/users/login", "", map[string]string{"email": name + "@example.com", "password": "secret1"})
+	expectStatus(s.t, rec, http.StatusAccepted)
+	var body struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+	decode(s.t, rec, &body)
+	if body.ChallengeToken == "" {
+		s.t.Fatalf("no challenge token in %s", rec.Body)
+	}
+	return body.ChallengeToken
+}
+
+func (s *server) answer(challenge, code string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.do("POST", "/users/login/2fa", "", map[string]string{"challenge_token": challenge, "code": code})
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	s := newServer(t)
+	accessToken := s.user("alice", models.RoleUser)
+	secret, counter, codes := s.enableTwoFactor(accessToken)
+
+	challenge := s.challenge("alice")
+	// The code that turned two-factor login on was used already.
+	expectError(t, s.answer(challenge, s.totpCode(secret, counter)), http.StatusUnauthorized, "The code is incorrect.")
+	expectError(t, s.answer("not.a.token", s.totpCode(secret, counter+1)), http.StatusUnauthorized, token.ErrInvalidChallenge.Error())
+
+	rec := s.answer(challenge, s.totpCode(secret, counter+1))
+	expectStatus(t, rec, http.StatusOK)
+	var login struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	decode(t, rec, &login)
+	if login.Token == "" || login.RefreshToken == "" {
+		t.Fatalf("got no tokens in %s", rec.Body)
+	}
+	expectStatus(t, s.do("GET", "/users/sessions", login.Token, nil), http.StatusOK)
+
+	// The challenge can't be answered again, even with another valid code.
+	expectError(t, s.answer(challenge, codes[0]), http.StatusUnauthorized, token.ErrInvalidChallenge.Error())
+	// Neither can the code, with a new challenge.
+	expectError(t, s.answer(s.challenge("alice"), s.totpCode(secret, counter+1)), http.StatusUnauthorized, "The code is incorrect.")
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	s := newServer(t)
+	accessToken := s.user("alice", models.RoleUser)
+	_, _, codes := s.enableTwoFactor(accessToken)
+	if len(codes) != 10 {
+		t.Fatalf("got %d recovery codes, want 10", len(codes))
+	}
+
+	// Recovery codes may be typed without dashes and in lower case.
+	typed := strings.ToLower(strings.ReplaceAll(codes[0], "-", ""))
+	expectStatus(t, s.answer(s.challenge("alice"), typed), http.StatusOK)
+	expectError(t, s.answer(s.challenge("alice"), codes[0]), http.StatusUnauthorized, "The code is incorrect.")
+	expectStatus(t, s.answer(s.challenge("alice"), codes[1]), http.StatusOK)
+
+	// Disabling takes a code too, and logins get tokens right away again.
+	expectError(t, s.do("DELETE", "/users/2fa", accessToken, map[string]string{"code": codes[1]}), http.StatusBadRequest, "The code is incorrect.")
+	expectStatus(t, s.do("DELETE", "/users/2fa", accessToken, map[string]string{"code": codes[2]}), http.StatusOK)
+	rec := s.do("POST", "/users/login", "", map[string]string{"email": "alice@example.com", "password": "secret1"})
+	expectStatus(t, rec, http.StatusOK)
+}
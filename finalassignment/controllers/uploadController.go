@@ -0,0 +1,413 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/imageinfo"
+	"finalassignment.id/finalassignment/utils/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is how much larger than the photo itself a multipart
+// body may be, to leave room for the boundaries and the other fields.
+const multipartOverhead = 64 << 10
+
+// photoKeyPrefix is where stored photos live. Only blobs under it are served
+// publicly; upload chunks are not.
+const photoKeyPrefix = "photos/"
+
+// StartPhotoUpload godoc
+// @Summary      Start a resumable photo upload
+// @Description  Start uploading a photo of the given size in chunks. Send the chunks in order with PATCH /photos/uploads/{uploadId}, then create the photo with its upload_id. Unfinished uploads expire after storage.upload_ttl.
+// @Tags         photos
+// @Accept       json
+// @Produce      json
+// @Param        upload body dto.PhotoUploadStart true "Size of the whole photo in bytes."
+// @Success      201  {object}  responses.PhotoUpload
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      413  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /photos/uploads [post]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) StartPhotoUpload(ctx *gin.Context) {
+	var start dto.PhotoUploadStart
+	if err := ctx.ShouldBindJSON(&start); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&start); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	if start.Size > h.storage.MaxUploadSize {
+		h.abortTooLarge(ctx)
+		return
+	}
+	// Sweep the uploads that were given up on while we are here.
+	expired, err := h.uploads.DeleteExpiredPhotoUploads(time.Now())
+	if err != nil {
+		ctx.Error(err)
+	}
+	for _, upload := range expired {
+		h.deleteChunks(ctx, upload)
+	}
+	upload := models.PhotoUpload{
+		ID:        uuid.NewString(),
+		UserID:    middlewares.CurrentPrincipal(ctx).UserID,
+		Size:      start.Size,
+		ExpiresAt: time.Now().Add(h.storage.UploadTTL),
+	}
+	if err := h.uploads.CreatePhotoUpload(&upload); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, uploadResponse(upload))
+}
+
+// GetPhotoUpload godoc
+// @Summary      Get a resumable photo upload
+// @Description  Get how far an upload of the logged in user has got, to resume it after a broken connection.
+// @Tags         photos
+// @Produce      json
+// @Param		 uploadId path string true "ID of the upload"
+// @Success      200  {object}  responses.PhotoUpload
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /photos/uploads/{uploadId} [get]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) GetPhotoUpload(ctx *gin.Context) {
+	upload, ok := h.findUpload(ctx, ctx.Param("uploadId"))
+	if !ok {
+		return
+	}
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+	ctx.JSON(http.StatusOK, uploadResponse(upload))
+}
+
+// UploadPhotoChunk godoc
+// @Summary      Upload a chunk of a photo
+// @Description  Append the request body to an upload. Upload-Offset has to be the number of bytes received so far; if it is not, nothing is stored and the current offset is sent back in the Upload-Offset header.
+// @Tags         photos
+// @Accept       application/offset+octet-stream
+// @Produce      json
+// @Param		 uploadId path string true "ID of the upload"
+// @Param		 Upload-Offset header int true "Offset of the chunk in the photo"
+// @Param        chunk body string true "Bytes of the chunk"
+// @Success      200  {object}  responses.PhotoUpload
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      409  {object}  responses.ErrorMessage
+// @Failure      411  {object}  responses.ErrorMessage
+// @Failure      413  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /photos/uploads/{uploadId} [patch]
+// @Security	 BearerAuth
+// @Security	 ApiKeyAuth
+func (h *PhotoHandler) UploadPhotoChunk(ctx *gin.Context) {
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "The Upload-Offset header has to be the offset of the chunk.",
+		})
+		return
+	}
+	upload, ok := h.findUpload(ctx, ctx.Param("uploadId"))
+	if !ok {
+		return
+	}
+	if offset != upload.Received {
+		h.abortOffsetMismatch(ctx, upload.Received)
+		return
+	}
+	length := ctx.Request.ContentLength
+	if length <= 0 {
+		ctx.AbortWithStatusJSON(http.StatusLengthRequired, responses.ErrorMessage{
+			ErrorMessage: "Chunks have to be sent with a Content-Length.",
+		})
+		return
+	}
+	if offset+length > upload.Size {
+		ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, responses.ErrorMessage{
+			ErrorMessage: fmt.Sprintf("The chunk goes past the %d bytes of the upload.", upload.Size),
+		})
+		return
+	}
+	// Requests racing for this offset each store their chunk under a key
+	// of their own, only the one counted first is kept.
+	key := upload.ChunkKey(uuid.NewString())
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, length)
+	if err := h.blobs.Put(ctx.Request.Context(), key, body, length, "application/octet-stream"); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	upload, err = h.uploads.AddPhotoUploadChunk(upload.ID, offset, length, key)
+	if err != nil {
+		if err := h.blobs.Delete(ctx.Request.Context(), key); err != nil {
+			ctx.Error(err)
+		}
+		if errors.Is(err, database.ErrUploadOffsetMismatch) {
+			// Another request stored a chunk at this offset first.
+			current, ok := h.findUpload(ctx, ctx.Param("uploadId"))
+			if ok {
+				h.abortOffsetMismatch(ctx, current.Received)
+			}
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+	ctx.JSON(http.StatusOK, uploadResponse(upload))
+}
+
+// GetStoredPhoto godoc
+// @Summary      Get an uploaded photo
+// @Description  Get the image of a photo that was uploaded instead of linked. Its photo_url points here unless storage.public_url is set elsewhere.
+// @Tags         photos
+// @Produce      jpeg,png,gif,image/webp
+// @Param		 key path string true "Storage key of the photo, such as photos/0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11.jpg"
+// @Success      200
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /uploads/{key} [get]
+func (h *PhotoHandler) GetStoredPhoto(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	var blob io.ReadCloser
+	err := storage.ErrNotFound
+	if strings.HasPrefix(key, photoKeyPrefix) && fs.ValidPath(key) {
+		blob, err = h.blobs.Get(ctx.Request.Context(), key)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Upload %s is not found.", key),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer blob.Close()
+	// Stored photos are never overwritten, a new upload gets a new key.
+	ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.DataFromReader(http.StatusOK, -1, mime.TypeByExtension(path.Ext(key)), blob, nil)
+}
+
+// createPhotoFromForm creates a photo out of a multipart/form-data body,
+// with the image in its photo field.
+func (h *PhotoHandler) createPhotoFromForm(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, h.storage.MaxUploadSize+multipartOverhead)
+	if err := ctx.Request.ParseMultipartForm(multipartOverhead); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.abortTooLarge(ctx)
+			return
+		}
+		abortBadRequest(err, ctx)
+		return
+	}
+	var form dto.PhotoForm
+	if err := ctx.ShouldBind(&form); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&form); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	header, err := ctx.FormFile("photo")
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "Send the image as the photo field of the form.",
+		})
+		return
+	}
+	if header.Size > h.storage.MaxUploadSize {
+		h.abortTooLarge(ctx)
+		return
+	}
+	image, err := header.Open()
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer image.Close()
+	file, ok := h.storePhoto(ctx, image, header.Size)
+	if !ok {
+		return
+	}
+	h.createPhoto(ctx, &dto.Photo{
+		Title:    form.Title,
+		Caption:  form.Caption,
+		PhotoUrl: h.fileURL(file.StorageKey),
+	}, file)
+}
+
+// finishUpload stores the photo put together from the chunks of a complete
+// upload, and then drops the upload.
+func (h *PhotoHandler) finishUpload(ctx *gin.Context, uploadID string) (models.PhotoFile, bool) {
+	upload, ok := h.findUpload(ctx, uploadID)
+	if !ok {
+		return models.PhotoFile{}, false
+	}
+	if upload.Received < upload.Size {
+		ctx.AbortWithStatusJSON(http.StatusConflict, responses.ErrorMessage{
+			ErrorMessage: fmt.Sprintf("Upload %s is not complete, %d of %d bytes have been received.", upload.ID, upload.Received, upload.Size),
+		})
+		return models.PhotoFile{}, false
+	}
+	chunks := &chunkReader{ctx: ctx, blobs: h.blobs, upload: upload}
+	file, ok := h.storePhoto(ctx, chunks, upload.Size)
+	chunks.Close()
+	if !ok {
+		return models.PhotoFile{}, false
+	}
+	if err := h.uploads.DeletePhotoUpload(upload.ID); err != nil {
+		ctx.Error(err)
+	}
+	h.deleteChunks(ctx, upload)
+	return file, true
+}
+
+// storePhoto checks that r holds an image we accept and stores it under a
+// new key.
+func (h *PhotoHandler) storePhoto(ctx *gin.Context, r io.Reader, size int64) (models.PhotoFile, bool) {
+	info, r, err := imageinfo.Inspect(r)
+	if err != nil {
+		if errors.Is(err, imageinfo.ErrUnsupported) {
+			ctx.AbortWithStatusJSON(http.StatusUnsupportedMediaType, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return models.PhotoFile{}, false
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return models.PhotoFile{}, false
+	}
+	key := photoKeyPrefix + uuid.NewString() + imageinfo.Extension(info.MIMEType)
+	if err := h.blobs.Put(ctx.Request.Context(), key, r, size, info.MIMEType); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return models.PhotoFile{}, false
+	}
+	return models.PhotoFile{
+		StorageKey: key,
+		MimeType:   info.MIMEType,
+		ByteSize:   size,
+		Width:      info.Width,
+		Height:     info.Height,
+	}, true
+}
+
+// findUpload gets an unexpired upload of the current user, or responds with
+// 404.
+func (h *PhotoHandler) findUpload(ctx *gin.Context, uploadID string) (models.PhotoUpload, bool) {
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	upload, err := h.uploads.GetPhotoUpload(userID, uploadID)
+	if err == nil && upload.ExpiresAt.Before(time.Now()) {
+		err = database.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("Upload %s is not found.", uploadID),
+			})
+			return models.PhotoUpload{}, false
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return models.PhotoUpload{}, false
+	}
+	return upload, true
+}
+
+func (h *PhotoHandler) deleteChunks(ctx *gin.Context, upload models.PhotoUpload) {
+	for _, chunk := range upload.Chunks {
+		if err := h.blobs.Delete(ctx.Request.Context(), chunk.StorageKey); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+func (h *PhotoHandler) fileURL(key string) string {
+	return h.storage.PublicURL + "/" + key
+}
+
+func (h *PhotoHandler) abortTooLarge(ctx *gin.Context) {
+	ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, responses.ErrorMessage{
+		ErrorMessage: fmt.Sprintf("Photos can be at most %d bytes.", h.storage.MaxUploadSize),
+	})
+}
+
+func (h *PhotoHandler) abortOffsetMismatch(ctx *gin.Context, received int64) {
+	ctx.Header("Upload-Offset", strconv.FormatInt(received, 10))
+	ctx.AbortWithStatusJSON(http.StatusConflict, responses.ErrorMessage{
+		ErrorMessage: database.ErrUploadOffsetMismatch.Error(),
+	})
+}
+
+func uploadResponse(upload models.PhotoUpload) responses.PhotoUpload {
+	return responses.PhotoUpload{
+		UploadID:  upload.ID,
+		Size:      upload.Size,
+		Received:  upload.Received,
+		ExpiresAt: upload.ExpiresAt,
+	}
+}
+
+// chunkReader reads the chunks of an upload one after another.
+type chunkReader struct {
+	ctx     *gin.Context
+	blobs   storage.BlobStore
+	upload  models.PhotoUpload
+	next    int
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next == len(r.upload.Chunks) {
+				return 0, io.EOF
+			}
+			chunk, err := r.blobs.Get(r.ctx.Request.Context(), r.upload.Chunks[r.next].StorageKey)
+			if err != nil {
+				return 0, err
+			}
+			r.current = chunk
+			r.next++
+		}
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
@@ -0,0 +1,227 @@
+package controllers_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"finalassignment.id/finalassignment/models"
+)
+
+// pngImage encodes a width by height image.
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// postForm creates a photo out of a multipart/form-data body, with data as
+// its photo field unless it is nil.
+func (s *server) postForm(accessToken string, data []byte) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("title", "uploaded")
+	form.WriteField("caption", "caption")
+	if data != nil {
+		part, err := form.CreateFormFile("photo", "photo.png")
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	form.Close()
+	req := httptest.NewRequest("POST", "/photos/", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return s.send(req, accessToken)
+}
+
+// patchChunk sends data as the chunk of uploadID at offset.
+func (s *server) patchChunk(accessToken, uploadID string, offset int, data []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PATCH", "/photos/uploads/"+uploadID, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.Itoa(offset))
+	return s.send(req, accessToken)
+}
+
+// startUpload starts an upload of size bytes and returns its ID.
+func (s *server) startUpload(accessToken string, size int) string {
+	s.t.Helper()
+	rec := s.do("POST", "/photos/uploads", accessToken, map[string]interface{}{"size": size})
+	expectStatus(s.t, rec, http.StatusCreated)
+	var upload struct {
+		UploadID string `json:"upload_id"`
+	}
+	decode(s.t, rec, &upload)
+	return upload.UploadID
+}
+
+// expectStoredPhoto checks the photo created by rec serves data.
+func (s *server) expectStoredPhoto(rec *httptest.ResponseRecorder, data []byte) {
+	s.t.Helper()
+	expectStatus(s.t, rec, http.StatusCreated)
+	var created struct {
+		ID       uint   `json:"id"`
+		PhotoUrl string `json:"photo_url"`
+	}
+	decode(s.t, rec, &created)
+	photoURL, err := url.Parse(created.PhotoUrl)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	stored := s.do("GET", photoURL.Path, "", nil)
+	expectStatus(s.t, stored, http.StatusOK)
+	if !bytes.Equal(stored.Body.Bytes(), data) || stored.Header().Get("Content-Type") != "image/png" {
+		s.t.Errorf("the photo is served as %d %s bytes, want the %d uploaded", stored.Body.Len(), stored.Header().Get("Content-Type"), len(data))
+	}
+	photo, err := s.repos.Photos.GetSinglePhoto(created.ID)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if photo.MimeType != "image/png" || photo.ByteSize != int64(len(data)) || photo.Width != 40 || photo.Height != 30 {
+		s.t.Errorf("got file %+v", photo.PhotoFile)
+	}
+}
+
+// storedChunks counts the chunk blobs of uploadID left in storage.
+func (s *server) storedChunks(uploadID string) int {
+	s.t.Helper()
+	chunks, err := os.ReadDir(filepath.Join(s.cfg.Storage.Path, "uploads", uploadID))
+	if err != nil && !os.IsNotExist(err) {
+		s.t.Fatal(err)
+	}
+	return len(chunks)
+}
+
+func TestCreatePhotoFromForm(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	data := pngImage(t, 40, 30)
+	s.expectStoredPhoto(s.postForm(owner, data), data)
+
+	expectError(t, s.postForm(owner, nil), http.StatusBadRequest, "Send the image as the photo field of the form.")
+	rec := s.postForm(owner, []byte("not an image at all"))
+	expectStatus(t, rec, http.StatusUnsupportedMediaType)
+}
+
+func TestUploadSizeLimits(t *testing.T) {
+	data := pngImage(t, 40, 30)
+	s := newServer(t, "-storage-max-upload-size", strconv.Itoa(len(data)-1))
+	owner := s.user("owner", models.RoleUser)
+	tooLarge := fmt.Sprintf("Photos can be at most %d bytes.", len(data)-1)
+	expectError(t, s.postForm(owner, data), http.StatusRequestEntityTooLarge, tooLarge)
+	rec := s.do("POST", "/photos/uploads", owner, map[string]interface{}{"size": len(data)})
+	expectError(t, rec, http.StatusRequestEntityTooLarge, tooLarge)
+
+	// Chunks can't go past the size the upload was started with.
+	uploadID := s.startUpload(owner, 10)
+	rec = s.patchChunk(owner, uploadID, 0, data[:11])
+	expectError(t, rec, http.StatusRequestEntityTooLarge, "The chunk goes past the 10 bytes of the upload.")
+	if s.storedChunks(uploadID) != 0 {
+		t.Error("a chunk past the size was stored")
+	}
+}
+
+func TestResumableUpload(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	data := pngImage(t, 40, 30)
+	uploadID := s.startUpload(owner, len(data))
+	half := len(data) / 2
+
+	expectStatus(t, s.patchChunk(owner, uploadID, 0, data[:half]), http.StatusOK)
+	// Resending the chunk, as a client that lost the response would, is
+	// answered with the offset to go on from.
+	rec := s.patchChunk(owner, uploadID, 0, data[:half])
+	expectStatus(t, rec, http.StatusConflict)
+	if got := rec.Header().Get("Upload-Offset"); got != strconv.Itoa(half) {
+		t.Errorf("got Upload-Offset %q, want %d", got, half)
+	}
+	rec = s.do("GET", "/photos/uploads/"+uploadID, owner, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Upload-Offset"); got != strconv.Itoa(half) {
+		t.Errorf("got Upload-Offset %q, want %d", got, half)
+	}
+
+	rec = s.do("POST", "/photos/", owner, map[string]interface{}{"title": "uploaded", "upload_id": uploadID})
+	expectError(t, rec, http.StatusConflict,
+		fmt.Sprintf("Upload %s is not complete, %d of %d bytes have been received.", uploadID, half, len(data)))
+
+	expectStatus(t, s.patchChunk(owner, uploadID, half, data[half:]), http.StatusOK)
+	s.expectStoredPhoto(s.do("POST", "/photos/", owner, map[string]interface{}{"title": "uploaded", "upload_id": uploadID}), data)
+	if n := s.storedChunks(uploadID); n != 0 {
+		t.Errorf("%d chunks were left behind", n)
+	}
+	expectError(t, s.do("GET", "/photos/uploads/"+uploadID, owner, nil), http.StatusNotFound,
+		fmt.Sprintf("Upload %s is not found.", uploadID))
+}
+
+func TestUploadIsPrivate(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	other := s.user("other", models.RoleUser)
+	uploadID := s.startUpload(owner, 10)
+	notFound := fmt.Sprintf("Upload %s is not found.", uploadID)
+	expectError(t, s.do("GET", "/photos/uploads/"+uploadID, other, nil), http.StatusNotFound, notFound)
+	expectError(t, s.patchChunk(other, uploadID, 0, []byte("0123456789")), http.StatusNotFound, notFound)
+	// Chunks are not served as photos.
+	expectStatus(t, s.patchChunk(owner, uploadID, 0, []byte("0123456789")), http.StatusOK)
+	expectStatus(t, s.do("GET", "/uploads/uploads/"+uploadID+"/0", "", nil), http.StatusNotFound)
+}
+
+func TestConcurrentChunks(t *testing.T) {
+	s := newServer(t)
+	owner := s.user("owner", models.RoleUser)
+	data := pngImage(t, 40, 30)
+	// PNG decoders stop at the end of the image, so the chunks racing for
+	// the offset after it can be told apart.
+	tails := [][]byte{[]byte("AAAAAAAA"), []byte("BBBBBBBB"), []byte("CCCCCCCC"), []byte("DDDDDDDD")}
+	uploadID := s.startUpload(owner, len(data)+8)
+	expectStatus(t, s.patchChunk(owner, uploadID, 0, data), http.StatusOK)
+
+	codes := make([]int, len(tails))
+	var wg sync.WaitGroup
+	for i, tail := range tails {
+		wg.Add(1)
+		go func(i int, tail []byte) {
+			defer wg.Done()
+			codes[i] = s.patchChunk(owner, uploadID, len(data), tail).Code
+		}(i, tail)
+	}
+	wg.Wait()
+	winner := -1
+	for i, code := range codes {
+		switch {
+		case code == http.StatusOK && winner == -1:
+			winner = i
+		case code != http.StatusConflict:
+			t.Fatalf("got statuses %v, want one 200 and the rest 409", codes)
+		}
+	}
+	if winner == -1 {
+		t.Fatalf("got statuses %v, want one 200 and the rest 409", codes)
+	}
+	if n := s.storedChunks(uploadID); n != 2 {
+		t.Errorf("%d chunks are stored, want the 2 counted", n)
+	}
+	rec := s.do("POST", "/photos/", owner, map[string]interface{}{"title": "uploaded", "upload_id": uploadID})
+	s.expectStoredPhoto(rec, append(data, tails[winner]...))
+}
@@ -2,20 +2,71 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"finalassignment.id/finalassignment/config"
 	"finalassignment.id/finalassignment/controllers/responses"
 	"finalassignment.id/finalassignment/database"
 	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/policy"
+	"finalassignment.id/finalassignment/utils/mailer"
+	"finalassignment.id/finalassignment/utils/oidc"
 	"finalassignment.id/finalassignment/utils/token"
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgconn"
-	"gorm.io/gorm"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
+type UserHandler struct {
+	users          database.UserRepository
+	refreshTokens  database.RefreshTokenRepository
+	sessions       database.SessionRepository
+	revocations    database.RevocationStore
+	passwordResets database.PasswordResetRepository
+	twoFactor      database.TwoFactorRepository
+	loginAttempts  database.LoginAttemptStore
+	identities     database.IdentityRepository
+	// identityProvider is nil unless OpenID Connect login is configured.
+	identityProvider *oidc.Provider
+	mailer           mailer.Mailer
+	passwords        config.Password
+	login            config.Login
+	verification     config.Verification
+	totp             config.TOTP
+	publicURL        string
+}
+
+func NewUserHandler(repos database.Repositories, mailer mailer.Mailer, cfg *config.Config) *UserHandler {
+	h := &UserHandler{
+		users:          repos.Users,
+		refreshTokens:  repos.RefreshTokens,
+		sessions:       repos.Sessions,
+		revocations:    repos.Revocations,
+		passwordResets: repos.PasswordResets,
+		twoFactor:      repos.TwoFactor,
+		loginAttempts:  repos.LoginAttempts,
+		identities:     repos.Identities,
+		mailer:         mailer,
+		passwords:      cfg.Password,
+		login:          cfg.Login,
+		verification:   cfg.Verification,
+		totp:           cfg.TOTP,
+		publicURL:      cfg.HTTP.PublicURL,
+	}
+	if cfg.OIDC.Issuer != "" {
+		h.identityProvider = oidc.NewProvider(cfg.OIDC)
+	}
+	return h
+}
+
 // RegisterUser godoc
 // @Summary      Register a new user
-// @Description  Register a new user.
+// @Description  Register a new user. A link to verify the email is sent to it.
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -25,7 +76,7 @@ import (
 // @Failure      400  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /users/register [post]
-func RegisterUser(ctx *gin.Context) {
+func (h *UserHandler) RegisterUser(ctx *gin.Context) {
 	var newUser dto.UserRegister
 	if err := ctx.ShouldBindJSON(&newUser); err != nil {
 		abortBadRequest(err, ctx)
@@ -35,40 +86,55 @@ func RegisterUser(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	ID, err := database.CreateUser(&newUser)
+	passwordHash, err := h.hashPassword(newUser.Password)
 	if err != nil {
-		var perr *pgconn.PgError
-		if ok := errors.As(err, &perr); ok {
-			if perr.Code == uniqueViolationErr {
-				ctx.AbortWithStatusJSON(209, responses.Message{
-					Message: "The email or username is already registered. If it is yours, do login instead.",
-				})
-				return
-			}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	user := models.User{
+		Username: newUser.Username,
+		Email:    newUser.Email,
+		Password: passwordHash,
+		Age:      newUser.Age,
+		Role:     models.RoleUser,
+	}
+	if err := h.users.CreateUser(&user); err != nil {
+		if errors.Is(err, database.ErrDuplicate) {
+			ctx.AbortWithStatusJSON(209, responses.Message{
+				Message: "The email or username is already registered. If it is yours, do login instead.",
+			})
+			return
 		}
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	// The account exists either way, the user can ask for the email again.
+	if _, err := h.sendVerification(user); err != nil {
+		ctx.Error(err)
+	}
 	ctx.JSON(http.StatusCreated, responses.UserRegister{
 		Age:      newUser.Age,
 		Email:    newUser.Email,
-		ID:       ID,
+		ID:       user.ID,
 		Username: newUser.Username,
 	})
 }
 
 // LoginUser godoc
 // @Summary      Login a user
-// @Description  Login a user.
+// @Description  Login a user. Returns a short lived access token and a refresh token to renew it with /users/refresh. Users with two-factor authentication enabled get a challenge token instead, to be sent to /users/login/2fa along with a code. Repeated failures lock the account and the client IP out for a while.
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        user body dto.UserLogin true "JSON of the user to login. Minimum password length is 6."
 // @Success      200  {object}  responses.UserLogin
+// @Success      202  {object}  responses.LoginChallenge
 // @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      429  {object}  responses.ErrorMessage
 // @Failure      500  {object}  nil
 // @Router       /users/login [post]
-func LoginUser(ctx *gin.Context) {
+func (h *UserHandler) LoginUser(ctx *gin.Context) {
 	var userLogin dto.UserLogin
 	if err := ctx.ShouldBindJSON(&userLogin); err != nil {
 		abortBadRequest(err, ctx)
@@ -78,9 +144,20 @@ func LoginUser(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	jwt, err := database.GenerateToken(userLogin)
+	if h.abortLoginLocked(ctx, userLogin.Email) {
+		return
+	}
+	user, err := h.users.GetUserByEmail(userLogin.Email)
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userLogin.Password))
+	}
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, database.ErrPasswordMismatch) {
+		// Users who signed up through OpenID Connect have no password hash.
+		if errors.Is(err, database.ErrNotFound) || errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrHashTooShort) {
+			if err := h.recordLoginFailure(ctx, userLogin.Email); err != nil {
+				ctx.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
 			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
 				ErrorMessage: "Email or password is incorrect.",
 			})
@@ -89,14 +166,318 @@ func LoginUser(ctx *gin.Context) {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	if cost, err := bcrypt.Cost([]byte(user.Password)); err == nil && cost < h.passwords.BcryptCost {
+		// The password is at hand, upgrade a hash made before the cost was
+		// raised.
+		if passwordHash, err := h.hashPassword(userLogin.Password); err != nil {
+			ctx.Error(err)
+		} else if err := h.users.UpdatePassword(user.ID, passwordHash); err != nil {
+			ctx.Error(err)
+		}
+	}
+	h.completeLogin(ctx, user)
+}
+
+// completeLogin logs in user, whose identity has been proven, unless they
+// are suspended. Users with two-factor login enabled get a challenge to
+// answer at /users/login/2fa instead of tokens.
+func (h *UserHandler) completeLogin(ctx *gin.Context, user models.User) {
+	if abortSuspended(ctx, user) {
+		return
+	}
+	credential, err := h.twoFactor.GetTOTPCredential(user.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err == nil && credential.ConfirmedAt != nil {
+		challenge, err := token.GenerateLoginChallenge(user.ID, h.totp.ChallengeTTL)
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		ctx.JSON(http.StatusAccepted, responses.LoginChallenge{
+			ChallengeToken: challenge,
+			ExpiresIn:      int64(h.totp.ChallengeTTL.Seconds()),
+		})
+		return
+	}
+	h.startSession(ctx, user)
+}
+
+// startSession issues user a new refresh token family and an access token,
+// and forgets the failed logins of their account.
+func (h *UserHandler) startSession(ctx *gin.Context, user models.User) {
+	if err := h.loginAttempts.ClearLoginFailures(accountLoginKey(user.Email)); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	refreshToken, hash, expiresAt, err := token.GenerateRefreshToken()
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	familyID := uuid.NewString()
+	err = h.refreshTokens.CreateRefreshToken(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	session, err := h.createSession(ctx, user.ID, familyID, expiresAt)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	respondWithTokens(ctx, user, session.ID, refreshToken)
+}
+
+// RefreshToken godoc
+// @Summary      Refresh an access token
+// @Description  Exchange a refresh token for a new access token and a new refresh token. Each refresh token can only be used once, using it again revokes every refresh token descended from the same login.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        refreshToken body dto.RefreshToken true "Refresh token returned by the last login or refresh."
+// @Success      200  {object}  responses.UserLogin
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      401  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/refresh [post]
+func (h *UserHandler) RefreshToken(ctx *gin.Context) {
+	var refreshDto dto.RefreshToken
+	if err := ctx.ShouldBindJSON(&refreshDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&refreshDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	refreshToken, hash, expiresAt, err := token.GenerateRefreshToken()
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	next := models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	}
+	current, err := h.refreshTokens.RotateRefreshToken(token.HashRefreshToken(refreshDto.RefreshToken), &next)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+				ErrorMessage: "The refresh token is invalid.",
+			})
+			return
+		}
+		if errors.Is(err, database.ErrRefreshTokenReused) {
+			// The access tokens of the session go along with its refresh
+			// tokens, unless it was signed out already.
+			session, err := h.sessions.GetSessionByFamily(current.FamilyID)
+			if err == nil && session.RevokedAt != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+					ErrorMessage: middlewares.ErrSignedOut.Error(),
+				})
+				return
+			}
+			if err := h.sessions.RevokeSessionByFamily(current.FamilyID); err != nil {
+				ctx.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if errors.Is(err, database.ErrRefreshTokenExpired) || errors.Is(err, database.ErrRefreshTokenReused) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	user, err := h.users.GetUserWithoutPreload(current.UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if abortSuspended(ctx, user) {
+		return
+	}
+	session, err := h.sessions.GetSessionByFamily(current.FamilyID)
+	if errors.Is(err, database.ErrNotFound) {
+		// Logins from before sessions were recorded get one on their next
+		// refresh.
+		session, err = h.createSession(ctx, user.ID, current.FamilyID, expiresAt)
+	} else if err == nil && session.RevokedAt != nil {
+		err = h.refreshTokens.RevokeRefreshTokensOfFamily(current.FamilyID)
+		if err == nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorMessage{
+				ErrorMessage: middlewares.ErrSignedOut.Error(),
+			})
+			return
+		}
+	} else if err == nil {
+		err = h.sessions.ExtendSession(session.ID, time.Now(), expiresAt)
+	}
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	respondWithTokens(ctx, user, session.ID, refreshToken)
+}
+
+// createSession records a login of userID from the client of ctx, whose
+// refresh tokens are of familyID.
+func (h *UserHandler) createSession(ctx *gin.Context, userID uint, familyID string, expiresAt time.Time) (models.Session, error) {
+	session := models.Session{
+		UserID:     userID,
+		FamilyID:   familyID,
+		UserAgent:  ctx.Request.UserAgent(),
+		IP:         ctx.ClientIP(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  expiresAt,
+	}
+	return session, h.sessions.CreateSession(&session)
+}
+
+// LogoutUser godoc
+// @Summary      Logout
+// @Description  Revoke the bearer token of the request and sign out its session, revoking the refresh tokens of the same login. A refresh token given in the body is revoked along with every refresh token rotated from the same login, for tokens issued before sessions were recorded.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        logout body dto.Logout false "Refresh token to revoke along with the access token."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/logout [post]
+// @Security	 BearerAuth
+func (h *UserHandler) LogoutUser(ctx *gin.Context) {
+	var logoutDto dto.Logout
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&logoutDto); err != nil {
+			abortBadRequest(err, ctx)
+			return
+		}
+	}
+	principal := middlewares.CurrentPrincipal(ctx)
+	var err error
+	if principal.TokenID != "" {
+		err = h.revocations.RevokeToken(principal.TokenID, principal.ExpiresAt)
+	} else {
+		// Tokens issued before jti existed can only be revoked all at once.
+		err = h.revocations.RevokeUserTokens(principal.UserID, time.Now())
+	}
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if principal.SessionID != 0 {
+		session, err := h.sessions.RevokeSession(principal.UserID, principal.SessionID)
+		if err == nil {
+			err = h.refreshTokens.RevokeRefreshTokensOfFamily(session.FamilyID)
+		}
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if logoutDto.RefreshToken != "" {
+		familyID, err := h.refreshTokens.RevokeRefreshTokenFamily(token.HashRefreshToken(logoutDto.RefreshToken))
+		if err == nil {
+			err = h.sessions.RevokeSessionByFamily(familyID)
+		}
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "You have been logged out.",
+	})
+}
+
+// revokeAllTokens revokes every access and refresh token of userID issued so
+// far, signing out all their sessions.
+func (h *UserHandler) revokeAllTokens(userID uint) error {
+	if err := h.revocations.RevokeUserTokens(userID, time.Now()); err != nil {
+		return err
+	}
+	if err := h.sessions.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+	return h.refreshTokens.RevokeUserRefreshTokens(userID)
+}
+
+// abortSuspended aborts with 403 and returns true when user is suspended.
+func abortSuspended(ctx *gin.Context, user models.User) bool {
+	if user.SuspendedAt == nil {
+		return false
+	}
+	ctx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorMessage{
+		ErrorMessage: "Your account has been suspended.",
+	})
+	return true
+}
+
+func respondWithTokens(ctx *gin.Context, user models.User, sessionID uint, refreshToken string) {
+	jwt, err := token.GenerateToken(user.ID, []string{user.Role}, sessionID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 	ctx.JSON(http.StatusOK, responses.UserLogin{
-		Token: jwt,
+		Token:        jwt,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(token.TTL().Seconds()),
+	})
+}
+
+// GetUser godoc
+// @Summary      Get the profile of a user
+// @Description  Get the public profile of a user. Their email and age are left out.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param		 userId path uint true "ID number of the user"
+// @Success      200  {object}  responses.UserProfile
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/{userId} [get]
+// @Security	 BearerAuth
+func (h *UserHandler) GetUser(ctx *gin.Context) {
+	parsedID, err := strconv.ParseUint(ctx.Param("userId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	user, err := h.users.GetUserWithoutPreload(uint(parsedID))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("User with ID %d is not found.", parsedID),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.UserProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
 	})
 }
 
 // UpdateUser godoc
 // @Summary      Update logged in user
-// @Description  update logged in user identified by their bearer token.
+// @Description  update logged in user identified by their bearer token. Changing the email marks it unverified and sends a verification link to the new address.
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -106,7 +487,7 @@ func LoginUser(ctx *gin.Context) {
 // @Failure      500  {object}  nil
 // @Router       /users [put]
 // @Security	 BearerAuth
-func UpdateUser(ctx *gin.Context) {
+func (h *UserHandler) UpdateUser(ctx *gin.Context) {
 	var userDto dto.UserUpdate
 	if err := ctx.ShouldBindJSON(&userDto); err != nil {
 		abortBadRequest(err, ctx)
@@ -116,24 +497,22 @@ func UpdateUser(ctx *gin.Context) {
 		validationAbort(err, ctx)
 		return
 	}
-	userID, err := token.ExtractTokenID(ctx)
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	user, err := h.users.UpdateUser(userID, &userDto)
 	if err != nil {
+		if errors.Is(err, database.ErrDuplicate) {
+			ctx.AbortWithStatusJSON(http.StatusOK, responses.Message{
+				Message: "The email or username is already registered.",
+			})
+			return
+		}
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-	user, err := database.UpdateUser(userID, &userDto)
-	if err != nil {
-		var perr *pgconn.PgError
-		if ok := errors.As(err, &perr); ok {
-			if perr.Code == uniqueViolationErr {
-				ctx.AbortWithStatusJSON(http.StatusOK, responses.Message{
-					Message: "The email or username is already registered.",
-				})
-				return
-			}
+	if user.EmailVerifiedAt == nil && user.VerificationSentAt == nil {
+		if _, err := h.sendVerification(user); err != nil {
+			ctx.Error(err)
 		}
-		ctx.AbortWithError(http.StatusInternalServerError, err)
-		return
 	}
 	ctx.JSON(http.StatusOK, responses.UserUpdate{
 		ID:        user.ID,
@@ -155,13 +534,13 @@ func UpdateUser(ctx *gin.Context) {
 // @Failure      500  {object}  nil
 // @Router       /users [delete]
 // @Security	 BearerAuth
-func DeleteUser(ctx *gin.Context) {
-	userID, err := token.ExtractTokenID(ctx)
-	if err != nil {
+func (h *UserHandler) DeleteUser(ctx *gin.Context) {
+	userID := middlewares.CurrentPrincipal(ctx).UserID
+	if err := h.revokeAllTokens(userID); err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-	if err := database.DeleteUserById(userID); err != nil {
+	if err := h.users.DeleteUserById(userID); err != nil {
 		ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -169,3 +548,134 @@ func DeleteUser(ctx *gin.Context) {
 		Message: "Your account has been successfully deleted",
 	})
 }
+
+// SuspendUser godoc
+// @Summary      Suspend a user
+// @Description  Suspend a user and revoke every token issued to them. Suspended users cannot login until the suspension is lifted. Moderators can only suspend users with a less privileged role.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param		 userId path uint true "ID number of the user to be suspended"
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /admin/users/{userId}/suspend [post]
+// @Security	 BearerAuth
+func (h *UserHandler) SuspendUser(ctx *gin.Context) {
+	h.setSuspended(ctx, true)
+}
+
+// UnsuspendUser godoc
+// @Summary      Lift the suspension of a user
+// @Description  Lift the suspension of a user so they can login again.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param		 userId path uint true "ID number of the suspended user"
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /admin/users/{userId}/suspend [delete]
+// @Security	 BearerAuth
+func (h *UserHandler) UnsuspendUser(ctx *gin.Context) {
+	h.setSuspended(ctx, false)
+}
+
+func (h *UserHandler) setSuspended(ctx *gin.Context, suspended bool) {
+	user, ok := h.authorizeOnUser(ctx, policy.Suspend)
+	if !ok {
+		return
+	}
+	var suspendedAt *time.Time
+	message := fmt.Sprintf("The suspension of user with ID %d has been lifted.", user.ID)
+	if suspended {
+		now := time.Now()
+		suspendedAt = &now
+		message = fmt.Sprintf("User with ID %d has been suspended.", user.ID)
+		if err := h.revokeAllTokens(user.ID); err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if _, err := h.users.SuspendUser(user.ID, suspendedAt); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: message,
+	})
+}
+
+// UpdateUserRole godoc
+// @Summary      Change the role of a user
+// @Description  Change the role of a user to user, moderator or admin. Every token issued to them is revoked so the new role applies from their next login. Admin only.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param		 userId path uint true "ID number of the user"
+// @Param        role body dto.UserRole true "The new role."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      403  {object}  responses.ErrorMessage
+// @Failure      404  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /admin/users/{userId}/role [put]
+// @Security	 BearerAuth
+func (h *UserHandler) UpdateUserRole(ctx *gin.Context) {
+	var roleDto dto.UserRole
+	if err := ctx.ShouldBindJSON(&roleDto); err != nil {
+		abortBadRequest(err, ctx)
+		return
+	}
+	if err := validate.Struct(&roleDto); err != nil {
+		validationAbort(err, ctx)
+		return
+	}
+	user, ok := h.authorizeOnUser(ctx, policy.ChangeRole)
+	if !ok {
+		return
+	}
+	if err := h.revokeAllTokens(user.ID); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if _, err := h.users.UpdateUserRole(user.ID, roleDto.Role); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: fmt.Sprintf("User with ID %d is now a %s.", user.ID, roleDto.Role),
+	})
+}
+
+// authorizeOnUser loads the user named by the userId path parameter and
+// checks the principal may do action on them, aborting the request if not.
+func (h *UserHandler) authorizeOnUser(ctx *gin.Context, action policy.Action) (models.User, bool) {
+	parsedID, err := strconv.ParseUint(ctx.Param("userId"), 10, 0)
+	if err != nil {
+		abortBadRequest(err, ctx)
+		return models.User{}, false
+	}
+	user, err := h.users.GetUserWithoutPreload(uint(parsedID))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorMessage{
+				ErrorMessage: fmt.Sprintf("User with ID %d is not found.", parsedID),
+			})
+			return models.User{}, false
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return models.User{}, false
+	}
+	if err := policy.Authorize(middlewares.CurrentPrincipal(ctx), action, policy.User(user)); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorMessage{
+			ErrorMessage: err.Error(),
+		})
+		return models.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+)
+
+// login logs name in with the password s.user registered them with and
+// returns their access and refresh tokens.
+func (s *server) login(name string) (string, string) {
+	s.t.Helper()
+	rec := s.do("POST", "/users/login", "", map[string]string{"email": name + "@example.com", "password": "secret1"})
+	expectStatus(s.t, rec, http.StatusOK)
+	return tokens(s.t, rec)
+}
+
+func (s *server) refresh(refreshToken string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.do("POST", "/users/refresh", "", map[string]string{"refresh_token": refreshToken})
+}
+
+func tokens(t *testing.T, rec *httptest.ResponseRecorder) (string, string) {
+	t.Helper()
+	var body struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	decode(t, rec, &body)
+	if body.Token == "" || body.RefreshToken == "" {
+		t.Fatalf("got no tokens in %s", rec.Body)
+	}
+	return body.Token, body.RefreshToken
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newServer(t)
+	s.user("alice", models.RoleUser)
+	_, first := s.login("alice")
+
+	rec := s.refresh(first)
+	expectStatus(t, rec, http.StatusOK)
+	accessToken, second := tokens(t, rec)
+	if second == first {
+		t.Fatal("the refresh token was not rotated")
+	}
+	expectStatus(t, s.do("GET", "/users/sessions", accessToken, nil), http.StatusOK)
+	expectError(t, s.refresh("unknown"), http.StatusUnauthorized, "The refresh token is invalid.")
+}
+
+// TestRefreshTokenReuse checks that reusing a rotated refresh token signs
+// out the whole login: its newest refresh token and access token included.
+func TestRefreshTokenReuse(t *testing.T) {
+	s := newServer(t)
+	s.user("alice", models.RoleUser)
+	_, first := s.login("alice")
+	_, otherLogin := s.login("alice")
+	rec := s.refresh(first)
+	expectStatus(t, rec, http.StatusOK)
+	accessToken, second := tokens(t, rec)
+
+	expectError(t, s.refresh(first), http.StatusUnauthorized, database.ErrRefreshTokenReused.Error())
+	expectError(t, s.refresh(second), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+	expectError(t, s.do("GET", "/users/sessions", accessToken, nil), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+	// The other login of the user goes on.
+	expectStatus(t, s.refresh(otherLogin), http.StatusOK)
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	s := newServer(t, "-jwt-refresh-ttl", "50ms")
+	s.user("alice", models.RoleUser)
+	_, refreshToken := s.login("alice")
+	time.Sleep(100 * time.Millisecond)
+	expectError(t, s.refresh(refreshToken), http.StatusUnauthorized, database.ErrRefreshTokenExpired.Error())
+}
+
+// TestLogout checks logging out revokes the access token and the refresh
+// tokens of the login, and nothing else.
+func TestLogout(t *testing.T) {
+	s := newServer(t)
+	s.user("alice", models.RoleUser)
+	accessToken, refreshToken := s.login("alice")
+	otherAccessToken, otherRefreshToken := s.login("alice")
+
+	expectStatus(t, s.do("POST", "/users/logout", accessToken, nil), http.StatusOK)
+	expectError(t, s.do("GET", "/users/sessions", accessToken, nil), http.StatusUnauthorized, middlewares.ErrTokenRevoked.Error())
+	expectError(t, s.refresh(refreshToken), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+	expectStatus(t, s.do("GET", "/users/sessions", otherAccessToken, nil), http.StatusOK)
+	expectStatus(t, s.refresh(otherRefreshToken), http.StatusOK)
+}
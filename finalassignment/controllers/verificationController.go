@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"finalassignment.id/finalassignment/controllers/responses"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/mailer"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail godoc
+// @Summary      Verify an email
+// @Description  Mark the email of a user verified. This is the link sent by email after registering or changing the email.
+// @Tags         users
+// @Produce      json
+// @Param        token query string true "Token from the verification link."
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/verify [get]
+func (h *UserHandler) VerifyEmail(ctx *gin.Context) {
+	userID, email, err := token.ParseEmailVerificationToken(ctx.Query("token"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	user, err := h.users.GetUserWithoutPreload(userID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+				ErrorMessage: token.ErrInvalidVerification.Error(),
+			})
+			return
+		}
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if user.Email != email {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "The email verification link was sent to an email the account no longer uses.",
+		})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		ctx.JSON(http.StatusOK, responses.Message{
+			Message: "Your email is already verified.",
+		})
+		return
+	}
+	if err := h.users.VerifyEmail(user.ID, time.Now()); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: "Your email has been verified.",
+	})
+}
+
+// ResendVerification godoc
+// @Summary      Resend the verification email
+// @Description  Send the email verification link of the logged in user again. It can only be asked for once per resend interval.
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  responses.Message
+// @Failure      400  {object}  responses.ErrorMessage
+// @Failure      429  {object}  responses.ErrorMessage
+// @Failure      500  {object}  nil
+// @Router       /users/verify/resend [post]
+// @Security	 BearerAuth
+func (h *UserHandler) ResendVerification(ctx *gin.Context) {
+	user, err := h.users.GetUserWithoutPreload(middlewares.CurrentPrincipal(ctx).UserID)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorMessage{
+			ErrorMessage: "Your email is already verified.",
+		})
+		return
+	}
+	retryAfter, err := h.sendVerification(user)
+	if err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if retryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, responses.ErrorMessage{
+			ErrorMessage: "A verification email was sent recently, please wait before asking for another.",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, responses.Message{
+		Message: fmt.Sprintf("A verification email has been sent to %s.", user.Email),
+	})
+}
+
+// sendVerification emails user a link to verify their email. When the last
+// one was sent less than the resend interval ago it sends nothing and
+// returns how long to wait instead.
+func (h *UserHandler) sendVerification(user models.User) (retryAfter time.Duration, err error) {
+	now := time.Now()
+	reserved, err := h.users.ReserveVerificationEmail(user.ID, now, now.Add(-h.verification.ResendInterval))
+	if err != nil {
+		return 0, err
+	}
+	if !reserved {
+		retryAfter = h.verification.ResendInterval
+		if user.VerificationSentAt != nil && user.VerificationSentAt.Add(retryAfter).After(now) {
+			retryAfter = user.VerificationSentAt.Add(retryAfter).Sub(now)
+		}
+		return retryAfter, nil
+	}
+	verificationToken, err := token.GenerateEmailVerificationToken(user.ID, user.Email, h.verification.TTL)
+	if err != nil {
+		return 0, err
+	}
+	link := h.publicURL + "/users/verify?token=" + url.QueryEscape(verificationToken)
+	return 0, h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this is your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you didn't create an account, ignore this email.\n",
+			user.Username, link, h.verification.TTL),
+	})
+}
@@ -0,0 +1,75 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+)
+
+// unverifiedUser registers a user named name without verifying their email
+// and returns their access token.
+func (s *server) unverifiedUser(name string) string {
+	s.t.Helper()
+	s.mail.Reset()
+	rec := s.do("POST", "/users/register", "", map[string]interface{}{
+		"username": name, "email": name + "@example.com", "password": "secret1", "age": 20,
+	})
+	expectStatus(s.t, rec, http.StatusCreated)
+	accessToken, _ := s.login(name)
+	return accessToken
+}
+
+// verificationPath returns the path of the verification link sent last.
+func (s *server) verificationPath() string {
+	s.t.Helper()
+	mail := s.mail.String()
+	i := strings.LastIndex(mail, "/users/verify?token=")
+	if i < 0 {
+		s.t.Fatalf("no verification link in %q", mail)
+	}
+	path, _, _ := strings.Cut(mail[i:], "\r\n")
+	return path
+}
+
+// TestUnverifiedUser checks users can't post anything until they verify
+// their email, and can do the rest.
+func TestUnverifiedUser(t *testing.T) {
+	s := newServer(t)
+	verified := s.user("alice", models.RoleUser)
+	photoID := s.create("/photos/", verified, newPhoto("first"))
+	accessToken := s.unverifiedUser("bob")
+	link := s.verificationPath()
+
+	posts := []struct {
+		path string
+		body interface{}
+	}{
+		{"/photos/", newPhoto("mine")},
+		{"/photos/uploads", map[string]interface{}{"filename": "photo.jpg", "size": 10, "title": "mine"}},
+		{"/comments/", map[string]interface{}{"message": "nice", "photo_id": photoID}},
+		{fmt.Sprint("/photos/", photoID, "/comments"), map[string]string{"message": "nice"}},
+		{"/albums/", map[string]string{"title": "mine"}},
+	}
+	for _, post := range posts {
+		expectError(t, s.do("POST", post.path, accessToken, post.body), http.StatusForbidden, middlewares.ErrUnverified.Error())
+	}
+	expectStatus(t, s.do("GET", "/photos/", accessToken, nil), http.StatusOK)
+	expectStatus(t, s.do("GET", fmt.Sprint("/photos/", photoID), accessToken, nil), http.StatusOK)
+	expectStatus(t, s.do("POST", "/socialmedias/", accessToken, map[string]string{"name": "bob", "social_media_url": "https://example.com/bob"}), http.StatusCreated)
+
+	// The link verifies the email, and the same access token works.
+	expectStatus(t, s.do("GET", link, "", nil), http.StatusOK)
+	s.create("/photos/", accessToken, newPhoto("mine"))
+	s.create(fmt.Sprint("/photos/", photoID, "/comments"), accessToken, map[string]string{"message": "nice"})
+}
+
+func TestUnverifiedUserNotRequired(t *testing.T) {
+	s := newServer(t, "-verification-required", "false")
+	accessToken := s.unverifiedUser("bob")
+	photoID := s.create("/photos/", accessToken, newPhoto("mine"))
+	s.create(fmt.Sprint("/photos/", photoID, "/comments"), accessToken, map[string]string{"message": "nice"})
+}
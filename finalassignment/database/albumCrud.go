@@ -0,0 +1,227 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+var ErrNotInAlbum = errors.New("Only photos in the album can be moved.")
+
+type albumRepository struct {
+	db *gorm.DB
+}
+
+func (r *albumRepository) CreateAlbum(userID uint, albumDto *dto.Album) (models.Album, error) {
+	newAlbum := models.Album{
+		Title:        albumDto.Title,
+		Description:  albumDto.Description,
+		CoverPhotoID: albumDto.CoverPhotoID,
+		Visibility:   albumDto.Visibility,
+		UserID:       userID,
+		Model: models.Model{
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	if newAlbum.Visibility == "" {
+		newAlbum.Visibility = models.AlbumPublic
+	}
+	if err := r.db.Create(&newAlbum).Error; err != nil {
+		return models.Album{}, err
+	}
+	return newAlbum, nil
+}
+func (r *albumRepository) GetAllAlbums(viewerID uint, options ListOptions) ([]models.AlbumWithUser, *Cursor, error) {
+	albums := []models.AlbumWithUser{}
+	query := r.db.Model(&models.AlbumWithUser{}).Where("(visibility = ? OR user_id = ?)", models.AlbumPublic, viewerID)
+	if err := preloadCoverPhoto(preloadUser(options.apply(query))).Find(&albums).Error; err != nil {
+		return nil, nil, err
+	}
+	albums, next := NextPage(albums, options, func(album models.AlbumWithUser) models.Model { return album.Model })
+	return albums, next, nil
+}
+func (r *albumRepository) GetSingleAlbum(albumID uint) (models.Album, error) {
+	album := models.Album{}
+	err := r.db.Model(&models.Album{}).Take(&album, albumID).Error
+	return album, err
+}
+func (r *albumRepository) GetAlbumWithUser(albumID uint) (models.AlbumWithUser, error) {
+	album := models.AlbumWithUser{}
+	err := preloadCoverPhoto(preloadUser(r.db.Model(&models.AlbumWithUser{}))).Take(&album, albumID).Error
+	return album, err
+}
+func (r *albumRepository) GetAlbumPhotos(albumID uint) ([]models.PhotoWithUser, error) {
+	photos := []models.PhotoWithUser{}
+	err := preloadVariants(preloadUser(r.db.Model(&models.PhotoWithUser{}))).Select("photos.*").
+		Joins("JOIN album_photos ON album_photos.photo_id = photos.id").
+		Where("album_photos.album_id = ?", albumID).
+		Order("album_photos.position").Order("album_photos.photo_id").Find(&photos).Error
+	return photos, err
+}
+func (r *albumRepository) UpdateAlbum(albumID uint, albumDto *dto.Album) (models.Album, error) {
+	album, err := r.GetSingleAlbum(albumID)
+	if err != nil {
+		return models.Album{}, err
+	}
+	album.Title = albumDto.Title
+	album.Description = albumDto.Description
+	album.CoverPhotoID = albumDto.CoverPhotoID
+	if albumDto.Visibility != "" {
+		album.Visibility = albumDto.Visibility
+	}
+	album.UpdatedAt = time.Now()
+	if err := r.db.Save(&album).Error; err != nil {
+		return models.Album{}, err
+	}
+	return album, nil
+}
+func (r *albumRepository) DeleteAlbum(albumID uint) error {
+	album, err := r.GetSingleAlbum(albumID)
+	if err != nil {
+		return err
+	}
+	return r.db.Delete(&album, albumID).Error
+}
+func (r *albumRepository) AddAlbumPhotos(albumID uint, photoIDs []uint) (ids []uint, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := touchAlbum(tx, albumID)
+		if err != nil {
+			return err
+		}
+		// Deleted photos leave gaps, which are closed first so the added
+		// photos can be numbered after the count.
+		ids = albumPhotoIDs(current)
+		if err := renumberAlbumPhotos(tx, albumID, current, ids); err != nil {
+			return err
+		}
+		in := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			in[id] = true
+		}
+		added := []models.AlbumPhoto{}
+		for _, photoID := range photoIDs {
+			if in[photoID] {
+				continue
+			}
+			in[photoID] = true
+			ids = append(ids, photoID)
+			added = append(added, models.AlbumPhoto{AlbumID: albumID, PhotoID: photoID, Position: len(ids), CreatedAt: time.Now()})
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		return tx.Create(&added).Error
+	})
+	return
+}
+func (r *albumRepository) RemoveAlbumPhotos(albumID uint, photoIDs []uint) (ids []uint, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := touchAlbum(tx, albumID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("album_id = ? AND photo_id IN ?", albumID, photoIDs).Delete(&models.AlbumPhoto{}).Error; err != nil {
+			return err
+		}
+		removed := make(map[uint]bool, len(photoIDs))
+		for _, photoID := range photoIDs {
+			removed[photoID] = true
+		}
+		ids = []uint{}
+		for _, id := range albumPhotoIDs(current) {
+			if !removed[id] {
+				ids = append(ids, id)
+			}
+		}
+		return renumberAlbumPhotos(tx, albumID, current, ids)
+	})
+	return
+}
+func (r *albumRepository) MoveAlbumPhotos(albumID uint, photoIDs []uint, position int) (ids []uint, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		current, err := touchAlbum(tx, albumID)
+		if err != nil {
+			return err
+		}
+		if ids, err = MoveAlbumPhotoIDs(albumPhotoIDs(current), photoIDs, position); err != nil {
+			return err
+		}
+		return renumberAlbumPhotos(tx, albumID, current, ids)
+	})
+	return
+}
+
+// touchAlbum bumps the updated_at of an album and returns its photos in
+// their order. Postgres keeps the row locked until the end of tx, so changes
+// to the photos of an album are made one at a time.
+func touchAlbum(tx *gorm.DB, albumID uint) ([]models.AlbumPhoto, error) {
+	result := tx.Model(&models.Album{}).Where("id = ?", albumID).UpdateColumn("updated_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	photos := []models.AlbumPhoto{}
+	err := tx.Where("album_id = ?", albumID).Order("position").Order("photo_id").Find(&photos).Error
+	return photos, err
+}
+
+func albumPhotoIDs(photos []models.AlbumPhoto) []uint {
+	ids := make([]uint, len(photos))
+	for i, photo := range photos {
+		ids[i] = photo.PhotoID
+	}
+	return ids
+}
+
+// renumberAlbumPhotos numbers ids, the photos of an album in their new
+// order, from 1. Only the positions that differ from the ones in before are
+// saved.
+func renumberAlbumPhotos(tx *gorm.DB, albumID uint, before []models.AlbumPhoto, ids []uint) error {
+	positions := make(map[uint]int, len(before))
+	for _, photo := range before {
+		positions[photo.PhotoID] = photo.Position
+	}
+	for i, id := range ids {
+		if positions[id] == i+1 {
+			continue
+		}
+		err := tx.Model(&models.AlbumPhoto{}).Where("album_id = ? AND photo_id = ?", albumID, id).UpdateColumn("position", i+1).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveAlbumPhotoIDs returns ids with photoIDs taken out and put back in
+// their order at position, counting from 1 and capped to the end. It
+// returns ErrNotInAlbum when one of photoIDs is not in ids.
+func MoveAlbumPhotoIDs(ids, photoIDs []uint, position int) ([]uint, error) {
+	moved := make(map[uint]bool, len(photoIDs))
+	for _, photoID := range photoIDs {
+		moved[photoID] = true
+	}
+	rest := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !moved[id] {
+			rest = append(rest, id)
+		}
+	}
+	if len(rest)+len(photoIDs) != len(ids) {
+		return nil, ErrNotInAlbum
+	}
+	at := position - 1
+	if at > len(rest) {
+		at = len(rest)
+	}
+	result := make([]uint, 0, len(ids))
+	result = append(result, rest[:at]...)
+	result = append(result, photoIDs...)
+	return append(result, rest[at:]...), nil
+}
@@ -0,0 +1,91 @@
+package database_test
+
+import (
+	"reflect"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+// expectAlbumPhotos checks the album lists photoIDs numbered from 1.
+func expectAlbumPhotos(t *testing.T, db *gorm.DB, repos database.Repositories, albumID uint, photoIDs ...uint) {
+	t.Helper()
+	rows := []models.AlbumPhoto{}
+	if err := db.Where("album_id = ?", albumID).Order("position").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint
+	for i, row := range rows {
+		if row.Position != i+1 {
+			t.Errorf("photo %d is at position %d, want %d", row.PhotoID, row.Position, i+1)
+		}
+		ids = append(ids, row.PhotoID)
+	}
+	if !reflect.DeepEqual(ids, photoIDs) {
+		t.Errorf("the album holds photos %v, want %v", ids, photoIDs)
+	}
+	photos, err := repos.Albums.GetAlbumPhotos(albumID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids = nil
+	for _, photo := range photos {
+		ids = append(ids, photo.ID)
+		if photo.User.ID != photo.UserID || photo.User.Username == "" {
+			t.Errorf("photo %d is listed with user %+v", photo.ID, photo.User)
+		}
+	}
+	if !reflect.DeepEqual(ids, photoIDs) {
+		t.Errorf("the album lists photos %v, want %v", ids, photoIDs)
+	}
+}
+
+// TestAlbumPositionsAfterDeletedPhotos checks the gap a deleted photo
+// leaves in an album is closed rather than numbered over.
+func TestAlbumPositionsAfterDeletedPhotos(t *testing.T) {
+	db := openSQLite(t)
+	repos := database.NewRepositories(db)
+	user := models.User{Username: "owner", Email: "owner@example.com", Password: "x", Age: 20, Role: models.RoleUser}
+	if err := repos.Users.CreateUser(&user); err != nil {
+		t.Fatal(err)
+	}
+	var photoIDs []uint
+	for i := 0; i < 4; i++ {
+		id, err := repos.Photos.CreatePhoto(user.ID, &dto.Photo{Title: "title", PhotoUrl: "https://example.com/a.jpg"}, models.PhotoFile{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		photoIDs = append(photoIDs, id)
+	}
+	p1, p2, p3, p4 := photoIDs[0], photoIDs[1], photoIDs[2], photoIDs[3]
+	album, err := repos.Albums.CreateAlbum(user.ID, &dto.Album{Title: "album"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Albums.AddAlbumPhotos(album.ID, []uint{p1, p2, p3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Photos.DeletePhoto(p2); err != nil {
+		t.Fatal(err)
+	}
+	// Moving a photo to where it is changes nothing but the gap.
+	if _, err := repos.Albums.MoveAlbumPhotos(album.ID, []uint{p1}, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectAlbumPhotos(t, db, repos, album.ID, p1, p3)
+
+	if err := repos.Photos.DeletePhoto(p1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Albums.AddAlbumPhotos(album.ID, []uint{p4}); err != nil {
+		t.Fatal(err)
+	}
+	expectAlbumPhotos(t, db, repos, album.ID, p3, p4)
+	if _, err := repos.Albums.MoveAlbumPhotos(album.ID, []uint{p4}, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectAlbumPhotos(t, db, repos, album.ID, p4, p3)
+}
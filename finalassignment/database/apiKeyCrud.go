@@ -0,0 +1,38 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func (r *apiKeyRepository) CreateAPIKey(apiKey *models.APIKey) error {
+	apiKey.CreatedAt = time.Now()
+	apiKey.UpdatedAt = time.Now()
+	return r.db.Create(apiKey).Error
+}
+func (r *apiKeyRepository) GetUserAPIKeys(userID uint) ([]models.APIKey, error) {
+	apiKeys := []models.APIKey{}
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&apiKeys).Error
+	return apiKeys, err
+}
+func (r *apiKeyRepository) GetAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	apiKey := models.APIKey{}
+	err := r.db.Where("key_hash = ?", keyHash).Take(&apiKey).Error
+	return apiKey, err
+}
+func (r *apiKeyRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+func (r *apiKeyRepository) DeleteUserAPIKey(userID, id uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIKey{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return result.Error
+}
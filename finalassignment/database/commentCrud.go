@@ -5,55 +5,87 @@ import (
 
 	"finalassignment.id/finalassignment/dto"
 	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
 )
 
-func GetAllComments() ([]models.Comment, error) {
-	comments := make([]models.Comment, 1)
-	if err := db.Model(&models.Comment{}).Find(&comments).Error; err != nil {
-		return nil, err
-	}
-	return comments, nil
+type commentRepository struct {
+	db *gorm.DB
 }
-func DeleteComment(commentID, userID uint) error {
-	comment, err := GetSingleComment(commentID)
-	if err != nil {
-		return err
+
+func (r *commentRepository) GetAllComments(options ListOptions) ([]models.CommentWithUserAndPhoto, *Cursor, error) {
+	comments := []models.CommentWithUserAndPhoto{}
+	query := r.db.Model(&models.CommentWithUserAndPhoto{})
+	if options.PhotoID != 0 {
+		query = query.Where("photo_id = ?", options.PhotoID)
 	}
-	if comment.UserID != userID {
-		return ErrIllegalUpdate
+	if options.ParentID != 0 {
+		query = query.Where("parent_id = ?", options.ParentID)
 	}
-	if err := db.Delete(&comment, commentID).Error; err != nil {
-		return err
+	if options.TopLevel {
+		query = query.Where("parent_id IS NULL")
 	}
-	return nil
+	if err := preloadUser(options.apply(query)).Preload("Photo").Find(&comments).Error; err != nil {
+		return nil, nil, err
+	}
+	comments, next := NextPage(comments, options, func(comment models.CommentWithUserAndPhoto) models.Model { return comment.Model })
+	return comments, next, nil
+}
+func (r *commentRepository) DeleteComment(commentID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		comment := models.Comment{}
+		if err := tx.Take(&comment, commentID).Error; err != nil {
+			return err
+		}
+		if comment.ReplyCount > 0 {
+			return tx.Model(&comment).Updates(map[string]interface{}{
+				"message":    "",
+				"user_id":    nil,
+				"deleted_at": time.Now(),
+			}).Error
+		}
+		for {
+			if err := tx.Delete(&comment).Error; err != nil {
+				return err
+			}
+			if comment.ParentID == nil {
+				return nil
+			}
+			parent := models.Comment{}
+			if err := tx.Take(&parent, *comment.ParentID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&parent).UpdateColumn("reply_count", gorm.Expr("reply_count - 1")).Error; err != nil {
+				return err
+			}
+			// A tombstone goes away with its last reply.
+			if parent.DeletedAt == nil || parent.ReplyCount > 1 {
+				return nil
+			}
+			comment = parent
+		}
+	})
 }
-func GetSingleComment(commentID uint) (models.Comment, error) {
+func (r *commentRepository) GetSingleComment(commentID uint) (models.Comment, error) {
 	comment := models.Comment{}
-	if db == nil {
-		return comment, ErrDbNotStarted
-	}
-	err := db.Model(&models.Comment{}).Take(&comment, commentID).Error
+	err := r.db.Model(&models.Comment{}).Take(&comment, commentID).Error
 	return comment, err
 }
-func UpdateComment(commentID, userID uint, messageDto *dto.CommentMessage) (comment models.Comment, err error) {
-	comment, err = GetSingleComment(commentID)
+func (r *commentRepository) GetCommentWithUserAndPhoto(commentID uint) (models.CommentWithUserAndPhoto, error) {
+	comment := models.CommentWithUserAndPhoto{}
+	err := preloadUser(r.db.Model(&models.CommentWithUserAndPhoto{})).Preload("Photo").Take(&comment, commentID).Error
+	return comment, err
+}
+func (r *commentRepository) UpdateComment(commentID uint, messageDto *dto.CommentMessage) (comment models.Comment, err error) {
+	comment, err = r.GetSingleComment(commentID)
 	if err != nil {
 		return
 	}
-	if comment.UserID != userID {
-		comment = models.Comment{}
-		err = ErrIllegalUpdate
-		return
-	}
 	comment.Message = messageDto.Message
 	comment.UpdatedAt = time.Now()
-	err = db.Save(&comment).Error
+	err = r.db.Save(&comment).Error
 	return
 }
-func CreateComment(userID uint, commentDto *dto.Comment) (models.Comment, error) {
-	if db == nil {
-		return models.Comment{}, ErrDbNotStarted
-	}
+func (r *commentRepository) CreateComment(userID uint, commentDto *dto.Comment) (models.Comment, error) {
 	newComment := models.Comment{
 		UserID:  userID,
 		PhotoID: commentDto.PhotoID,
@@ -63,7 +95,21 @@ func CreateComment(userID uint, commentDto *dto.Comment) (models.Comment, error)
 			UpdatedAt: time.Now(),
 		},
 	}
-	if err := db.Create(&newComment).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if commentDto.ParentID != 0 {
+			parent := models.Comment{}
+			if err := tx.Take(&parent, commentDto.ParentID).Error; err != nil {
+				return err
+			}
+			newComment.ParentID = &parent.ID
+			newComment.Depth = parent.Depth + 1
+			if err := tx.Model(&parent).UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&newComment).Error
+	})
+	if err != nil {
 		return models.Comment{}, err
 	}
 	return newComment, nil
@@ -0,0 +1,59 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+// TestDeleteCommentTombstone checks deleting a comment with replies leaves
+// a tombstone, which is deleted along with its last reply, up the thread.
+func TestDeleteCommentTombstone(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		user := createUser(t, repos, "alice")
+		photoID, err := repos.Photos.CreatePhoto(user.ID, &dto.Photo{Title: "title", PhotoUrl: "https://example.com/a.jpg"}, models.PhotoFile{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		create := func(message string, parentID uint) models.Comment {
+			t.Helper()
+			comment, err := repos.Comments.CreateComment(user.ID, &dto.Comment{Message: message, PhotoID: photoID, ParentID: parentID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return comment
+		}
+		top := create("top", 0)
+		middle := create("middle", top.ID)
+		bottom := create("bottom", middle.ID)
+		if middle.Depth != 1 || bottom.Depth != 2 {
+			t.Errorf("got depths %d and %d", middle.Depth, bottom.Depth)
+		}
+
+		for _, comment := range []models.Comment{top, middle} {
+			if err := repos.Comments.DeleteComment(comment.ID); err != nil {
+				t.Fatal(err)
+			}
+			tombstone, err := repos.Comments.GetCommentWithUserAndPhoto(comment.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tombstone.DeletedAt == nil || tombstone.Message != "" || tombstone.UserID != 0 || tombstone.ReplyCount != 1 {
+				t.Errorf("got %+v, want a tombstone", tombstone.Comment)
+			}
+		}
+
+		// The last reply takes both tombstones above it along.
+		if err := repos.Comments.DeleteComment(bottom.ID); err != nil {
+			t.Fatal(err)
+		}
+		for _, comment := range []models.Comment{top, middle, bottom} {
+			if _, err := repos.Comments.GetSingleComment(comment.ID); !errors.Is(err, database.ErrNotFound) {
+				t.Errorf("comment %q gave %v, want ErrNotFound", comment.Message, err)
+			}
+		}
+	})
+}
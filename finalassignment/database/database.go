@@ -2,32 +2,48 @@ package database
 
 import (
 	"errors"
-	"fmt"
 
-	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/config"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var (
-	host             = "localhost"
-	dbUser           = "postgres"
-	dbPort           = "5432"
-	dbName           = "finalassignmentdb"
-	db               *gorm.DB
-	err              error
-	ErrDbNotStarted  error = errors.New("DB hasn't started yet.")
-	ErrIllegalUpdate       = errors.New("This resource is not yours.")
+	ErrIllegalUpdate = errors.New("This resource is not yours.")
+	ErrNotFound      = gorm.ErrRecordNotFound
+	ErrDuplicate     = errors.New("A record with the same unique value already exists.")
 )
 
-func StartDB() {
-	var password string
-	fmt.Println("Enter db password (not hidden, be careful of shoulder surfing)")
-	fmt.Scanln(&password)
-	config := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", host, dbUser, password, dbName, dbPort)
-	db, err = gorm.Open(postgres.Open(config), &gorm.Config{})
-	db.Debug().AutoMigrate(models.User{}, models.Photo{}, models.Comment{}, models.SocialMedia{})
+// OpenDB connects to the database described by cfg without touching its
+// schema.
+func OpenDB(cfg config.Database) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case "sqlite":
+		// The background workers write alongside requests, so connections
+		// wait for each other's transactions instead of failing with
+		// SQLITE_BUSY.
+		dialector = sqlite.Open(cfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	default:
+		dialector = postgres.Open(cfg.DSN())
+	}
+	return gorm.Open(dialector, &gorm.Config{})
 }
-func GetDB() *gorm.DB {
-	return db
+
+// StartDB connects to the database and refuses to continue when its schema
+// is missing migrations.
+func StartDB(cfg config.Database) (*gorm.DB, error) {
+	db, err := OpenDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := NewMigrator(db, cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.CheckSchema(); err != nil {
+		return nil, err
+	}
+	return db, nil
 }
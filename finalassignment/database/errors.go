@@ -0,0 +1,30 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+)
+
+const (
+	pgUniqueViolation          = "23505"
+	sqliteConstraintUnique     = 2067
+	sqliteConstraintPrimaryKey = 1555
+)
+
+// translateError maps driver specific errors to the errors of this package so
+// callers don't have to know which database is in use.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrDuplicate
+	}
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqliteConstraintUnique, sqliteConstraintPrimaryKey:
+			return ErrDuplicate
+		}
+	}
+	return err
+}
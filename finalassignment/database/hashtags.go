@@ -0,0 +1,65 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTagLength is the most characters a tag can have, longer hashtags are
+// not taken as tags.
+const MaxTagLength = 64
+
+// ParseHashtags returns the tags of the hashtags in caption, normalized by
+// NormalizeTag, in the order they first appear. A hashtag is a # at the start
+// of a word followed by letters, digits and underscores, at least one of
+// which is a letter, so "#1" and the "#x" of "a#x" are not hashtags.
+func ParseHashtags(caption string) []string {
+	tags := []string{}
+	seen := make(map[string]bool)
+	runes := []rune(caption)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && isTagRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+		if tag := NormalizeTag(string(runes[i+1 : end])); IsTag(tag) && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		i = end - 1
+	}
+	return tags
+}
+
+// NormalizeTag returns name without a leading #, in lower case and composed
+// Unicode so "#Café" typed either way is the same tag.
+func NormalizeTag(name string) string {
+	return norm.NFC.String(strings.ToLower(strings.TrimPrefix(name, "#")))
+}
+
+// IsTag reports whether a name returned by NormalizeTag is a tag.
+func IsTag(name string) bool {
+	if name == "" || utf8.RuneCountInString(name) > MaxTagLength {
+		return false
+	}
+	letter := false
+	for _, r := range name {
+		if !isTagRune(r) {
+			return false
+		}
+		letter = letter || unicode.IsLetter(r)
+	}
+	return letter
+}
+
+// isTagRune reports whether r can be part of a hashtag. Combining marks are,
+// for the accents of decomposed letters.
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func (r *identityRepository) CreateIdentity(identity *models.UserIdentity) error {
+	identity.CreatedAt = time.Now()
+	identity.UpdatedAt = time.Now()
+	return translateError(r.db.Create(identity).Error)
+}
+func (r *identityRepository) GetIdentity(issuer, subject string) (models.UserIdentity, error) {
+	identity := models.UserIdentity{}
+	err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).Take(&identity).Error
+	return identity, err
+}
+func (r *identityRepository) GetUserIdentities(userID uint) ([]models.UserIdentity, error) {
+	identities := []models.UserIdentity{}
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+func (r *identityRepository) DeleteUserIdentity(userID, id uint) error {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserIdentity{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return result.Error
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+// Sort columns of ListOptions.
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+)
+
+// ListOptions selects a page of photos, comments or social medias.
+type ListOptions struct {
+	// Limit is the size of the page.
+	Limit int
+	// SortBy is SortCreatedAt or SortUpdatedAt, ascending unless Desc.
+	SortBy string
+	Desc   bool
+	// After is where the previous page ended, nil for the first page.
+	After *Cursor
+	// UserID, PhotoID, ParentID, Tag, CreatedAfter and CreatedBefore filter
+	// rows when set. PhotoID, ParentID and TopLevel only apply to comments;
+	// TopLevel leaves out replies. Tag only applies to photos and is a name
+	// returned by NormalizeTag.
+	UserID        uint
+	PhotoID       uint
+	ParentID      uint
+	TopLevel      bool
+	Tag           string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// Cursor is the position of a row in a sorted list, the value of its sort
+// column and its ID to break ties.
+type Cursor struct {
+	Time time.Time
+	ID   uint
+}
+
+// SortTime returns the value of the sort column of row.
+func (options ListOptions) SortTime(row models.Model) time.Time {
+	if options.SortBy == SortUpdatedAt {
+		return row.UpdatedAt
+	}
+	return row.CreatedAt
+}
+
+// NextPage cuts rows, which were fetched with one row more than the limit,
+// down to the page and returns where the next page starts, nil on the last
+// page.
+func NextPage[T any](rows []T, options ListOptions, model func(T) models.Model) ([]T, *Cursor) {
+	if len(rows) <= options.Limit {
+		return rows, nil
+	}
+	rows = rows[:options.Limit]
+	last := model(rows[len(rows)-1])
+	return rows, &Cursor{Time: options.SortTime(last), ID: last.ID}
+}
+
+// apply filters, sorts and limits query to the page of options, fetching one
+// row more than the limit for NextPage.
+func (options ListOptions) apply(query *gorm.DB) *gorm.DB {
+	column, direction, beyond := SortCreatedAt, "ASC", ">"
+	if options.SortBy == SortUpdatedAt {
+		column = SortUpdatedAt
+	}
+	if options.Desc {
+		direction, beyond = "DESC", "<"
+	}
+	if options.UserID != 0 {
+		query = query.Where("user_id = ?", options.UserID)
+	}
+	// SQLite compares timestamps as text, so bounds, the cursor's included,
+	// have to be in the zone the rows were written in.
+	if !options.CreatedAfter.IsZero() {
+		query = query.Where("created_at > ?", options.CreatedAfter.Local())
+	}
+	if !options.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", options.CreatedBefore.Local())
+	}
+	if options.After != nil {
+		after := options.After.Time.Local()
+		query = query.Where(
+			"("+column+" "+beyond+" ?) OR ("+column+" = ? AND id "+beyond+" ?)",
+			after, after, options.After.ID,
+		)
+	}
+	return query.Order(column + " " + direction).Order("id " + direction).Limit(options.Limit + 1)
+}
+
+// preloadUser loads the user of the rows of query in a single query, with
+// only the fields photos, comments and social medias are shown with.
+func preloadUser(query *gorm.DB) *gorm.DB {
+	return query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "username", "email")
+	})
+}
+
+// preloadVariants loads the variants of the photos of query in a single
+// query.
+func preloadVariants(query *gorm.DB) *gorm.DB {
+	return query.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+// preloadCoverPhoto loads the cover photos of the albums of query in a
+// single query.
+func preloadCoverPhoto(query *gorm.DB) *gorm.DB {
+	return query.Preload("CoverPhoto")
+}
@@ -0,0 +1,147 @@
+package database_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite migrates a fresh SQLite database.
+func openSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+	cfg := config.Database{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "test.db")}
+	db, err := database.OpenDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	migrator, err := database.NewMigrator(db, cfg.Driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// countQueries counts the statements run on db in the returned int.
+func countQueries(t *testing.T, db *gorm.DB) *int {
+	t.Helper()
+	queries := new(int)
+	count := func(*gorm.DB) { *queries++ }
+	if err := db.Callback().Query().After("gorm:query").Register("test:count", count); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:count", count); err != nil {
+		t.Fatal(err)
+	}
+	return queries
+}
+
+// addRows creates the users numbered from to to-1, with a photo, a comment
+// and a social media each.
+func addRows(t testing.TB, repos database.Repositories, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		user := models.User{Username: fmt.Sprint("user", i), Email: fmt.Sprint("user", i, "@example.com"), Password: "x", Age: 20, Role: models.RoleUser}
+		if err := repos.Users.CreateUser(&user); err != nil {
+			t.Fatal(err)
+		}
+		photoID, err := repos.Photos.CreatePhoto(user.ID, &dto.Photo{Title: "title", PhotoUrl: "https://example.com/a.jpg"}, models.PhotoFile{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.Comments.CreateComment(user.ID, &dto.Comment{PhotoID: photoID, Message: "message"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repos.SocialMedias.CreateSocialMedia(user.ID, &dto.SocialMedia{Name: "name", SocialMediaUrl: "https://example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestListQueries checks the lists load the users, and photos, of their rows
+// in a fixed number of statements rather than one per row.
+func TestListQueries(t *testing.T) {
+	lists := map[string]func(database.Repositories, database.ListOptions) (int, error){
+		"photos": func(repos database.Repositories, options database.ListOptions) (int, error) {
+			rows, _, err := repos.Photos.GetAllPhotos(options)
+			return len(rows), err
+		},
+		"comments": func(repos database.Repositories, options database.ListOptions) (int, error) {
+			rows, _, err := repos.Comments.GetAllComments(options)
+			return len(rows), err
+		},
+		"social medias": func(repos database.Repositories, options database.ListOptions) (int, error) {
+			rows, _, err := repos.SocialMedias.GetAllSocialMedias(options)
+			return len(rows), err
+		},
+	}
+	for name, list := range lists {
+		t.Run(name, func(t *testing.T) {
+			db := openSQLite(t)
+			repos, queries := database.NewRepositories(db), countQueries(t, db)
+			options := database.ListOptions{Limit: 100, SortBy: database.SortCreatedAt}
+			count := func(want int) int {
+				t.Helper()
+				*queries = 0
+				n, err := list(repos, options)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != want {
+					t.Fatalf("listed %d rows, want %d", n, want)
+				}
+				return *queries
+			}
+			addRows(t, repos, 0, 1)
+			one := count(1)
+			if one == 0 {
+				t.Fatal("no queries were counted")
+			}
+			addRows(t, repos, 1, 100)
+			if hundred := count(100); hundred != one {
+				t.Errorf("listing 1 row took %d queries, 100 rows took %d", one, hundred)
+			}
+		})
+	}
+}
+
+// BenchmarkListPhotos compares listing 100 photos with their users preloaded
+// against looking up the user of every photo on its own, as the lists did
+// before.
+func BenchmarkListPhotos(b *testing.B) {
+	db := openSQLite(b)
+	repos := database.NewRepositories(db)
+	addRows(b, repos, 0, 100)
+	b.Run("preload", func(b *testing.B) {
+		options := database.ListOptions{Limit: 100, SortBy: database.SortCreatedAt}
+		for i := 0; i < b.N; i++ {
+			if _, _, err := repos.Photos.GetAllPhotos(options); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("n+1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			photos := []models.Photo{}
+			if err := db.Order("created_at").Order("id").Limit(100).Find(&photos).Error; err != nil {
+				b.Fatal(err)
+			}
+			for _, photo := range photos {
+				user := models.User{}
+				if err := db.Select("id", "username", "email").Take(&user, photo.UserID).Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginAttemptStore struct {
+	db *gorm.DB
+}
+
+func (r *loginAttemptStore) GetLoginAttempt(key string) (models.LoginAttempt, error) {
+	attempt := models.LoginAttempt{}
+	err := r.db.Where(&models.LoginAttempt{Key: key}).Take(&attempt).Error
+	return attempt, err
+}
+func (r *loginAttemptStore) RecordLoginFailure(key string, at, forgetBefore time.Time) (attempt models.LoginAttempt, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// Forgotten rows of every key are cleared out while we are here.
+		if err := tx.Where("last_failure_at < ?", forgetBefore).Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("login_attempts.failures + 1"),
+				"last_failure_at": at,
+			}),
+		}).Create(&models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: at}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where(&models.LoginAttempt{Key: key}).Take(&attempt).Error
+	})
+	return
+}
+func (r *loginAttemptStore) ClearLoginFailures(key string) error {
+	return r.db.Where(&models.LoginAttempt{Key: key}).Delete(&models.LoginAttempt{}).Error
+}
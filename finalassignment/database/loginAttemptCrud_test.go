@@ -0,0 +1,52 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		attempts := repos.LoginAttempts
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if _, err := attempts.GetLoginAttempt("account:alice"); !errors.Is(err, database.ErrNotFound) {
+			t.Fatalf("GetLoginAttempt of a key without failures gave %v", err)
+		}
+		for i := 1; i <= 3; i++ {
+			at := start.Add(time.Duration(i) * time.Minute)
+			attempt, err := attempts.RecordLoginFailure("account:alice", at, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if attempt.Failures != i || !attempt.LastFailureAt.Equal(at) {
+				t.Errorf("failure %d recorded %+v", i, attempt)
+			}
+		}
+		if _, err := attempts.RecordLoginFailure("ip:192.0.2.1", start.Add(3*time.Minute), start); err != nil {
+			t.Fatal(err)
+		}
+
+		// A failure after the others are forgotten starts counting again.
+		attempt, err := attempts.RecordLoginFailure("account:alice", start.Add(time.Hour), start.Add(30*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempt.Failures != 1 {
+			t.Errorf("got %d failures, want the forgotten ones not counted", attempt.Failures)
+		}
+		// Forgotten failures of other keys are cleared out too.
+		if _, err := attempts.GetLoginAttempt("ip:192.0.2.1"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("GetLoginAttempt of forgotten failures gave %v", err)
+		}
+
+		if err := attempts.ClearLoginFailures("account:alice"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := attempts.GetLoginAttempt("account:alice"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("GetLoginAttempt of cleared failures gave %v", err)
+		}
+	})
+}
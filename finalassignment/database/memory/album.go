@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+type albumRepository struct {
+	*store
+}
+
+func (r *albumRepository) CreateAlbum(userID uint, albumDto *dto.Album) (models.Album, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newAlbum := models.Album{
+		Title:        albumDto.Title,
+		Description:  albumDto.Description,
+		CoverPhotoID: albumDto.CoverPhotoID,
+		Visibility:   albumDto.Visibility,
+		UserID:       userID,
+		Model: models.Model{
+			ID:        r.nextID("albums"),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	if newAlbum.Visibility == "" {
+		newAlbum.Visibility = models.AlbumPublic
+	}
+	r.albums[newAlbum.ID] = newAlbum
+	return newAlbum, nil
+}
+func (r *albumRepository) GetAllAlbums(viewerID uint, options database.ListOptions) ([]models.AlbumWithUser, *database.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	albums, next := page(r.albums, options,
+		func(album models.Album) models.Model { return album.Model },
+		func(album models.Album) bool {
+			return (album.Visibility == models.AlbumPublic || album.UserID == viewerID) &&
+				(options.UserID == 0 || album.UserID == options.UserID)
+		},
+	)
+	views := make([]models.AlbumWithUser, len(albums))
+	for i, album := range albums {
+		views[i] = r.albumView(album)
+	}
+	return views, next, nil
+}
+func (r *albumRepository) GetSingleAlbum(albumID uint) (models.Album, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	album, ok := r.albums[albumID]
+	if !ok {
+		return models.Album{}, database.ErrNotFound
+	}
+	return album, nil
+}
+func (r *albumRepository) GetAlbumWithUser(albumID uint) (models.AlbumWithUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	album, ok := r.albums[albumID]
+	if !ok {
+		return models.AlbumWithUser{}, database.ErrNotFound
+	}
+	return r.albumView(album), nil
+}
+func (r *albumRepository) GetAlbumPhotos(albumID uint) ([]models.PhotoWithUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	photos := []models.PhotoWithUser{}
+	for _, albumPhoto := range r.albumPhotos[albumID] {
+		photo := r.photos[albumPhoto.PhotoID]
+		photos = append(photos, models.PhotoWithUser{Photo: photo, User: r.listedUser(photo.UserID), Variants: r.variantsOf(photo.ID)})
+	}
+	return photos, nil
+}
+func (r *albumRepository) UpdateAlbum(albumID uint, albumDto *dto.Album) (models.Album, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	album, ok := r.albums[albumID]
+	if !ok {
+		return models.Album{}, database.ErrNotFound
+	}
+	album.Title = albumDto.Title
+	album.Description = albumDto.Description
+	album.CoverPhotoID = albumDto.CoverPhotoID
+	if albumDto.Visibility != "" {
+		album.Visibility = albumDto.Visibility
+	}
+	album.UpdatedAt = time.Now()
+	r.albums[albumID] = album
+	return album, nil
+}
+func (r *albumRepository) DeleteAlbum(albumID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.albums[albumID]; !ok {
+		return database.ErrNotFound
+	}
+	delete(r.albums, albumID)
+	// ON DELETE CASCADE
+	delete(r.albumPhotos, albumID)
+	return nil
+}
+func (r *albumRepository) AddAlbumPhotos(albumID uint, photoIDs []uint) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.touchAlbum(albumID) {
+		return nil, database.ErrNotFound
+	}
+	ids := r.albumPhotoIDs(albumID)
+	in := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		in[id] = true
+	}
+	for _, photoID := range photoIDs {
+		if in[photoID] {
+			continue
+		}
+		if _, ok := r.photos[photoID]; !ok {
+			// The foreign key would fail in SQL.
+			return nil, database.ErrNotFound
+		}
+		in[photoID] = true
+		ids = append(ids, photoID)
+	}
+	r.setAlbumPhotos(albumID, ids)
+	return ids, nil
+}
+func (r *albumRepository) RemoveAlbumPhotos(albumID uint, photoIDs []uint) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.touchAlbum(albumID) {
+		return nil, database.ErrNotFound
+	}
+	r.removeAlbumPhotos(albumID, photoIDs)
+	return r.albumPhotoIDs(albumID), nil
+}
+func (r *albumRepository) MoveAlbumPhotos(albumID uint, photoIDs []uint, position int) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.albums[albumID]; !ok {
+		return nil, database.ErrNotFound
+	}
+	ids, err := database.MoveAlbumPhotoIDs(r.albumPhotoIDs(albumID), photoIDs, position)
+	if err != nil {
+		return nil, err
+	}
+	r.touchAlbum(albumID)
+	r.setAlbumPhotos(albumID, ids)
+	return ids, nil
+}
+
+// albumView must be called with mu held.
+func (s *store) albumView(album models.Album) models.AlbumWithUser {
+	view := models.AlbumWithUser{Album: album, User: s.listedUser(album.UserID)}
+	if album.CoverPhotoID != nil {
+		if cover, ok := s.photos[*album.CoverPhotoID]; ok {
+			view.CoverPhoto = &cover
+		}
+	}
+	return view
+}
+
+// touchAlbum bumps the UpdatedAt of an album and reports whether there is
+// one. It must be called with mu held for writing.
+func (s *store) touchAlbum(albumID uint) bool {
+	album, ok := s.albums[albumID]
+	if !ok {
+		return false
+	}
+	album.UpdatedAt = time.Now()
+	s.albums[albumID] = album
+	return true
+}
+
+// albumPhotoIDs must be called with mu held.
+func (s *store) albumPhotoIDs(albumID uint) []uint {
+	ids := []uint{}
+	for _, albumPhoto := range s.albumPhotos[albumID] {
+		ids = append(ids, albumPhoto.PhotoID)
+	}
+	return ids
+}
+
+// setAlbumPhotos numbers ids, the photos of an album in their order, from
+// 1. Photos in the album already keep when they were added. It must be
+// called with mu held for writing.
+func (s *store) setAlbumPhotos(albumID uint, ids []uint) {
+	addedAt := make(map[uint]time.Time)
+	for _, albumPhoto := range s.albumPhotos[albumID] {
+		addedAt[albumPhoto.PhotoID] = albumPhoto.CreatedAt
+	}
+	albumPhotos := make([]models.AlbumPhoto, len(ids))
+	for i, id := range ids {
+		createdAt, ok := addedAt[id]
+		if !ok {
+			createdAt = time.Now()
+		}
+		albumPhotos[i] = models.AlbumPhoto{AlbumID: albumID, PhotoID: id, Position: i + 1, CreatedAt: createdAt}
+	}
+	s.albumPhotos[albumID] = albumPhotos
+}
+
+// removeAlbumPhotos must be called with mu held for writing.
+func (s *store) removeAlbumPhotos(albumID uint, photoIDs []uint) {
+	removed := make(map[uint]bool, len(photoIDs))
+	for _, photoID := range photoIDs {
+		removed[photoID] = true
+	}
+	ids := []uint{}
+	for _, id := range s.albumPhotoIDs(albumID) {
+		if !removed[id] {
+			ids = append(ids, id)
+		}
+	}
+	s.setAlbumPhotos(albumID, ids)
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type apiKeyRepository struct {
+	*store
+}
+
+func (r *apiKeyRepository) CreateAPIKey(apiKey *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[apiKey.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	for _, other := range r.apiKeys {
+		if other.KeyHash == apiKey.KeyHash {
+			return database.ErrDuplicate
+		}
+	}
+	apiKey.ID = r.nextID("api_keys")
+	apiKey.CreatedAt = time.Now()
+	apiKey.UpdatedAt = time.Now()
+	r.apiKeys[apiKey.ID] = *apiKey
+	return nil
+}
+func (r *apiKeyRepository) GetUserAPIKeys(userID uint) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	apiKeys := []models.APIKey{}
+	for _, id := range sortedIDs(r.apiKeys) {
+		if r.apiKeys[id].UserID == userID {
+			apiKeys = append(apiKeys, r.apiKeys[id])
+		}
+	}
+	return apiKeys, nil
+}
+func (r *apiKeyRepository) GetAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, apiKey := range r.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return apiKey, nil
+		}
+	}
+	return models.APIKey{}, database.ErrNotFound
+}
+func (r *apiKeyRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	apiKey, ok := r.apiKeys[id]
+	if !ok {
+		return nil
+	}
+	apiKey.LastUsedAt = &usedAt
+	r.apiKeys[id] = apiKey
+	return nil
+}
+func (r *apiKeyRepository) DeleteUserAPIKey(userID, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	apiKey, ok := r.apiKeys[id]
+	if !ok || apiKey.UserID != userID {
+		return database.ErrNotFound
+	}
+	delete(r.apiKeys, id)
+	return nil
+}
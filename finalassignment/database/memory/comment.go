@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+type commentRepository struct {
+	*store
+}
+
+func (r *commentRepository) CreateComment(userID uint, commentDto *dto.Comment) (models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newComment := models.Comment{
+		UserID:  userID,
+		PhotoID: commentDto.PhotoID,
+		Message: commentDto.Message,
+		Model: models.Model{
+			ID:        r.nextID("comments"),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	if commentDto.ParentID != 0 {
+		parent, ok := r.comments[commentDto.ParentID]
+		if !ok {
+			return models.Comment{}, database.ErrNotFound
+		}
+		newComment.ParentID = &parent.ID
+		newComment.Depth = parent.Depth + 1
+		parent.ReplyCount++
+		r.comments[parent.ID] = parent
+	}
+	r.comments[newComment.ID] = newComment
+	return newComment, nil
+}
+func (r *commentRepository) GetAllComments(options database.ListOptions) ([]models.CommentWithUserAndPhoto, *database.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	comments, next := page(r.comments, options,
+		func(comment models.Comment) models.Model { return comment.Model },
+		func(comment models.Comment) bool {
+			return (options.UserID == 0 || comment.UserID == options.UserID) &&
+				(options.PhotoID == 0 || comment.PhotoID == options.PhotoID) &&
+				(options.ParentID == 0 || (comment.ParentID != nil && *comment.ParentID == options.ParentID)) &&
+				(!options.TopLevel || comment.ParentID == nil)
+		},
+	)
+	views := make([]models.CommentWithUserAndPhoto, len(comments))
+	for i, comment := range comments {
+		views[i] = models.CommentWithUserAndPhoto{Comment: comment, User: r.listedUser(comment.UserID), Photo: r.photos[comment.PhotoID]}
+	}
+	return views, next, nil
+}
+func (r *commentRepository) GetSingleComment(commentID uint) (models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	comment, ok := r.comments[commentID]
+	if !ok {
+		return models.Comment{}, database.ErrNotFound
+	}
+	return comment, nil
+}
+func (r *commentRepository) GetCommentWithUserAndPhoto(commentID uint) (models.CommentWithUserAndPhoto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	comment, ok := r.comments[commentID]
+	if !ok {
+		return models.CommentWithUserAndPhoto{}, database.ErrNotFound
+	}
+	return models.CommentWithUserAndPhoto{Comment: comment, User: r.listedUser(comment.UserID), Photo: r.photos[comment.PhotoID]}, nil
+}
+func (r *commentRepository) UpdateComment(commentID uint, messageDto *dto.CommentMessage) (models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment, ok := r.comments[commentID]
+	if !ok {
+		return models.Comment{}, database.ErrNotFound
+	}
+	comment.Message = messageDto.Message
+	comment.UpdatedAt = time.Now()
+	r.comments[commentID] = comment
+	return comment, nil
+}
+func (r *commentRepository) DeleteComment(commentID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment, ok := r.comments[commentID]
+	if !ok {
+		return database.ErrNotFound
+	}
+	if comment.ReplyCount > 0 {
+		now := time.Now()
+		comment.Message = ""
+		comment.UserID = 0
+		comment.DeletedAt = &now
+		comment.UpdatedAt = now
+		r.comments[commentID] = comment
+		return nil
+	}
+	for {
+		delete(r.comments, comment.ID)
+		if comment.ParentID == nil {
+			return nil
+		}
+		parent := r.comments[*comment.ParentID]
+		parent.ReplyCount--
+		r.comments[parent.ID] = parent
+		// A tombstone goes away with its last reply.
+		if parent.DeletedAt == nil || parent.ReplyCount > 0 {
+			return nil
+		}
+		comment = parent
+	}
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type identityRepository struct {
+	*store
+}
+
+func (r *identityRepository) CreateIdentity(identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[identity.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	for _, other := range r.identities {
+		if other.Issuer == identity.Issuer && other.Subject == identity.Subject {
+			return database.ErrDuplicate
+		}
+	}
+	identity.ID = r.nextID("user_identities")
+	identity.CreatedAt = time.Now()
+	identity.UpdatedAt = time.Now()
+	r.identities[identity.ID] = *identity
+	return nil
+}
+func (r *identityRepository) GetIdentity(issuer, subject string) (models.UserIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return models.UserIdentity{}, database.ErrNotFound
+}
+func (r *identityRepository) GetUserIdentities(userID uint) ([]models.UserIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	identities := []models.UserIdentity{}
+	for _, id := range sortedIDs(r.identities) {
+		if r.identities[id].UserID == userID {
+			identities = append(identities, r.identities[id])
+		}
+	}
+	return identities, nil
+}
+func (r *identityRepository) DeleteUserIdentity(userID, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity, ok := r.identities[id]
+	if !ok || identity.UserID != userID {
+		return database.ErrNotFound
+	}
+	delete(r.identities, id)
+	return nil
+}
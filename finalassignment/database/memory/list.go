@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+// page returns the page of table options select, the way ListOptions are
+// applied in SQL. keep filters rows on what only some tables have.
+func page[T any](table map[uint]T, options database.ListOptions, model func(T) models.Model, keep func(T) bool) ([]T, *database.Cursor) {
+	rows := []T{}
+	for _, id := range sortedIDs(table) {
+		row := table[id]
+		m := model(row)
+		if !keep(row) ||
+			(!options.CreatedAfter.IsZero() && !m.CreatedAt.After(options.CreatedAfter)) ||
+			(!options.CreatedBefore.IsZero() && !m.CreatedAt.Before(options.CreatedBefore)) {
+			continue
+		}
+		if options.After != nil && !sortsBefore(options, options.After.Time, options.After.ID, options.SortTime(m), m.ID) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := model(rows[i]), model(rows[j])
+		return sortsBefore(options, options.SortTime(a), a.ID, options.SortTime(b), b.ID)
+	})
+	if len(rows) > options.Limit+1 {
+		rows = rows[:options.Limit+1]
+	}
+	return database.NextPage(rows, options, model)
+}
+
+// sortsBefore reports whether the row at aTime, aID comes before the one at
+// bTime, bID in the order of options.
+func sortsBefore(options database.ListOptions, aTime time.Time, aID uint, bTime time.Time, bID uint) bool {
+	if options.Desc {
+		aTime, aID, bTime, bID = bTime, bID, aTime, aID
+	}
+	if !aTime.Equal(bTime) {
+		return aTime.Before(bTime)
+	}
+	return aID < bID
+}
+
+// listedUser returns the fields of user id photos, comments and social
+// medias are shown with, like preloadUser does in SQL. It is zero when the
+// user was deleted.
+func (s *store) listedUser(id uint) models.User {
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}
+	}
+	return models.User{Model: models.Model{ID: user.ID}, Username: user.Username, Email: user.Email}
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type loginAttemptStore struct {
+	*store
+}
+
+func (r *loginAttemptStore) GetLoginAttempt(key string) (models.LoginAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	attempt, ok := r.loginAttempts[key]
+	if !ok {
+		return models.LoginAttempt{}, database.ErrNotFound
+	}
+	return attempt, nil
+}
+func (r *loginAttemptStore) RecordLoginFailure(key string, at, forgetBefore time.Time) (models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for attemptKey, attempt := range r.loginAttempts {
+		if attempt.LastFailureAt.Before(forgetBefore) {
+			delete(r.loginAttempts, attemptKey)
+		}
+	}
+	attempt := r.loginAttempts[key]
+	attempt.Key = key
+	attempt.Failures++
+	attempt.LastFailureAt = at
+	r.loginAttempts[key] = attempt
+	return attempt, nil
+}
+func (r *loginAttemptStore) ClearLoginFailures(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.loginAttempts, key)
+	return nil
+}
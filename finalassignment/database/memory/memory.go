@@ -0,0 +1,105 @@
+// Package memory implements the database repositories on top of plain maps.
+// It is safe for concurrent use and is meant for tests and local
+// experiments that should not need Postgres.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+// store holds every table. All repositories returned by New share one store
+// so deleting a user can detach their photos, comments and social medias the
+// same way the foreign keys do in Postgres.
+type store struct {
+	mu     sync.RWMutex
+	lastID map[string]uint
+	users  map[uint]models.User
+	photos map[uint]models.Photo
+	// photoVariants are keyed by photo ID.
+	photoVariants map[uint][]models.PhotoVariant
+	// photoUploads are keyed by their random ID.
+	photoUploads   map[string]models.PhotoUpload
+	comments       map[uint]models.Comment
+	socialMedias   map[uint]models.SocialMedia
+	refreshTokens  map[uint]models.RefreshToken
+	passwordResets map[uint]models.PasswordResetToken
+	// totpCredentials are keyed by user ID.
+	totpCredentials map[uint]models.TOTPCredential
+	recoveryCodes   map[uint]models.RecoveryCode
+	loginAttempts   map[string]models.LoginAttempt
+	apiKeys         map[uint]models.APIKey
+	identities      map[uint]models.UserIdentity
+	sessions        map[uint]models.Session
+	revokedTokens   map[string]time.Time
+	revokedUsers    map[uint]time.Time
+	albums          map[uint]models.Album
+	// albumPhotos are keyed by album ID and sorted by position.
+	albumPhotos map[uint][]models.AlbumPhoto
+	tags        map[uint]models.Tag
+	// photoTags are keyed by photo ID.
+	photoTags map[uint][]models.PhotoTag
+}
+
+// New returns empty in-memory repositories.
+func New() database.Repositories {
+	s := &store{
+		lastID:          make(map[string]uint),
+		users:           make(map[uint]models.User),
+		photos:          make(map[uint]models.Photo),
+		photoVariants:   make(map[uint][]models.PhotoVariant),
+		photoUploads:    make(map[string]models.PhotoUpload),
+		comments:        make(map[uint]models.Comment),
+		socialMedias:    make(map[uint]models.SocialMedia),
+		albums:          make(map[uint]models.Album),
+		albumPhotos:     make(map[uint][]models.AlbumPhoto),
+		tags:            make(map[uint]models.Tag),
+		photoTags:       make(map[uint][]models.PhotoTag),
+		refreshTokens:   make(map[uint]models.RefreshToken),
+		passwordResets:  make(map[uint]models.PasswordResetToken),
+		totpCredentials: make(map[uint]models.TOTPCredential),
+		recoveryCodes:   make(map[uint]models.RecoveryCode),
+		loginAttempts:   make(map[string]models.LoginAttempt),
+		apiKeys:         make(map[uint]models.APIKey),
+		identities:      make(map[uint]models.UserIdentity),
+		sessions:        make(map[uint]models.Session),
+		revokedTokens:   make(map[string]time.Time),
+		revokedUsers:    make(map[uint]time.Time),
+	}
+	return database.Repositories{
+		Users:          &userRepository{s},
+		Photos:         &photoRepository{s},
+		PhotoUploads:   &photoUploadRepository{s},
+		Comments:       &commentRepository{s},
+		SocialMedias:   &socialMediaRepository{s},
+		Albums:         &albumRepository{s},
+		Tags:           &tagRepository{s},
+		RefreshTokens:  &refreshTokenRepository{s},
+		PasswordResets: &passwordResetRepository{s},
+		TwoFactor:      &twoFactorRepository{s},
+		LoginAttempts:  &loginAttemptStore{s},
+		APIKeys:        &apiKeyRepository{s},
+		Identities:     &identityRepository{s},
+		Sessions:       &sessionRepository{s},
+		Revocations:    &revocationStore{s},
+	}
+}
+
+// nextID must be called with mu held for writing.
+func (s *store) nextID(table string) uint {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+func sortedIDs[T any](table map[uint]T) []uint {
+	ids := make([]uint, 0, len(table))
+	for id := range table {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type passwordResetRepository struct {
+	*store
+}
+
+func (r *passwordResetRepository) CreatePasswordResetToken(resetToken *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[resetToken.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	for _, other := range r.passwordResets {
+		if other.TokenHash == resetToken.TokenHash {
+			return database.ErrDuplicate
+		}
+	}
+	resetToken.ID = r.nextID("password_reset_tokens")
+	resetToken.CreatedAt = time.Now()
+	resetToken.UpdatedAt = time.Now()
+	r.passwordResets[resetToken.ID] = *resetToken
+	return nil
+}
+func (r *passwordResetRepository) UsePasswordResetToken(tokenHash string) (models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, resetToken := range r.passwordResets {
+		if resetToken.TokenHash != tokenHash {
+			continue
+		}
+		now := time.Now()
+		if resetToken.UsedAt != nil || resetToken.ExpiresAt.Before(now) {
+			return resetToken, database.ErrPasswordResetTokenExpired
+		}
+		for id, other := range r.passwordResets {
+			if other.UserID == resetToken.UserID && other.UsedAt == nil {
+				other.UsedAt = &now
+				other.UpdatedAt = now
+				r.passwordResets[id] = other
+			}
+		}
+		resetToken.UsedAt = &now
+		resetToken.UpdatedAt = now
+		return resetToken, nil
+	}
+	return models.PasswordResetToken{}, database.ErrNotFound
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+type photoRepository struct {
+	*store
+}
+
+func (r *photoRepository) CreatePhoto(userID uint, photoDto *dto.Photo, file models.PhotoFile) (ID uint, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newPhoto := models.Photo{
+		Title:     photoDto.Title,
+		Caption:   photoDto.Caption,
+		PhotoUrl:  photoDto.PhotoUrl,
+		UserID:    userID,
+		PhotoFile: file,
+		Model: models.Model{
+			ID:        r.nextID("photos"),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	if file.StorageKey != "" {
+		newPhoto.VariantsStatus = models.VariantsPending
+	} else {
+		newPhoto.LinkStatus = models.LinkPending
+	}
+	r.photos[newPhoto.ID] = newPhoto
+	r.setPhotoTags(newPhoto.ID, newPhoto.Caption)
+	return newPhoto.ID, nil
+}
+func (r *photoRepository) GetAllPhotos(options database.ListOptions) ([]models.PhotoWithUser, *database.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	photos, next := page(r.photos, options,
+		func(photo models.Photo) models.Model { return photo.Model },
+		func(photo models.Photo) bool {
+			return (options.UserID == 0 || photo.UserID == options.UserID) &&
+				(options.Tag == "" || r.hasTag(photo.ID, options.Tag))
+		},
+	)
+	views := make([]models.PhotoWithUser, len(photos))
+	for i, photo := range photos {
+		views[i] = models.PhotoWithUser{Photo: photo, User: r.listedUser(photo.UserID), Variants: r.variantsOf(photo.ID)}
+	}
+	return views, next, nil
+}
+func (r *photoRepository) GetSinglePhoto(photoID uint) (models.Photo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	photo, ok := r.photos[photoID]
+	if !ok {
+		return models.Photo{}, database.ErrNotFound
+	}
+	return photo, nil
+}
+func (r *photoRepository) GetPhotos(photoIDs []uint) ([]models.Photo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	photos := []models.Photo{}
+	for _, photoID := range photoIDs {
+		if photo, ok := r.photos[photoID]; ok {
+			photos = append(photos, photo)
+		}
+	}
+	return photos, nil
+}
+func (r *photoRepository) GetPhotoWithUser(photoID uint) (models.PhotoWithUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	photo, ok := r.photos[photoID]
+	if !ok {
+		return models.PhotoWithUser{}, database.ErrNotFound
+	}
+	return models.PhotoWithUser{Photo: photo, User: r.listedUser(photo.UserID), Variants: r.variantsOf(photo.ID)}, nil
+}
+func (r *photoRepository) UpdatePhoto(photoID uint, photoDto *dto.Photo) (UpdatedAt time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	photo, ok := r.photos[photoID]
+	if !ok {
+		err = database.ErrNotFound
+		return
+	}
+	if photoDto.Title != "" {
+		photo.Title = photoDto.Title
+	}
+	if photoDto.PhotoUrl != "" && photoDto.PhotoUrl != photo.PhotoUrl && photoDto.PhotoUrl != photo.SourceUrl {
+		// The photo no longer shows the file it was uploaded with.
+		photo.PhotoUrl = photoDto.PhotoUrl
+		photo.PhotoFile = models.PhotoFile{}
+		photo.VariantsStatus = ""
+		photo.PhotoLink = models.PhotoLink{LinkStatus: models.LinkPending}
+		delete(r.photoVariants, photoID)
+	}
+	if photoDto.Caption != photo.Caption {
+		r.setPhotoTags(photoID, photoDto.Caption)
+	}
+	photo.Caption = photoDto.Caption
+	photo.UpdatedAt = time.Now()
+	r.photos[photoID] = photo
+	UpdatedAt = photo.UpdatedAt
+	return
+}
+func (r *photoRepository) DeletePhoto(photoID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.photos[photoID]; !ok {
+		return database.ErrNotFound
+	}
+	delete(r.photos, photoID)
+	// ON DELETE CASCADE
+	delete(r.photoVariants, photoID)
+	delete(r.photoTags, photoID)
+	for albumID := range r.albumPhotos {
+		r.removeAlbumPhotos(albumID, []uint{photoID})
+	}
+	// ON DELETE SET NULL
+	for albumID, album := range r.albums {
+		if album.CoverPhotoID != nil && *album.CoverPhotoID == photoID {
+			album.CoverPhotoID = nil
+			r.albums[albumID] = album
+		}
+	}
+	return nil
+}
+func (r *photoRepository) GetPendingVariantPhotoIDs() ([]uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := []uint{}
+	for id, photo := range r.photos {
+		if photo.VariantsStatus == models.VariantsPending {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+func (r *photoRepository) GetPhotoVariants(photoID uint) ([]models.PhotoVariant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.variantsOf(photoID), nil
+}
+func (r *photoRepository) SavePhotoVariants(photoID uint, variants []models.PhotoVariant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	photo, ok := r.photos[photoID]
+	if !ok || photo.VariantsStatus != models.VariantsPending {
+		return database.ErrNotFound
+	}
+	saved := make([]models.PhotoVariant, len(variants))
+	for i, variant := range variants {
+		variant.ID = r.nextID("photo_variants")
+		variant.PhotoID = photoID
+		variant.CreatedAt = time.Now()
+		saved[i] = variant
+		variants[i] = variant
+	}
+	r.photoVariants[photoID] = saved
+	photo.VariantsStatus = models.VariantsReady
+	r.photos[photoID] = photo
+	return nil
+}
+func (r *photoRepository) SetPhotoVariantsStatus(photoID uint, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	photo, ok := r.photos[photoID]
+	if !ok {
+		return database.ErrNotFound
+	}
+	photo.VariantsStatus = status
+	r.photos[photoID] = photo
+	return nil
+}
+func (r *photoRepository) GetPhotoLinksToCheck(brokenBefore time.Time) ([]uint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := []uint{}
+	for id, photo := range r.photos {
+		broken := photo.LinkStatus == models.LinkBroken && photo.LinkCheckedAt != nil && photo.LinkCheckedAt.Before(brokenBefore)
+		if photo.LinkStatus == models.LinkPending || broken {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+func (r *photoRepository) SetPhotoLink(photoID uint, photoURL string, link models.PhotoLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	photo, ok := r.photos[photoID]
+	if !ok || photo.PhotoUrl != photoURL || photo.StorageKey != "" {
+		return database.ErrNotFound
+	}
+	photo.LinkStatus = link.LinkStatus
+	photo.LinkCheckedAt = link.LinkCheckedAt
+	photo.LinkError = link.LinkError
+	r.photos[photoID] = photo
+	return nil
+}
+func (r *photoRepository) IngestPhotoLink(photoID uint, photoURL, copyURL string, file models.PhotoFile, checkedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	photo, ok := r.photos[photoID]
+	if !ok || photo.PhotoUrl != photoURL || photo.StorageKey != "" {
+		return database.ErrNotFound
+	}
+	photo.PhotoUrl = copyURL
+	photo.PhotoFile = file
+	photo.VariantsStatus = models.VariantsPending
+	photo.PhotoLink = models.PhotoLink{LinkStatus: models.LinkOK, LinkCheckedAt: &checkedAt, SourceUrl: photoURL}
+	r.photos[photoID] = photo
+	return nil
+}
+
+// variantsOf returns a copy of the variants of a photo, so callers can't
+// change the store.
+func (s *store) variantsOf(photoID uint) []models.PhotoVariant {
+	return append([]models.PhotoVariant{}, s.photoVariants[photoID]...)
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type photoUploadRepository struct {
+	*store
+}
+
+func (r *photoUploadRepository) CreatePhotoUpload(upload *models.PhotoUpload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[upload.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	if _, ok := r.photoUploads[upload.ID]; ok {
+		return database.ErrDuplicate
+	}
+	upload.CreatedAt = time.Now()
+	upload.UpdatedAt = time.Now()
+	r.photoUploads[upload.ID] = *upload
+	return nil
+}
+func (r *photoUploadRepository) GetPhotoUpload(userID uint, id string) (models.PhotoUpload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	upload, ok := r.photoUploads[id]
+	if !ok || upload.UserID != userID {
+		return models.PhotoUpload{}, database.ErrNotFound
+	}
+	return copyChunks(upload), nil
+}
+func (r *photoUploadRepository) AddPhotoUploadChunk(id string, offset, length int64, key string) (models.PhotoUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	upload, ok := r.photoUploads[id]
+	if !ok || upload.Received != offset {
+		return models.PhotoUpload{}, database.ErrUploadOffsetMismatch
+	}
+	upload.Received += length
+	upload.Chunks = append(copyChunks(upload).Chunks, models.PhotoUploadChunk{
+		UploadID:   id,
+		Position:   len(upload.Chunks),
+		StorageKey: key,
+	})
+	upload.UpdatedAt = time.Now()
+	r.photoUploads[id] = upload
+	return copyChunks(upload), nil
+}
+func (r *photoUploadRepository) DeletePhotoUpload(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.photoUploads, id)
+	return nil
+}
+func (r *photoUploadRepository) DeleteExpiredPhotoUploads(now time.Time) ([]models.PhotoUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	uploads := []models.PhotoUpload{}
+	for id, upload := range r.photoUploads {
+		if upload.ExpiresAt.Before(now) {
+			uploads = append(uploads, upload)
+			delete(r.photoUploads, id)
+		}
+	}
+	return uploads, nil
+}
+
+// copyChunks keeps the chunks of upload from being shared with the store.
+func copyChunks(upload models.PhotoUpload) models.PhotoUpload {
+	upload.Chunks = append([]models.PhotoUploadChunk(nil), upload.Chunks...)
+	return upload
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type refreshTokenRepository struct {
+	*store
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(refreshToken *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(refreshToken)
+}
+
+// insert must be called with mu held for writing.
+func (r *refreshTokenRepository) insert(refreshToken *models.RefreshToken) error {
+	if _, ok := r.users[refreshToken.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	for _, other := range r.refreshTokens {
+		if other.TokenHash == refreshToken.TokenHash {
+			return database.ErrDuplicate
+		}
+	}
+	refreshToken.ID = r.nextID("refresh_tokens")
+	refreshToken.CreatedAt = time.Now()
+	refreshToken.UpdatedAt = time.Now()
+	r.refreshTokens[refreshToken.ID] = *refreshToken
+	return nil
+}
+func (r *refreshTokenRepository) RotateRefreshToken(tokenHash string, next *models.RefreshToken) (models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var current models.RefreshToken
+	found := false
+	for _, refreshToken := range r.refreshTokens {
+		if refreshToken.TokenHash == tokenHash {
+			current, found = refreshToken, true
+			break
+		}
+	}
+	if !found {
+		return current, database.ErrNotFound
+	}
+	now := time.Now()
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		r.revokeWhere(func(refreshToken models.RefreshToken) bool {
+			return refreshToken.FamilyID == current.FamilyID
+		})
+		return current, database.ErrRefreshTokenReused
+	}
+	if current.ExpiresAt.Before(now) {
+		return current, database.ErrRefreshTokenExpired
+	}
+	current.UsedAt = &now
+	current.UpdatedAt = now
+	r.refreshTokens[current.ID] = current
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	return current, r.insert(next)
+}
+func (r *refreshTokenRepository) RevokeRefreshTokenFamily(tokenHash string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, refreshToken := range r.refreshTokens {
+		if refreshToken.TokenHash == tokenHash {
+			familyID := refreshToken.FamilyID
+			r.revokeWhere(func(refreshToken models.RefreshToken) bool {
+				return refreshToken.FamilyID == familyID
+			})
+			return familyID, nil
+		}
+	}
+	return "", database.ErrNotFound
+}
+func (r *refreshTokenRepository) RevokeRefreshTokensOfFamily(familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeWhere(func(refreshToken models.RefreshToken) bool {
+		return refreshToken.FamilyID == familyID
+	})
+	return nil
+}
+func (r *refreshTokenRepository) RevokeUserRefreshTokens(userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeWhere(func(refreshToken models.RefreshToken) bool {
+		return refreshToken.UserID == userID
+	})
+	return nil
+}
+
+// revokeWhere must be called with mu held for writing.
+func (r *refreshTokenRepository) revokeWhere(match func(models.RefreshToken) bool) {
+	now := time.Now()
+	for id, refreshToken := range r.refreshTokens {
+		if match(refreshToken) && refreshToken.RevokedAt == nil {
+			refreshToken.RevokedAt = &now
+			refreshToken.UpdatedAt = now
+			r.refreshTokens[id] = refreshToken
+		}
+	}
+}
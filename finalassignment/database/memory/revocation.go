@@ -0,0 +1,45 @@
+package memory
+
+import "time"
+
+type revocationStore struct {
+	*store
+}
+
+func (r *revocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for revokedJTI, revokedUntil := range r.revokedTokens {
+		if revokedUntil.Before(now) {
+			delete(r.revokedTokens, revokedJTI)
+		}
+	}
+	r.revokedTokens[jti] = expiresAt
+	return nil
+}
+func (r *revocationStore) ConsumeToken(jti string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.revokedTokens[jti]; ok {
+		return false, nil
+	}
+	r.revokedTokens[jti] = expiresAt
+	return true, nil
+}
+func (r *revocationStore) RevokeUserTokens(userID uint, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Truncated like the iat claim.
+	r.revokedUsers[userID] = before.Truncate(time.Microsecond)
+	return nil
+}
+func (r *revocationStore) IsTokenRevoked(jti string, userID uint, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.revokedTokens[jti]; ok {
+		return true, nil
+	}
+	revokedBefore, ok := r.revokedUsers[userID]
+	return ok && revokedBefore.After(issuedAt), nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type sessionRepository struct {
+	*store
+}
+
+func (r *sessionRepository) CreateSession(session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[session.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	for _, other := range r.sessions {
+		if other.FamilyID == session.FamilyID {
+			return database.ErrDuplicate
+		}
+	}
+	session.ID = r.nextID("sessions")
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+	r.sessions[session.ID] = *session
+	return nil
+}
+func (r *sessionRepository) GetSession(id uint) (models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return models.Session{}, database.ErrNotFound
+	}
+	return session, nil
+}
+func (r *sessionRepository) GetSessionByFamily(familyID string) (models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			return session, nil
+		}
+	}
+	return models.Session{}, database.ErrNotFound
+}
+func (r *sessionRepository) GetUserSessions(userID uint) ([]models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	sessions := []models.Session{}
+	for _, id := range sortedIDs(r.sessions) {
+		session := r.sessions[id]
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].ID > sessions[j].ID
+		}
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+func (r *sessionRepository) TouchSession(id uint, seenAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[id]; ok {
+		session.LastSeenAt = seenAt
+		r.sessions[id] = session
+	}
+	return nil
+}
+func (r *sessionRepository) ExtendSession(id uint, seenAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[id]; ok {
+		session.LastSeenAt = seenAt
+		session.ExpiresAt = expiresAt
+		session.UpdatedAt = seenAt
+		r.sessions[id] = session
+	}
+	return nil
+}
+func (r *sessionRepository) RevokeSession(userID, id uint) (models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return models.Session{}, database.ErrNotFound
+	}
+	session.RevokedAt = &now
+	session.UpdatedAt = now
+	r.sessions[id] = session
+	return session, nil
+}
+func (r *sessionRepository) RevokeSessionByFamily(familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeWhere(func(session models.Session) bool {
+		return session.FamilyID == familyID
+	})
+	return nil
+}
+func (r *sessionRepository) RevokeUserSessions(userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeWhere(func(session models.Session) bool {
+		return session.UserID == userID
+	})
+	return nil
+}
+
+// revokeWhere must be called with mu held for writing.
+func (r *sessionRepository) revokeWhere(match func(models.Session) bool) {
+	now := time.Now()
+	for id, session := range r.sessions {
+		if match(session) && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			session.UpdatedAt = now
+			r.sessions[id] = session
+		}
+	}
+}
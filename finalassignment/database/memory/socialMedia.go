@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+type socialMediaRepository struct {
+	*store
+}
+
+func (r *socialMediaRepository) CreateSocialMedia(userID uint, socmedDto *dto.SocialMedia) (models.SocialMedia, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newSocmed := models.SocialMedia{
+		UserID:         userID,
+		Name:           socmedDto.Name,
+		SocialMediaUrl: socmedDto.SocialMediaUrl,
+		Model: models.Model{
+			ID:        r.nextID("social_media"),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	r.socialMedias[newSocmed.ID] = newSocmed
+	return newSocmed, nil
+}
+func (r *socialMediaRepository) GetAllSocialMedias(options database.ListOptions) ([]models.SocialMediaWithUser, *database.Cursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	socmeds, next := page(r.socialMedias, options,
+		func(socmed models.SocialMedia) models.Model { return socmed.Model },
+		func(socmed models.SocialMedia) bool { return options.UserID == 0 || socmed.UserID == options.UserID },
+	)
+	views := make([]models.SocialMediaWithUser, len(socmeds))
+	for i, socmed := range socmeds {
+		views[i] = models.SocialMediaWithUser{SocialMedia: socmed, User: r.listedUser(socmed.UserID)}
+	}
+	return views, next, nil
+}
+func (r *socialMediaRepository) GetSingleSocialMedia(socmedID uint) (models.SocialMedia, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	socmed, ok := r.socialMedias[socmedID]
+	if !ok {
+		return models.SocialMedia{}, database.ErrNotFound
+	}
+	return socmed, nil
+}
+func (r *socialMediaRepository) GetSocialMediaWithUser(socmedID uint) (models.SocialMediaWithUser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	socmed, ok := r.socialMedias[socmedID]
+	if !ok {
+		return models.SocialMediaWithUser{}, database.ErrNotFound
+	}
+	return models.SocialMediaWithUser{SocialMedia: socmed, User: r.listedUser(socmed.UserID)}, nil
+}
+func (r *socialMediaRepository) UpdateSocialMedia(socmedID uint, socmedDto *dto.SocialMedia) (UpdatedAt time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	socmed, ok := r.socialMedias[socmedID]
+	if !ok {
+		err = database.ErrNotFound
+		return
+	}
+	socmed.Name = socmedDto.Name
+	socmed.SocialMediaUrl = socmedDto.SocialMediaUrl
+	socmed.UpdatedAt = time.Now()
+	r.socialMedias[socmedID] = socmed
+	UpdatedAt = socmed.UpdatedAt
+	return
+}
+func (r *socialMediaRepository) DeleteSocialMedia(socmedID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.socialMedias[socmedID]; !ok {
+		return database.ErrNotFound
+	}
+	delete(r.socialMedias, socmedID)
+	return nil
+}
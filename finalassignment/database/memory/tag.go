@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type tagRepository struct {
+	*store
+}
+
+func (r *tagRepository) GetTags(prefix string, limit int) ([]models.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.countTags(limit, func(tag models.Tag, photoTag models.PhotoTag) bool {
+		return strings.HasPrefix(tag.Name, prefix)
+	}), nil
+}
+func (r *tagRepository) GetTrendingTags(since time.Time, limit int) ([]models.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.countTags(limit, func(tag models.Tag, photoTag models.PhotoTag) bool {
+		return !photoTag.CreatedAt.Before(since)
+	}), nil
+}
+
+// countTags counts the photo tags keep accepts by tag, and returns the limit
+// most counted tags, most counted first. It must be called with mu held.
+func (s *store) countTags(limit int, keep func(models.Tag, models.PhotoTag) bool) []models.TagCount {
+	counted := make(map[uint]int64)
+	for _, photoTags := range s.photoTags {
+		for _, photoTag := range photoTags {
+			if keep(s.tags[photoTag.TagID], photoTag) {
+				counted[photoTag.TagID]++
+			}
+		}
+	}
+	counts := []models.TagCount{}
+	for tagID, count := range counted {
+		counts = append(counts, models.TagCount{Name: s.tags[tagID].Name, PhotoCount: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].PhotoCount != counts[j].PhotoCount {
+			return counts[i].PhotoCount > counts[j].PhotoCount
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+// setPhotoTags tags a photo with the hashtags of its caption. Tags the
+// photo already had keep when they were first used on it. It must be called
+// with mu held for writing.
+func (s *store) setPhotoTags(photoID uint, caption string) {
+	taggedAt := make(map[uint]time.Time)
+	for _, photoTag := range s.photoTags[photoID] {
+		taggedAt[photoTag.TagID] = photoTag.CreatedAt
+	}
+	photoTags := []models.PhotoTag{}
+	for _, name := range database.ParseHashtags(caption) {
+		tag, ok := s.tagNamed(name)
+		if !ok {
+			tag = models.Tag{ID: s.nextID("tags"), Name: name, CreatedAt: time.Now()}
+			s.tags[tag.ID] = tag
+		}
+		createdAt, ok := taggedAt[tag.ID]
+		if !ok {
+			createdAt = time.Now()
+		}
+		photoTags = append(photoTags, models.PhotoTag{PhotoID: photoID, TagID: tag.ID, CreatedAt: createdAt})
+	}
+	if len(photoTags) == 0 {
+		delete(s.photoTags, photoID)
+		return
+	}
+	s.photoTags[photoID] = photoTags
+}
+
+// tagNamed must be called with mu held.
+func (s *store) tagNamed(name string) (models.Tag, bool) {
+	for _, tag := range s.tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return models.Tag{}, false
+}
+
+// hasTag must be called with mu held.
+func (s *store) hasTag(photoID uint, name string) bool {
+	for _, photoTag := range s.photoTags[photoID] {
+		if s.tags[photoTag.TagID].Name == name {
+			return true
+		}
+	}
+	return false
+}
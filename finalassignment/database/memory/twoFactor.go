@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+)
+
+type twoFactorRepository struct {
+	*store
+}
+
+func (r *twoFactorRepository) GetTOTPCredential(userID uint) (models.TOTPCredential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	credential, ok := r.totpCredentials[userID]
+	if !ok {
+		return models.TOTPCredential{}, database.ErrNotFound
+	}
+	return credential, nil
+}
+func (r *twoFactorRepository) SaveTOTPCredential(credential *models.TOTPCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[credential.UserID]; !ok {
+		return database.ErrNotFound
+	}
+	credential.CreatedAt = time.Now()
+	credential.UpdatedAt = time.Now()
+	credential.ConfirmedAt = nil
+	credential.LastCounter = 0
+	r.totpCredentials[credential.UserID] = *credential
+	return nil
+}
+func (r *twoFactorRepository) ConfirmTOTP(userID uint, counter int64, recoveryCodeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.totpCredentials[userID]
+	if !ok || credential.ConfirmedAt != nil {
+		return database.ErrNotFound
+	}
+	now := time.Now()
+	credential.ConfirmedAt = &now
+	credential.LastCounter = counter
+	credential.UpdatedAt = now
+	r.totpCredentials[userID] = credential
+	r.deleteRecoveryCodes(userID)
+	for _, hash := range recoveryCodeHashes {
+		id := r.nextID("recovery_codes")
+		r.recoveryCodes[id] = models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+			Model:    models.Model{ID: id, CreatedAt: now, UpdatedAt: now},
+		}
+	}
+	return nil
+}
+func (r *twoFactorRepository) UseTOTPCounter(userID uint, counter int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credential, ok := r.totpCredentials[userID]
+	if !ok || credential.ConfirmedAt == nil || credential.LastCounter >= counter {
+		return false, nil
+	}
+	credential.LastCounter = counter
+	credential.UpdatedAt = time.Now()
+	r.totpCredentials[userID] = credential
+	return true, nil
+}
+func (r *twoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, code := range r.recoveryCodes {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			code.UpdatedAt = now
+			r.recoveryCodes[id] = code
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (r *twoFactorRepository) DeleteTOTPCredential(userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.totpCredentials, userID)
+	r.deleteRecoveryCodes(userID)
+	return nil
+}
+
+// deleteRecoveryCodes must be called with mu held for writing.
+func (s *store) deleteRecoveryCodes(userID uint) {
+	for id, code := range s.recoveryCodes {
+		if code.UserID == userID {
+			delete(s.recoveryCodes, id)
+		}
+	}
+}
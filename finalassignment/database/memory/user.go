@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+type userRepository struct {
+	*store
+}
+
+// checkUnique must be called with mu held.
+func (r *userRepository) checkUnique(user models.User) error {
+	for _, other := range r.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Username == user.Username {
+			return database.ErrDuplicate
+		}
+		if other.Email == user.Email {
+			return database.ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (r *userRepository) CreateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkUnique(*user); err != nil {
+		return err
+	}
+	user.ID = r.nextID("users")
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+func (r *userRepository) GetUserWithoutPreload(id uint) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	return user, nil
+}
+func (r *userRepository) GetUserByEmail(email string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, database.ErrNotFound
+}
+func (r *userRepository) UpdateUser(id uint, userDto *dto.UserUpdate) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	if userDto.Email != "" && userDto.Email != user.Email {
+		user.Email = userDto.Email
+		user.EmailVerifiedAt = nil
+		user.VerificationSentAt = nil
+	}
+	if userDto.Username != "" {
+		user.Username = userDto.Username
+	}
+	if err := r.checkUnique(user); err != nil {
+		return user, err
+	}
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return user, nil
+}
+func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return database.ErrNotFound
+	}
+	user.Password = passwordHash
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+func (r *userRepository) VerifyEmail(id uint, verifiedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return database.ErrNotFound
+	}
+	user.EmailVerifiedAt = &verifiedAt
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+func (r *userRepository) ReserveVerificationEmail(id uint, sentAt, since time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return false, database.ErrNotFound
+	}
+	if user.VerificationSentAt != nil && user.VerificationSentAt.After(since) {
+		return false, nil
+	}
+	user.VerificationSentAt = &sentAt
+	r.users[id] = user
+	return true, nil
+}
+func (r *userRepository) SuspendUser(id uint, suspendedAt *time.Time) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	user.SuspendedAt = suspendedAt
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return user, nil
+}
+func (r *userRepository) UpdateUserRole(id uint, role string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return user, nil
+}
+func (r *userRepository) DeleteUserById(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return database.ErrNotFound
+	}
+	delete(r.users, id)
+	// ON DELETE SET NULL
+	for photoID, photo := range r.photos {
+		if photo.UserID == id {
+			photo.UserID = 0
+			r.photos[photoID] = photo
+		}
+	}
+	for commentID, comment := range r.comments {
+		if comment.UserID == id {
+			comment.UserID = 0
+			r.comments[commentID] = comment
+		}
+	}
+	// ON DELETE CASCADE
+	for albumID, album := range r.albums {
+		if album.UserID == id {
+			delete(r.albums, albumID)
+			delete(r.albumPhotos, albumID)
+		}
+	}
+	for tokenID, refreshToken := range r.refreshTokens {
+		if refreshToken.UserID == id {
+			delete(r.refreshTokens, tokenID)
+		}
+	}
+	for tokenID, resetToken := range r.passwordResets {
+		if resetToken.UserID == id {
+			delete(r.passwordResets, tokenID)
+		}
+	}
+	delete(r.totpCredentials, id)
+	r.deleteRecoveryCodes(id)
+	for apiKeyID, apiKey := range r.apiKeys {
+		if apiKey.UserID == id {
+			delete(r.apiKeys, apiKeyID)
+		}
+	}
+	for identityID, identity := range r.identities {
+		if identity.UserID == id {
+			delete(r.identities, identityID)
+		}
+	}
+	for sessionID, session := range r.sessions {
+		if session.UserID == id {
+			delete(r.sessions, sessionID)
+		}
+	}
+	for uploadID, upload := range r.photoUploads {
+		if upload.UserID == id {
+			delete(r.photoUploads, uploadID)
+		}
+	}
+	for socmedID, socmed := range r.socialMedias {
+		if socmed.UserID == id {
+			socmed.UserID = 0
+			r.socialMedias[socmedID] = socmed
+		}
+	}
+	return nil
+}
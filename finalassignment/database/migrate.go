@@ -0,0 +1,289 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+var (
+	ErrSchemaBehind       = errors.New("The database schema is behind, run \"finalassignment migrate up\".")
+	ErrUnknownMigration   = errors.New("The database has a migration applied that this binary doesn't know about.")
+	migrationFileName     = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	migrationNameAllowed  = regexp.MustCompile(`^\w+$`)
+	createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint PRIMARY KEY,
+    name text NOT NULL,
+    applied_at timestamp NOT NULL
+)`
+)
+
+// afterUp are the parts of migrations SQL can't express, run after their up
+// script in the same transaction.
+var afterUp = map[uint]func(tx *gorm.DB) error{
+	18: tagPhotos,
+}
+
+// Migration is a numbered pair of SQL scripts embedded from
+// migrations/<driver>.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a known migration and when it was applied, if ever.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table.
+type schemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator running the embedded migrations for driver
+// against db.
+func NewMigrator(db *gorm.DB, driver string) (*Migrator, error) {
+	migrations, err := loadMigrations(driver)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Exec(createMigrationsTable).Error; err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func loadMigrations(driver string) ([]Migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %w", driver, err)
+	}
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%s/%s: migration files must be named NNNN_name.up.sql or NNNN_name.down.sql", dir, entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		content, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%s: migration %d has two names, %q and %q", dir, version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (m *Migrator) applied() (map[uint]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists every known migration in order with the time it was applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that haven't been applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[uint]bool, len(m.migrations))
+	var pending []Migration
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("%w (version %d)", ErrUnknownMigration, version)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the ones it applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for i, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			if after, ok := afterUp[migration.Version]; ok {
+				if err := after(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns the ones it rolled back.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var rolledBack []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// CheckSchema returns ErrSchemaBehind when there are pending migrations.
+func (m *Migrator) CheckSchema() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w %d pending, the first is %04d_%s", ErrSchemaBehind, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// CreateMigration writes empty up and down scripts for every driver under
+// dir, numbered after the highest existing migration, and returns their paths.
+func CreateMigration(dir, name string) ([]string, error) {
+	if !migrationNameAllowed.MatchString(name) {
+		return nil, fmt.Errorf("migration name %q may only contain letters, digits and underscores", name)
+	}
+	drivers, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var next uint = 1
+	for _, driver := range drivers {
+		if !driver.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, driver.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if match := migrationFileName.FindStringSubmatch(entry.Name()); match != nil {
+				version, _ := strconv.ParseUint(match[1], 10, 0)
+				if uint(version) >= next {
+					next = uint(version) + 1
+				}
+			}
+		}
+	}
+	var created []string
+	for _, driver := range drivers {
+		if !driver.IsDir() {
+			continue
+		}
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, driver.Name(), fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			content := fmt.Sprintf("-- %04d_%s (%s, %s)\n", next, name, driver.Name(), direction)
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, file)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,126 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"gorm.io/gorm"
+)
+
+// tables lists the tables of the SQLite database db.
+func tables(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var names []string
+	err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name").Scan(&names).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+// expectApplied checks the first applied migrations are applied and the rest
+// are pending, in Status, Pending and CheckSchema alike.
+func expectApplied(t *testing.T, migrator *database.Migrator, applied int) {
+	t.Helper()
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, status := range statuses {
+		if (status.AppliedAt != nil) != (i < applied) {
+			t.Errorf("migration %04d_%s applied at %v, want the first %d applied", status.Version, status.Name, status.AppliedAt, applied)
+		}
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(statuses)-applied {
+		t.Errorf("got %d pending migrations, want %d", len(pending), len(statuses)-applied)
+	}
+	err = migrator.CheckSchema()
+	if applied == len(statuses) && err != nil {
+		t.Errorf("CheckSchema() = %v, want nil", err)
+	}
+	if applied < len(statuses) && !errors.Is(err, database.ErrSchemaBehind) {
+		t.Errorf("CheckSchema() = %v, want ErrSchemaBehind", err)
+	}
+}
+
+// TestMigrateRoundTrip checks every migration rolls back cleanly and applies
+// again on top of its own rollback.
+func TestMigrateRoundTrip(t *testing.T) {
+	db := openSQLite(t)
+	migrator, err := database.NewMigrator(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := len(statuses)
+	expectApplied(t, migrator, all)
+	migrated := tables(t, db)
+
+	rolledBack, err := migrator.Down(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolledBack) != 2 || rolledBack[0].Version != statuses[all-1].Version || rolledBack[1].Version != statuses[all-2].Version {
+		t.Fatalf("rolled back %+v, want the last two migrations newest first", rolledBack)
+	}
+	expectApplied(t, migrator, all-2)
+
+	rolledBack, err = migrator.Down(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolledBack) != all-2 {
+		t.Fatalf("rolled back %d migrations, want %d", len(rolledBack), all-2)
+	}
+	expectApplied(t, migrator, 0)
+	if got := tables(t, db); len(got) != 1 || got[0] != "schema_migrations" {
+		t.Errorf("got tables %v after rolling everything back, want only schema_migrations", got)
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != all {
+		t.Fatalf("applied %d migrations, want %d", len(applied), all)
+	}
+	expectApplied(t, migrator, all)
+	if got := tables(t, db); len(got) != len(migrated) {
+		t.Errorf("got tables %v after migrating again, want %v", got, migrated)
+	}
+	if applied, err := migrator.Up(); err != nil || len(applied) != 0 {
+		t.Errorf("Up() on an up to date schema applied %d migrations, error %v", len(applied), err)
+	}
+
+	// The schema works after the round trip.
+	repos := database.NewRepositories(db)
+	user := createUser(t, repos, "alice")
+	if _, err := repos.Users.GetUserByEmail(user.Email); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateUnknownVersion(t *testing.T) {
+	db := openSQLite(t)
+	migrator, err := database.NewMigrator(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'from_the_future', CURRENT_TIMESTAMP)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckSchema(); !errors.Is(err, database.ErrUnknownMigration) {
+		t.Errorf("CheckSchema() = %v, want ErrUnknownMigration", err)
+	}
+	if _, err := migrator.Up(); !errors.Is(err, database.ErrUnknownMigration) {
+		t.Errorf("Up() = %v, want ErrUnknownMigration", err)
+	}
+}
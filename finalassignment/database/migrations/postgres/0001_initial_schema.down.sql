@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS social_media;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS photos;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,11 @@
+CREATE TABLE revoked_tokens (
+    jti text PRIMARY KEY,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- No foreign key: the row has to outlive the user it revokes.
+CREATE TABLE user_token_revocations (
+    user_id bigint PRIMARY KEY,
+    revoked_before timestamptz NOT NULL
+);
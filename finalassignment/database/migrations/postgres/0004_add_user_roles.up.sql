@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN role text NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN suspended_at timestamptz;
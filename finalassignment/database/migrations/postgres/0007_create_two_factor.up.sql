@@ -0,0 +1,20 @@
+CREATE TABLE totp_credentials (
+    user_id bigint PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    secret text NOT NULL,
+    confirmed_at timestamptz,
+    last_counter bigint NOT NULL DEFAULT 0,
+    CONSTRAINT fk_users_totp_credentials FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL,
+    code_hash text NOT NULL,
+    used_at timestamptz,
+    CONSTRAINT fk_users_recovery_codes FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
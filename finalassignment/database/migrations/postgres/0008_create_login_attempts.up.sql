@@ -0,0 +1,6 @@
+CREATE TABLE login_attempts (
+    key text PRIMARY KEY,
+    failures bigint NOT NULL,
+    last_failure_at timestamptz NOT NULL
+);
+CREATE INDEX idx_login_attempts_last_failure_at ON login_attempts (last_failure_at);
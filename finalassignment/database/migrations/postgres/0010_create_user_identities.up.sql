@@ -0,0 +1,12 @@
+CREATE TABLE user_identities (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL,
+    issuer text NOT NULL,
+    subject text NOT NULL,
+    email text,
+    CONSTRAINT fk_users_user_identities FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX idx_user_identities_issuer_subject ON user_identities (issuer, subject);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
@@ -0,0 +1,15 @@
+CREATE TABLE sessions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL,
+    family_id text NOT NULL,
+    user_agent text,
+    ip text,
+    last_seen_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz,
+    CONSTRAINT fk_users_sessions FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX idx_sessions_family_id ON sessions (family_id);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
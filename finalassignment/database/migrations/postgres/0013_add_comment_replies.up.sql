@@ -0,0 +1,7 @@
+-- Threaded replies, and tombstones for comments deleted while they have
+-- replies.
+ALTER TABLE comments ADD COLUMN parent_id bigint REFERENCES comments (id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN depth bigint NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN reply_count bigint NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN deleted_at timestamptz;
+CREATE INDEX idx_comments_parent_id ON comments (parent_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS photo_upload_chunks;
+DROP TABLE IF EXISTS photo_uploads;
+ALTER TABLE photos DROP COLUMN height;
+ALTER TABLE photos DROP COLUMN width;
+ALTER TABLE photos DROP COLUMN byte_size;
+ALTER TABLE photos DROP COLUMN mime_type;
+ALTER TABLE photos DROP COLUMN storage_key;
@@ -0,0 +1,29 @@
+-- Uploaded photos, and the uploads still being sent in chunks.
+ALTER TABLE photos ADD COLUMN storage_key text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN mime_type text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN byte_size bigint NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN width bigint NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN height bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE photo_uploads (
+    id text PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL,
+    size bigint NOT NULL,
+    received bigint NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL,
+    CONSTRAINT fk_users_photo_uploads FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX idx_photo_uploads_user_id ON photo_uploads (user_id);
+CREATE INDEX idx_photo_uploads_expires_at ON photo_uploads (expires_at);
+
+-- The chunk that moved an upload on, stored under a key of its own so
+-- retries of the same chunk don't overwrite each other.
+CREATE TABLE photo_upload_chunks (
+    upload_id text NOT NULL,
+    position bigint NOT NULL,
+    storage_key text NOT NULL,
+    PRIMARY KEY (upload_id, position),
+    CONSTRAINT fk_photo_uploads_chunks FOREIGN KEY (upload_id) REFERENCES photo_uploads (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,27 @@
+-- Albums group photos of their user in the order they chose.
+CREATE TABLE albums (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    title text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    cover_photo_id bigint,
+    visibility text NOT NULL DEFAULT 'public',
+    user_id bigint NOT NULL,
+    CONSTRAINT fk_users_albums FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_photos_album_covers FOREIGN KEY (cover_photo_id) REFERENCES photos (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX idx_albums_user_id ON albums (user_id);
+CREATE INDEX idx_albums_created_at_id ON albums (created_at, id);
+CREATE INDEX idx_albums_updated_at_id ON albums (updated_at, id);
+
+CREATE TABLE album_photos (
+    album_id bigint NOT NULL,
+    photo_id bigint NOT NULL,
+    position bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (album_id, photo_id),
+    CONSTRAINT fk_albums_album_photos FOREIGN KEY (album_id) REFERENCES albums (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_photos_album_photos FOREIGN KEY (photo_id) REFERENCES photos (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX idx_album_photos_photo_id ON album_photos (photo_id);
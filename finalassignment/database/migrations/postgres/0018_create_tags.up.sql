@@ -0,0 +1,18 @@
+-- Tags are the hashtags of photo captions, kept in lower case.
+CREATE TABLE tags (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_tags_name ON tags (name);
+
+CREATE TABLE photo_tags (
+    photo_id bigint NOT NULL,
+    tag_id bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (photo_id, tag_id),
+    CONSTRAINT fk_photos_photo_tags FOREIGN KEY (photo_id) REFERENCES photos (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_tags_photo_tags FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX idx_photo_tags_tag_id ON photo_tags (tag_id);
+CREATE INDEX idx_photo_tags_created_at ON photo_tags (created_at);
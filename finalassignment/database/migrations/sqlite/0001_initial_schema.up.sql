@@ -0,0 +1,44 @@
+-- Matches the schema AutoMigrate used to create, so databases created
+-- before migrations existed can be brought under version control as is.
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    username text NOT NULL,
+    email text NOT NULL,
+    password text NOT NULL,
+    age integer NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS photos (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    title text NOT NULL,
+    caption text,
+    photo_url text NOT NULL,
+    user_id integer,
+    CONSTRAINT fk_users_photos FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer,
+    photo_id integer,
+    message varchar(8192) NOT NULL,
+    CONSTRAINT fk_users_comments FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS social_media (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    name varchar(8192) NOT NULL,
+    social_media_url varchar(8192) NOT NULL,
+    user_id integer,
+    CONSTRAINT fk_users_social_medias FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL ON UPDATE CASCADE
+);
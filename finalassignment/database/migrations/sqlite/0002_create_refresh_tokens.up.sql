@@ -0,0 +1,14 @@
+CREATE TABLE refresh_tokens (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL,
+    family_id text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at datetime NOT NULL,
+    used_at datetime,
+    revoked_at datetime,
+    CONSTRAINT fk_users_refresh_tokens FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
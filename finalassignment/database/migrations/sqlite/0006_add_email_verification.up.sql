@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email_verified_at datetime;
+ALTER TABLE users ADD COLUMN verification_sent_at datetime;
+-- Accounts created before verification existed are trusted as they are.
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
@@ -0,0 +1,20 @@
+CREATE TABLE totp_credentials (
+    user_id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    secret text NOT NULL,
+    confirmed_at datetime,
+    last_counter integer NOT NULL DEFAULT 0,
+    CONSTRAINT fk_users_totp_credentials FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL,
+    code_hash text NOT NULL,
+    used_at datetime,
+    CONSTRAINT fk_users_recovery_codes FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
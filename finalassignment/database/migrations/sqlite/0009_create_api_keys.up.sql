@@ -0,0 +1,14 @@
+CREATE TABLE api_keys (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL,
+    name text NOT NULL,
+    prefix text NOT NULL,
+    key_hash text NOT NULL,
+    scope text NOT NULL,
+    last_used_at datetime,
+    CONSTRAINT fk_users_api_keys FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,15 @@
+CREATE TABLE sessions (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL,
+    family_id text NOT NULL,
+    user_agent text,
+    ip text,
+    last_seen_at datetime NOT NULL,
+    expires_at datetime NOT NULL,
+    revoked_at datetime,
+    CONSTRAINT fk_users_sessions FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX idx_sessions_family_id ON sessions (family_id);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_social_media_updated_at_id;
+DROP INDEX IF EXISTS idx_social_media_created_at_id;
+DROP INDEX IF EXISTS idx_social_media_user_id;
+DROP INDEX IF EXISTS idx_comments_updated_at_id;
+DROP INDEX IF EXISTS idx_comments_created_at_id;
+DROP INDEX IF EXISTS idx_comments_photo_id;
+DROP INDEX IF EXISTS idx_comments_user_id;
+DROP INDEX IF EXISTS idx_photos_updated_at_id;
+DROP INDEX IF EXISTS idx_photos_created_at_id;
+DROP INDEX IF EXISTS idx_photos_user_id;
@@ -0,0 +1,11 @@
+-- Filters and keyset pagination of the list endpoints.
+CREATE INDEX idx_photos_user_id ON photos (user_id);
+CREATE INDEX idx_photos_created_at_id ON photos (created_at, id);
+CREATE INDEX idx_photos_updated_at_id ON photos (updated_at, id);
+CREATE INDEX idx_comments_user_id ON comments (user_id);
+CREATE INDEX idx_comments_photo_id ON comments (photo_id);
+CREATE INDEX idx_comments_created_at_id ON comments (created_at, id);
+CREATE INDEX idx_comments_updated_at_id ON comments (updated_at, id);
+CREATE INDEX idx_social_media_user_id ON social_media (user_id);
+CREATE INDEX idx_social_media_created_at_id ON social_media (created_at, id);
+CREATE INDEX idx_social_media_updated_at_id ON social_media (updated_at, id);
@@ -0,0 +1,7 @@
+-- Threaded replies, and tombstones for comments deleted while they have
+-- replies.
+ALTER TABLE comments ADD COLUMN parent_id integer REFERENCES comments (id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN depth integer NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN reply_count integer NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN deleted_at datetime;
+CREATE INDEX idx_comments_parent_id ON comments (parent_id);
@@ -0,0 +1,29 @@
+-- Uploaded photos, and the uploads still being sent in chunks.
+ALTER TABLE photos ADD COLUMN storage_key text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN mime_type text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN byte_size integer NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN width integer NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN height integer NOT NULL DEFAULT 0;
+
+CREATE TABLE photo_uploads (
+    id text PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL,
+    size integer NOT NULL,
+    received integer NOT NULL DEFAULT 0,
+    expires_at datetime NOT NULL,
+    CONSTRAINT fk_users_photo_uploads FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX idx_photo_uploads_user_id ON photo_uploads (user_id);
+CREATE INDEX idx_photo_uploads_expires_at ON photo_uploads (expires_at);
+
+-- The chunk that moved an upload on, stored under a key of its own so
+-- retries of the same chunk don't overwrite each other.
+CREATE TABLE photo_upload_chunks (
+    upload_id text NOT NULL,
+    position integer NOT NULL,
+    storage_key text NOT NULL,
+    PRIMARY KEY (upload_id, position),
+    CONSTRAINT fk_photo_uploads_chunks FOREIGN KEY (upload_id) REFERENCES photo_uploads (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
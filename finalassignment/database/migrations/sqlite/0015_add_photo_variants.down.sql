@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS photo_variants;
+DROP INDEX IF EXISTS idx_photos_variants_status;
+ALTER TABLE photos DROP COLUMN variants_status;
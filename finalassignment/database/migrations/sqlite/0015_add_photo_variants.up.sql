@@ -0,0 +1,18 @@
+-- Resized variants of uploaded photos.
+ALTER TABLE photos ADD COLUMN variants_status text NOT NULL DEFAULT '';
+CREATE INDEX idx_photos_variants_status ON photos (variants_status);
+
+CREATE TABLE photo_variants (
+    id integer PRIMARY KEY,
+    created_at datetime,
+    photo_id integer NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    storage_key text NOT NULL DEFAULT '',
+    mime_type text NOT NULL DEFAULT '',
+    byte_size integer NOT NULL DEFAULT 0,
+    width integer NOT NULL DEFAULT 0,
+    height integer NOT NULL DEFAULT 0,
+    CONSTRAINT fk_photos_photo_variants FOREIGN KEY (photo_id) REFERENCES photos (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX idx_photo_variants_photo_id_name ON photo_variants (photo_id, name);
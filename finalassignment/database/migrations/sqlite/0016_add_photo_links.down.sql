@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_photos_link_status;
+ALTER TABLE photos DROP COLUMN source_url;
+ALTER TABLE photos DROP COLUMN link_error;
+ALTER TABLE photos DROP COLUMN link_checked_at;
+ALTER TABLE photos DROP COLUMN link_status;
@@ -0,0 +1,8 @@
+-- What the checks of the URLs of linked photos found. Photos linked before
+-- are checked once the server starts.
+ALTER TABLE photos ADD COLUMN link_status text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN link_checked_at datetime;
+ALTER TABLE photos ADD COLUMN link_error text NOT NULL DEFAULT '';
+ALTER TABLE photos ADD COLUMN source_url text NOT NULL DEFAULT '';
+CREATE INDEX idx_photos_link_status ON photos (link_status);
+UPDATE photos SET link_status = 'pending' WHERE storage_key = '';
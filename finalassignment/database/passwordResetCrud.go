@@ -0,0 +1,48 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+var ErrPasswordResetTokenExpired = errors.New("The password reset token has expired or has already been used.")
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func (r *passwordResetRepository) CreatePasswordResetToken(resetToken *models.PasswordResetToken) error {
+	resetToken.CreatedAt = time.Now()
+	resetToken.UpdatedAt = time.Now()
+	return r.db.Create(resetToken).Error
+}
+func (r *passwordResetRepository) UsePasswordResetToken(tokenHash string) (resetToken models.PasswordResetToken, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", tokenHash).Take(&resetToken).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if resetToken.ExpiresAt.Before(now) {
+			return ErrPasswordResetTokenExpired
+		}
+		// The condition on used_at lets only one of concurrent resets with
+		// the same token succeed.
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Updates(map[string]interface{}{"used_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrPasswordResetTokenExpired
+		}
+		resetToken.UsedAt = &now
+		return tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", resetToken.UserID).
+			Updates(map[string]interface{}{"used_at": now, "updated_at": now}).Error
+	})
+	return
+}
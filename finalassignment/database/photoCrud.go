@@ -4,77 +4,193 @@ import (
 	"time"
 
 	"finalassignment.id/finalassignment/dto"
-	_ "finalassignment.id/finalassignment/dto"
 	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
 )
 
-func UpdatePhoto(photoID, userID uint, photoDto *dto.Photo) (UpdatedAt time.Time, err error) {
-	photo, err := GetSinglePhoto(photoID)
+type photoRepository struct {
+	db *gorm.DB
+}
+
+func (r *photoRepository) UpdatePhoto(photoID uint, photoDto *dto.Photo) (UpdatedAt time.Time, err error) {
+	photo, err := r.GetSinglePhoto(photoID)
 	if err != nil {
 		return
 	}
-	if photo.UserID != userID {
-		err = ErrIllegalUpdate
-		return
-	}
 	if photoDto.Title != "" {
 		photo.Title = photoDto.Title
 	}
-	if photoDto.PhotoUrl != "" {
+	replaced := false
+	if photoDto.PhotoUrl != "" && photoDto.PhotoUrl != photo.PhotoUrl && photoDto.PhotoUrl != photo.SourceUrl {
+		// The photo no longer shows the file it was uploaded with.
 		photo.PhotoUrl = photoDto.PhotoUrl
+		photo.PhotoFile = models.PhotoFile{}
+		photo.VariantsStatus = ""
+		photo.PhotoLink = models.PhotoLink{LinkStatus: models.LinkPending}
+		replaced = true
 	}
+	recaptioned := photoDto.Caption != photo.Caption
 	photo.Caption = photoDto.Caption
 	photo.UpdatedAt = time.Now()
-	err = db.Save(&photo).Error
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&photo).Error; err != nil {
+			return err
+		}
+		if recaptioned {
+			if err := setPhotoTags(tx, photoID, photo.Caption); err != nil {
+				return err
+			}
+		}
+		if !replaced {
+			return nil
+		}
+		return tx.Where("photo_id = ?", photoID).Delete(&models.PhotoVariant{}).Error
+	})
+	UpdatedAt = photo.UpdatedAt
 	return
 }
-func DeletePhoto(photoID, userID uint) error {
-	photo, err := GetSinglePhoto(photoID)
+func (r *photoRepository) DeletePhoto(photoID uint) error {
+	photo, err := r.GetSinglePhoto(photoID)
 	if err != nil {
 		return err
 	}
-	if photo.UserID != userID {
-		return ErrIllegalUpdate
-	}
-	if err := db.Delete(&photo, photoID).Error; err != nil {
+	if err := r.db.Delete(&photo, photoID).Error; err != nil {
 		return err
 	}
 	return nil
 }
-func CreatePhoto(userID uint, photoDto *dto.Photo) (ID uint, err error) {
-	if db == nil {
-		err = ErrDbNotStarted
-		return
-	}
+func (r *photoRepository) CreatePhoto(userID uint, photoDto *dto.Photo, file models.PhotoFile) (ID uint, err error) {
 	newPhoto := models.Photo{
-		Title:    photoDto.Title,
-		Caption:  photoDto.Caption,
-		PhotoUrl: photoDto.PhotoUrl,
-		UserID:   userID,
+		Title:     photoDto.Title,
+		Caption:   photoDto.Caption,
+		PhotoUrl:  photoDto.PhotoUrl,
+		UserID:    userID,
+		PhotoFile: file,
 		Model: models.Model{
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
 	}
-	err = db.Create(&newPhoto).Error
+	if file.StorageKey != "" {
+		newPhoto.VariantsStatus = models.VariantsPending
+	} else {
+		newPhoto.LinkStatus = models.LinkPending
+	}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newPhoto).Error; err != nil {
+			return err
+		}
+		return setPhotoTags(tx, newPhoto.ID, newPhoto.Caption)
+	})
 	if err != nil {
 		return
 	}
 	ID = newPhoto.ID
 	return
 }
-func GetAllPhotos() ([]models.Photo, error) {
-	photos := make([]models.Photo, 1)
-	if err := db.Model(&models.Photo{}).Find(&photos).Error; err != nil {
-		return nil, err
+func (r *photoRepository) GetAllPhotos(options ListOptions) ([]models.PhotoWithUser, *Cursor, error) {
+	photos := []models.PhotoWithUser{}
+	query := r.db.Model(&models.PhotoWithUser{})
+	if options.Tag != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.PhotoTag{}).Select("photo_tags.photo_id").
+			Joins("JOIN tags ON tags.id = photo_tags.tag_id").Where("tags.name = ?", options.Tag))
+	}
+	if err := preloadVariants(preloadUser(options.apply(query))).Find(&photos).Error; err != nil {
+		return nil, nil, err
 	}
-	return photos, nil
+	photos, next := NextPage(photos, options, func(photo models.PhotoWithUser) models.Model { return photo.Model })
+	return photos, next, nil
 }
-func GetSinglePhoto(photoID uint) (models.Photo, error) {
+func (r *photoRepository) GetSinglePhoto(photoID uint) (models.Photo, error) {
 	photo := models.Photo{}
-	if db == nil {
-		return photo, ErrDbNotStarted
-	}
-	err := db.Model(&models.Photo{}).Take(&photo, photoID).Error
+	err := r.db.Model(&models.Photo{}).Take(&photo, photoID).Error
+	return photo, err
+}
+func (r *photoRepository) GetPhotos(photoIDs []uint) ([]models.Photo, error) {
+	photos := []models.Photo{}
+	err := r.db.Model(&models.Photo{}).Where("id IN ?", photoIDs).Find(&photos).Error
+	return photos, err
+}
+func (r *photoRepository) GetPhotoWithUser(photoID uint) (models.PhotoWithUser, error) {
+	photo := models.PhotoWithUser{}
+	err := preloadVariants(preloadUser(r.db.Model(&models.PhotoWithUser{}))).Take(&photo, photoID).Error
 	return photo, err
 }
+func (r *photoRepository) GetPendingVariantPhotoIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Photo{}).Where("variants_status = ?", models.VariantsPending).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+func (r *photoRepository) GetPhotoVariants(photoID uint) ([]models.PhotoVariant, error) {
+	variants := []models.PhotoVariant{}
+	err := r.db.Where("photo_id = ?", photoID).Order("id").Find(&variants).Error
+	return variants, err
+}
+func (r *photoRepository) SavePhotoVariants(photoID uint, variants []models.PhotoVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Photo{}).Where("id = ? AND variants_status = ?", photoID, models.VariantsPending).UpdateColumn("variants_status", models.VariantsReady)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Where("photo_id = ?", photoID).Delete(&models.PhotoVariant{}).Error; err != nil {
+			return err
+		}
+		if len(variants) == 0 {
+			return nil
+		}
+		for i := range variants {
+			variants[i].PhotoID = photoID
+			variants[i].CreatedAt = time.Now()
+		}
+		return tx.Create(&variants).Error
+	})
+}
+func (r *photoRepository) SetPhotoVariantsStatus(photoID uint, status string) error {
+	return r.db.Model(&models.Photo{}).Where("id = ?", photoID).UpdateColumn("variants_status", status).Error
+}
+func (r *photoRepository) GetPhotoLinksToCheck(brokenBefore time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Photo{}).
+		Where("link_status = ? OR (link_status = ? AND link_checked_at < ?)", models.LinkPending, models.LinkBroken, brokenBefore).
+		Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+func (r *photoRepository) SetPhotoLink(photoID uint, photoURL string, link models.PhotoLink) error {
+	result := r.db.Model(&models.Photo{}).Where("id = ? AND photo_url = ? AND storage_key = ''", photoID, photoURL).UpdateColumns(map[string]interface{}{
+		"link_status":     link.LinkStatus,
+		"link_checked_at": link.LinkCheckedAt,
+		"link_error":      link.LinkError,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+func (r *photoRepository) IngestPhotoLink(photoID uint, photoURL, copyURL string, file models.PhotoFile, checkedAt time.Time) error {
+	result := r.db.Model(&models.Photo{}).Where("id = ? AND photo_url = ? AND storage_key = ''", photoID, photoURL).UpdateColumns(map[string]interface{}{
+		"photo_url":       copyURL,
+		"source_url":      photoURL,
+		"storage_key":     file.StorageKey,
+		"mime_type":       file.MimeType,
+		"byte_size":       file.ByteSize,
+		"width":           file.Width,
+		"height":          file.Height,
+		"variants_status": models.VariantsPending,
+		"link_status":     models.LinkOK,
+		"link_checked_at": checkedAt,
+		"link_error":      "",
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
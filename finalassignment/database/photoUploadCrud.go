@@ -0,0 +1,83 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+var ErrUploadOffsetMismatch = errors.New("The upload has moved on, resume it from its current offset.")
+
+type photoUploadRepository struct {
+	db *gorm.DB
+}
+
+func (r *photoUploadRepository) CreatePhotoUpload(upload *models.PhotoUpload) error {
+	upload.CreatedAt = time.Now()
+	upload.UpdatedAt = time.Now()
+	return r.db.Create(upload).Error
+}
+func (r *photoUploadRepository) GetPhotoUpload(userID uint, id string) (models.PhotoUpload, error) {
+	upload := models.PhotoUpload{}
+	err := preloadChunks(r.db).Where("id = ? AND user_id = ?", id, userID).Take(&upload).Error
+	return upload, err
+}
+func (r *photoUploadRepository) AddPhotoUploadChunk(id string, offset, length int64, key string) (models.PhotoUpload, error) {
+	upload := models.PhotoUpload{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Moving the offset on locks the upload, so the chunk is numbered
+		// after the ones counted before.
+		result := tx.Model(&models.PhotoUpload{}).Where("id = ? AND received = ?", id, offset).Updates(map[string]interface{}{
+			"received":   gorm.Expr("received + ?", length),
+			"updated_at": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUploadOffsetMismatch
+		}
+		var chunks int64
+		if err := tx.Model(&models.PhotoUploadChunk{}).Where("upload_id = ?", id).Count(&chunks).Error; err != nil {
+			return err
+		}
+		chunk := models.PhotoUploadChunk{UploadID: id, Position: int(chunks), StorageKey: key}
+		if err := tx.Create(&chunk).Error; err != nil {
+			return err
+		}
+		return preloadChunks(tx).Where("id = ?", id).Take(&upload).Error
+	})
+	if err != nil {
+		return models.PhotoUpload{}, err
+	}
+	return upload, nil
+}
+func (r *photoUploadRepository) DeletePhotoUpload(id string) error {
+	return r.db.Where("id = ?", id).Delete(&models.PhotoUpload{}).Error
+}
+func (r *photoUploadRepository) DeleteExpiredPhotoUploads(now time.Time) ([]models.PhotoUpload, error) {
+	uploads := []models.PhotoUpload{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := preloadChunks(tx).Where("expires_at < ?", now).Find(&uploads).Error; err != nil {
+			return err
+		}
+		if len(uploads) == 0 {
+			return nil
+		}
+		ids := make([]string, len(uploads))
+		for i, upload := range uploads {
+			ids[i] = upload.ID
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.PhotoUpload{}).Error
+	})
+	return uploads, err
+}
+
+// preloadChunks loads the chunks of the uploads of query in their order.
+func preloadChunks(query *gorm.DB) *gorm.DB {
+	return query.Preload("Chunks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	})
+}
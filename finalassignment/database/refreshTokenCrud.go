@@ -0,0 +1,80 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenExpired = errors.New("The refresh token has expired, please login again.")
+	ErrRefreshTokenReused  = errors.New("The refresh token has already been used, every session started from the same login has been revoked.")
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(refreshToken *models.RefreshToken) error {
+	refreshToken.CreatedAt = time.Now()
+	refreshToken.UpdatedAt = time.Now()
+	return r.db.Create(refreshToken).Error
+}
+func (r *refreshTokenRepository) RotateRefreshToken(tokenHash string, next *models.RefreshToken) (current models.RefreshToken, err error) {
+	reused := false
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", tokenHash).Take(&current).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if current.UsedAt == nil && current.RevokedAt == nil {
+			if current.ExpiresAt.Before(now) {
+				return ErrRefreshTokenExpired
+			}
+			// The condition on used_at makes concurrent rotations of the same
+			// token count as reuse instead of both succeeding.
+			result := tx.Model(&models.RefreshToken{}).
+				Where("id = ? AND used_at IS NULL", current.ID).
+				Updates(map[string]interface{}{"used_at": now, "updated_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				next.UserID = current.UserID
+				next.FamilyID = current.FamilyID
+				next.CreatedAt = now
+				next.UpdatedAt = now
+				return tx.Create(next).Error
+			}
+		}
+		reused = true
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", current.FamilyID).
+			Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	})
+	if err == nil && reused {
+		err = ErrRefreshTokenReused
+	}
+	return
+}
+func (r *refreshTokenRepository) RevokeRefreshTokenFamily(tokenHash string) (string, error) {
+	var refreshToken models.RefreshToken
+	if err := r.db.Where("token_hash = ?", tokenHash).Take(&refreshToken).Error; err != nil {
+		return "", err
+	}
+	return refreshToken.FamilyID, r.RevokeRefreshTokensOfFamily(refreshToken.FamilyID)
+}
+func (r *refreshTokenRepository) RevokeRefreshTokensOfFamily(familyID string) error {
+	now := time.Now()
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+}
+func (r *refreshTokenRepository) RevokeUserRefreshTokens(userID uint) error {
+	now := time.Now()
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+}
@@ -0,0 +1,175 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/database/memory"
+	"finalassignment.id/finalassignment/models"
+)
+
+// forEachStore runs test against the SQLite repositories and the in-memory
+// ones, which the handler tests run on.
+func forEachStore(t *testing.T, test func(t *testing.T, repos database.Repositories)) {
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, database.NewRepositories(openSQLite(t)))
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, memory.New())
+	})
+}
+
+func createUser(t *testing.T, repos database.Repositories, name string) models.User {
+	t.Helper()
+	user := models.User{Username: name, Email: name + "@example.com", Password: "x", Age: 20, Role: models.RoleUser}
+	if err := repos.Users.CreateUser(&user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createRefreshToken(t *testing.T, repos database.Repositories, userID uint, family, hash string, expiresAt time.Time) {
+	t.Helper()
+	err := repos.RefreshTokens.CreateRefreshToken(&models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rotate rotates the token with hash into a new one with the hash next.
+func rotate(t *testing.T, repos database.Repositories, hash, next string) (models.RefreshToken, error) {
+	t.Helper()
+	return repos.RefreshTokens.RotateRefreshToken(hash, &models.RefreshToken{TokenHash: next, ExpiresAt: time.Now().Add(time.Hour)})
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		user := createUser(t, repos, "alice")
+		createRefreshToken(t, repos, user.ID, "family", "a", time.Now().Add(time.Hour))
+
+		current, err := rotate(t, repos, "a", "b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current.UserID != user.ID || current.FamilyID != "family" {
+			t.Errorf("rotated %+v", current)
+		}
+		if _, err := rotate(t, repos, "b", "c"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rotate(t, repos, "missing", "d"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("rotating an unknown token gave %v, want ErrNotFound", err)
+		}
+	})
+}
+
+// TestRefreshTokenReuse checks that presenting a rotated token revokes the
+// whole family, the newest token included.
+func TestRefreshTokenReuse(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		user := createUser(t, repos, "alice")
+		createRefreshToken(t, repos, user.ID, "family", "a", time.Now().Add(time.Hour))
+		createRefreshToken(t, repos, user.ID, "other", "x", time.Now().Add(time.Hour))
+		if _, err := rotate(t, repos, "a", "b"); err != nil {
+			t.Fatal(err)
+		}
+
+		current, err := rotate(t, repos, "a", "c")
+		if !errors.Is(err, database.ErrRefreshTokenReused) {
+			t.Fatalf("reusing a token gave %v, want ErrRefreshTokenReused", err)
+		}
+		if current.FamilyID != "family" {
+			t.Errorf("reuse returned family %q", current.FamilyID)
+		}
+		if _, err := rotate(t, repos, "b", "d"); !errors.Is(err, database.ErrRefreshTokenReused) {
+			t.Errorf("the token rotated in before the reuse gave %v, want ErrRefreshTokenReused", err)
+		}
+		if _, err := rotate(t, repos, "c", "e"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("the token of the refused rotation gave %v, want ErrNotFound", err)
+		}
+		// Other logins of the user are left alone.
+		if _, err := rotate(t, repos, "x", "y"); err != nil {
+			t.Errorf("the other family gave %v", err)
+		}
+	})
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		user := createUser(t, repos, "alice")
+		createRefreshToken(t, repos, user.ID, "family", "a", time.Now().Add(-time.Second))
+
+		if _, err := rotate(t, repos, "a", "b"); !errors.Is(err, database.ErrRefreshTokenExpired) {
+			t.Fatalf("rotating an expired token gave %v, want ErrRefreshTokenExpired", err)
+		}
+		if _, err := rotate(t, repos, "b", "c"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("an expired token was rotated into %v", err)
+		}
+	})
+}
+
+func TestRevokeRefreshTokens(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		alice := createUser(t, repos, "alice")
+		bob := createUser(t, repos, "bob")
+		later := time.Now().Add(time.Hour)
+		createRefreshToken(t, repos, alice.ID, "alice-1", "a1", later)
+		createRefreshToken(t, repos, alice.ID, "alice-2", "a2", later)
+		createRefreshToken(t, repos, alice.ID, "alice-3", "a3", later)
+		createRefreshToken(t, repos, bob.ID, "bob-1", "b1", later)
+
+		family, err := repos.RefreshTokens.RevokeRefreshTokenFamily("a1")
+		if err != nil || family != "alice-1" {
+			t.Fatalf("RevokeRefreshTokenFamily = %q, %v", family, err)
+		}
+		if _, err := rotate(t, repos, "a1", "n1"); !errors.Is(err, database.ErrRefreshTokenReused) {
+			t.Errorf("a revoked token gave %v, want ErrRefreshTokenReused", err)
+		}
+		if _, err := rotate(t, repos, "a2", "n2"); err != nil {
+			t.Fatalf("another family of the user gave %v", err)
+		}
+
+		if err := repos.RefreshTokens.RevokeUserRefreshTokens(alice.ID); err != nil {
+			t.Fatal(err)
+		}
+		for _, hash := range []string{"n2", "a3"} {
+			if _, err := rotate(t, repos, hash, hash+"-next"); !errors.Is(err, database.ErrRefreshTokenReused) {
+				t.Errorf("token %s of a user whose tokens were revoked gave %v", hash, err)
+			}
+		}
+		if _, err := rotate(t, repos, "b1", "b2"); err != nil {
+			t.Errorf("another user's token gave %v", err)
+		}
+	})
+}
+
+func TestRevokeRefreshTokensOfFamily(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		user := createUser(t, repos, "alice")
+		later := time.Now().Add(time.Hour)
+		createRefreshToken(t, repos, user.ID, "phone", "p1", later)
+		createRefreshToken(t, repos, user.ID, "laptop", "l1", later)
+		if _, err := rotate(t, repos, "p1", "p2"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := repos.RefreshTokens.RevokeRefreshTokensOfFamily("phone"); err != nil {
+			t.Fatal(err)
+		}
+		for _, hash := range []string{"p1", "p2"} {
+			if _, err := rotate(t, repos, hash, hash+"-next"); !errors.Is(err, database.ErrRefreshTokenReused) {
+				t.Errorf("token %s of the revoked family gave %v", hash, err)
+			}
+		}
+		if _, err := rotate(t, repos, "l1", "l2"); err != nil {
+			t.Errorf("the token of another family gave %v", err)
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	CreateUser(user *models.User) error
+	GetUserWithoutPreload(id uint) (models.User, error)
+	GetUserByEmail(email string) (models.User, error)
+	// UpdateUser marks the email unverified again when it changes.
+	UpdateUser(id uint, userDto *dto.UserUpdate) (models.User, error)
+	UpdatePassword(id uint, passwordHash string) error
+	VerifyEmail(id uint, verifiedAt time.Time) error
+	// ReserveVerificationEmail records that a verification email is sent to
+	// the user at sentAt, unless the last one was sent after since, and
+	// reports whether it did.
+	ReserveVerificationEmail(id uint, sentAt, since time.Time) (bool, error)
+	// SuspendUser sets the SuspendedAt of the user, nil lifts the suspension.
+	SuspendUser(id uint, suspendedAt *time.Time) (models.User, error)
+	UpdateUserRole(id uint, role string) (models.User, error)
+	DeleteUserById(id uint) error
+}
+
+type PhotoRepository interface {
+	// CreatePhoto stores a photo of userID, tagged with the hashtags of its
+	// caption, file is zero for photos linked by URL. Uploaded photos start
+	// with their variants pending, linked ones with their link.
+	CreatePhoto(userID uint, photoDto *dto.Photo, file models.PhotoFile) (ID uint, err error)
+	// GetAllPhotos returns a page of photos with their users and where the
+	// next one starts.
+	GetAllPhotos(options ListOptions) ([]models.PhotoWithUser, *Cursor, error)
+	GetSinglePhoto(photoID uint) (models.Photo, error)
+	// GetPhotos returns the photos with photoIDs that exist, in no
+	// particular order.
+	GetPhotos(photoIDs []uint) ([]models.Photo, error)
+	GetPhotoWithUser(photoID uint) (models.PhotoWithUser, error)
+	// UpdatePhoto also drops the file and variants of the photo when it is
+	// pointed at another URL, and leaves the new link to be checked. The
+	// photo is tagged anew when its caption changes.
+	UpdatePhoto(photoID uint, photoDto *dto.Photo) (UpdatedAt time.Time, err error)
+	DeletePhoto(photoID uint) error
+	// GetPendingVariantPhotoIDs returns the photos whose variants are still
+	// to be made.
+	GetPendingVariantPhotoIDs() ([]uint, error)
+	GetPhotoVariants(photoID uint) ([]models.PhotoVariant, error)
+	// SavePhotoVariants replaces the variants of a pending photo and marks
+	// them ready. It returns ErrNotFound when the photo was deleted or its
+	// file replaced in the meantime.
+	SavePhotoVariants(photoID uint, variants []models.PhotoVariant) error
+	SetPhotoVariantsStatus(photoID uint, status string) error
+	// GetPhotoLinksToCheck returns the linked photos whose link is pending,
+	// and the broken ones last checked before brokenBefore.
+	GetPhotoLinksToCheck(brokenBefore time.Time) ([]uint, error)
+	// SetPhotoLink records a check of photoURL. It returns ErrNotFound when
+	// the photo was deleted or pointed elsewhere in the meantime.
+	SetPhotoLink(photoID uint, photoURL string, link models.PhotoLink) error
+	// IngestPhotoLink points a photo linked to photoURL at its copy, stored
+	// as file under copyURL, and leaves its variants to be made. It returns
+	// ErrNotFound like SetPhotoLink.
+	IngestPhotoLink(photoID uint, photoURL, copyURL string, file models.PhotoFile, checkedAt time.Time) error
+}
+
+type CommentRepository interface {
+	CreateComment(userID uint, commentDto *dto.Comment) (models.Comment, error)
+	GetAllComments(options ListOptions) ([]models.CommentWithUserAndPhoto, *Cursor, error)
+	GetSingleComment(commentID uint) (models.Comment, error)
+	GetCommentWithUserAndPhoto(commentID uint) (models.CommentWithUserAndPhoto, error)
+	UpdateComment(commentID uint, messageDto *dto.CommentMessage) (models.Comment, error)
+	DeleteComment(commentID uint) error
+}
+
+type SocialMediaRepository interface {
+	CreateSocialMedia(userID uint, socmedDto *dto.SocialMedia) (models.SocialMedia, error)
+	GetAllSocialMedias(options ListOptions) ([]models.SocialMediaWithUser, *Cursor, error)
+	GetSingleSocialMedia(socmedID uint) (models.SocialMedia, error)
+	GetSocialMediaWithUser(socmedID uint) (models.SocialMediaWithUser, error)
+	UpdateSocialMedia(socmedID uint, socmedDto *dto.SocialMedia) (UpdatedAt time.Time, err error)
+	DeleteSocialMedia(socmedID uint) error
+}
+
+type AlbumRepository interface {
+	CreateAlbum(userID uint, albumDto *dto.Album) (models.Album, error)
+	// GetAllAlbums returns a page of the public albums and those of
+	// viewerID, with their users and cover photos, and where the next one
+	// starts.
+	GetAllAlbums(viewerID uint, options ListOptions) ([]models.AlbumWithUser, *Cursor, error)
+	GetSingleAlbum(albumID uint) (models.Album, error)
+	GetAlbumWithUser(albumID uint) (models.AlbumWithUser, error)
+	// GetAlbumPhotos returns the photos of an album in their order, with
+	// their users and variants.
+	GetAlbumPhotos(albumID uint) ([]models.PhotoWithUser, error)
+	// UpdateAlbum keeps the visibility of the album when albumDto leaves it
+	// out.
+	UpdateAlbum(albumID uint, albumDto *dto.Album) (models.Album, error)
+	DeleteAlbum(albumID uint) error
+	// AddAlbumPhotos appends photos to the end of an album in the order of
+	// photoIDs, skipping the ones in it already. Like RemoveAlbumPhotos and
+	// MoveAlbumPhotos, it returns the IDs of the photos of the album in
+	// their new order.
+	AddAlbumPhotos(albumID uint, photoIDs []uint) ([]uint, error)
+	// RemoveAlbumPhotos ignores photos that are not in the album.
+	RemoveAlbumPhotos(albumID uint, photoIDs []uint) ([]uint, error)
+	// MoveAlbumPhotos moves photos of an album to position, see
+	// MoveAlbumPhotoIDs.
+	MoveAlbumPhotos(albumID uint, photoIDs []uint, position int) ([]uint, error)
+}
+
+// TagRepository counts the tags of photos, the photos of a tag are listed by
+// PhotoRepository.GetAllPhotos with ListOptions.Tag.
+type TagRepository interface {
+	// GetTags returns the limit tags starting with prefix on the most
+	// photos, with the number of photos they are on.
+	GetTags(prefix string, limit int) ([]models.TagCount, error)
+	// GetTrendingTags returns the limit tags put on the most photos since
+	// since, with the number of those photos.
+	GetTrendingTags(since time.Time, limit int) ([]models.TagCount, error)
+}
+
+type PhotoUploadRepository interface {
+	CreatePhotoUpload(upload *models.PhotoUpload) error
+	// GetPhotoUpload returns the upload with id if it belongs to userID.
+	GetPhotoUpload(userID uint, id string) (models.PhotoUpload, error)
+	// AddPhotoUploadChunk counts a chunk of length bytes received at offset
+	// and stored under key. It returns ErrUploadOffsetMismatch when another
+	// chunk was counted since the upload was read.
+	AddPhotoUploadChunk(id string, offset, length int64, key string) (models.PhotoUpload, error)
+	DeletePhotoUpload(id string) error
+	// DeleteExpiredPhotoUploads deletes the uploads that expired before now
+	// and returns them, so their chunks can be removed too.
+	DeleteExpiredPhotoUploads(now time.Time) ([]models.PhotoUpload, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(refreshToken *models.RefreshToken) error
+	// RotateRefreshToken marks the token with tokenHash as used and stores
+	// next in the same family. Presenting a token that was already used or
+	// revoked revokes its whole family and returns ErrRefreshTokenReused.
+	RotateRefreshToken(tokenHash string, next *models.RefreshToken) (models.RefreshToken, error)
+	// RevokeRefreshTokenFamily revokes the token with tokenHash and every
+	// token rotated from the same login, and returns the ID of the family.
+	RevokeRefreshTokenFamily(tokenHash string) (familyID string, err error)
+	RevokeRefreshTokensOfFamily(familyID string) error
+	RevokeUserRefreshTokens(userID uint) error
+}
+
+type PasswordResetRepository interface {
+	CreatePasswordResetToken(resetToken *models.PasswordResetToken) error
+	// UsePasswordResetToken marks the token with tokenHash as used, along
+	// with every other reset token of its user. Tokens that expired or were
+	// used already give ErrPasswordResetTokenExpired.
+	UsePasswordResetToken(tokenHash string) (models.PasswordResetToken, error)
+}
+
+type TwoFactorRepository interface {
+	GetTOTPCredential(userID uint) (models.TOTPCredential, error)
+	// SaveTOTPCredential stores a new unconfirmed credential, replacing the
+	// one its user had.
+	SaveTOTPCredential(credential *models.TOTPCredential) error
+	// ConfirmTOTP turns two-factor login on for userID, counter being the
+	// time step of the code that confirmed it, and replaces their recovery
+	// codes. It returns ErrNotFound when there is no unconfirmed credential.
+	ConfirmTOTP(userID uint, counter int64, recoveryCodeHashes []string) error
+	// UseTOTPCounter records that the code of time step counter was used and
+	// reports false when it or a later one was used already.
+	UseTOTPCounter(userID uint, counter int64) (bool, error)
+	// UseRecoveryCode marks the unused recovery code with codeHash used and
+	// reports whether there was one.
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+	DeleteTOTPCredential(userID uint) error
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(apiKey *models.APIKey) error
+	GetUserAPIKeys(userID uint) ([]models.APIKey, error)
+	GetAPIKeyByHash(keyHash string) (models.APIKey, error)
+	// TouchAPIKey records that the key was used at usedAt.
+	TouchAPIKey(id uint, usedAt time.Time) error
+	// DeleteUserAPIKey deletes the key with id if it belongs to userID and
+	// returns ErrNotFound otherwise.
+	DeleteUserAPIKey(userID, id uint) error
+}
+
+type IdentityRepository interface {
+	// CreateIdentity returns ErrDuplicate when the identity is linked to a
+	// user already.
+	CreateIdentity(identity *models.UserIdentity) error
+	GetIdentity(issuer, subject string) (models.UserIdentity, error)
+	GetUserIdentities(userID uint) ([]models.UserIdentity, error)
+	// DeleteUserIdentity deletes the identity with id if it belongs to
+	// userID and returns ErrNotFound otherwise.
+	DeleteUserIdentity(userID, id uint) error
+}
+
+// SessionRepository records the logins of users, see models.Session.
+type SessionRepository interface {
+	CreateSession(session *models.Session) error
+	GetSession(id uint) (models.Session, error)
+	GetSessionByFamily(familyID string) (models.Session, error)
+	// GetUserSessions returns the sessions of userID that are neither
+	// revoked nor expired, most recently seen first.
+	GetUserSessions(userID uint) ([]models.Session, error)
+	TouchSession(id uint, seenAt time.Time) error
+	// ExtendSession touches the session and moves its expiry to expiresAt,
+	// when its refresh token is rotated.
+	ExtendSession(id uint, seenAt, expiresAt time.Time) error
+	// RevokeSession revokes the session with id if it belongs to userID and
+	// is still active, and returns ErrNotFound otherwise.
+	RevokeSession(userID, id uint) (models.Session, error)
+	RevokeSessionByFamily(familyID string) error
+	RevokeUserSessions(userID uint) error
+}
+
+// LoginAttemptStore counts failed logins, see models.LoginAttempt.
+type LoginAttemptStore interface {
+	GetLoginAttempt(key string) (models.LoginAttempt, error)
+	// RecordLoginFailure counts a failure of key at at and returns the new
+	// count. Failures are forgotten when the last one is before
+	// forgetBefore.
+	RecordLoginFailure(key string, at, forgetBefore time.Time) (models.LoginAttempt, error)
+	ClearLoginFailures(key string) error
+}
+
+// RevocationStore tracks access tokens that must be rejected before they
+// expire.
+type RevocationStore interface {
+	RevokeToken(jti string, expiresAt time.Time) error
+	// ConsumeToken revokes the single use token jti and reports false when
+	// it was revoked already, so only one of the requests presenting it
+	// gets true.
+	ConsumeToken(jti string, expiresAt time.Time) (bool, error)
+	// RevokeUserTokens revokes every token issued to userID before before.
+	RevokeUserTokens(userID uint, before time.Time) error
+	IsTokenRevoked(jti string, userID uint, issuedAt time.Time) (bool, error)
+}
+
+// Repositories groups every repository the handlers depend on.
+type Repositories struct {
+	Users          UserRepository
+	Photos         PhotoRepository
+	PhotoUploads   PhotoUploadRepository
+	Comments       CommentRepository
+	SocialMedias   SocialMediaRepository
+	Albums         AlbumRepository
+	Tags           TagRepository
+	RefreshTokens  RefreshTokenRepository
+	PasswordResets PasswordResetRepository
+	TwoFactor      TwoFactorRepository
+	LoginAttempts  LoginAttemptStore
+	APIKeys        APIKeyRepository
+	Identities     IdentityRepository
+	Sessions       SessionRepository
+	Revocations    RevocationStore
+}
+
+// NewRepositories returns the GORM backed repositories for db.
+func NewRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:          &userRepository{db: db},
+		Photos:         &photoRepository{db: db},
+		PhotoUploads:   &photoUploadRepository{db: db},
+		Comments:       &commentRepository{db: db},
+		SocialMedias:   &socialMediaRepository{db: db},
+		Albums:         &albumRepository{db: db},
+		Tags:           &tagRepository{db: db},
+		RefreshTokens:  &refreshTokenRepository{db: db},
+		PasswordResets: &passwordResetRepository{db: db},
+		TwoFactor:      &twoFactorRepository{db: db},
+		LoginAttempts:  &loginAttemptStore{db: db},
+		APIKeys:        &apiKeyRepository{db: db},
+		Identities:     &identityRepository{db: db},
+		Sessions:       &sessionRepository{db: db},
+		Revocations:    &revocationStore{db: db},
+	}
+}
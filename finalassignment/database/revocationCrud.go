@@ -0,0 +1,63 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type revocationStore struct {
+	db *gorm.DB
+}
+
+func (r *revocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	// Expired rows can't match a valid token anymore, clear them out while
+	// we are here.
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+func (r *revocationStore) ConsumeToken(jti string, expiresAt time.Time) (bool, error) {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		return false, err
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+func (r *revocationStore) RevokeUserTokens(userID uint, before time.Time) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before"}),
+	}).Create(&models.UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: revokedBefore(before),
+	}).Error
+}
+func (r *revocationStore) IsTokenRevoked(jti string, userID uint, issuedAt time.Time) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	err := r.db.Model(&models.UserTokenRevocation{}).
+		Where("user_id = ? AND revoked_before > ?", userID, issuedAt).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// revokedBefore truncates t to the precision of the iat claim, which is also
+// the precision Postgres stores timestamps with.
+func revokedBefore(t time.Time) time.Time {
+	return t.Truncate(time.Microsecond)
+}
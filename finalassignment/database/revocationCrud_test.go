@@ -0,0 +1,62 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/database"
+)
+
+func expectRevoked(t *testing.T, repos database.Repositories, jti string, userID uint, issuedAt time.Time, want bool) {
+	t.Helper()
+	revoked, err := repos.Revocations.IsTokenRevoked(jti, userID, issuedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked != want {
+		t.Errorf("token %q of user %d issued at %v revoked = %v, want %v", jti, userID, issuedAt, revoked, want)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		user := createUser(t, repos, "alice")
+		now := time.Now()
+		if err := repos.Revocations.RevokeToken("revoked", now.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		// Revoking again is not an error.
+		if err := repos.Revocations.RevokeToken("revoked", now.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		expectRevoked(t, repos, "revoked", user.ID, now, true)
+		expectRevoked(t, repos, "other", user.ID, now, false)
+	})
+}
+
+// TestRevokeUserTokens checks the cutoff is compared as a time, to the
+// microsecond of the iat claim, on both stores.
+func TestRevokeUserTokens(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repos database.Repositories) {
+		alice := createUser(t, repos, "alice")
+		bob := createUser(t, repos, "bob")
+		cutoff := time.Now()
+		if err := repos.Revocations.RevokeUserTokens(alice.ID, cutoff); err != nil {
+			t.Fatal(err)
+		}
+		expectRevoked(t, repos, "a", alice.ID, cutoff.Add(-time.Hour), true)
+		expectRevoked(t, repos, "b", alice.ID, cutoff.Add(-time.Microsecond), true)
+		expectRevoked(t, repos, "c", alice.ID, cutoff.Truncate(time.Microsecond), false)
+		expectRevoked(t, repos, "d", alice.ID, cutoff.Add(time.Second), false)
+		expectRevoked(t, repos, "", alice.ID, cutoff.Add(-time.Hour), true)
+		expectRevoked(t, repos, "e", bob.ID, cutoff.Add(-time.Hour), false)
+
+		// A later cutoff replaces the first.
+		later := cutoff.Add(time.Minute)
+		if err := repos.Revocations.RevokeUserTokens(alice.ID, later); err != nil {
+			t.Fatal(err)
+		}
+		expectRevoked(t, repos, "d", alice.ID, cutoff.Add(time.Second), true)
+		expectRevoked(t, repos, "f", alice.ID, later.Add(time.Second), false)
+	})
+}
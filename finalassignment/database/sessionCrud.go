@@ -0,0 +1,64 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func (r *sessionRepository) CreateSession(session *models.Session) error {
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+	return r.db.Create(session).Error
+}
+func (r *sessionRepository) GetSession(id uint) (models.Session, error) {
+	session := models.Session{}
+	err := r.db.Take(&session, id).Error
+	return session, err
+}
+func (r *sessionRepository) GetSessionByFamily(familyID string) (models.Session, error) {
+	session := models.Session{}
+	err := r.db.Where("family_id = ?", familyID).Take(&session).Error
+	return session, err
+}
+func (r *sessionRepository) GetUserSessions(userID uint) ([]models.Session, error) {
+	sessions := []models.Session{}
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Order("id DESC").Find(&sessions).Error
+	return sessions, err
+}
+func (r *sessionRepository) TouchSession(id uint, seenAt time.Time) error {
+	return r.db.Model(&models.Session{}).Where("id = ?", id).Update("last_seen_at", seenAt).Error
+}
+func (r *sessionRepository) ExtendSession(id uint, seenAt, expiresAt time.Time) error {
+	return r.db.Model(&models.Session{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": seenAt, "expires_at": expiresAt, "updated_at": seenAt}).Error
+}
+func (r *sessionRepository) RevokeSession(userID, id uint) (models.Session, error) {
+	session := models.Session{}
+	err := r.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", id, userID, time.Now()).
+		Take(&session).Error
+	if err != nil {
+		return session, err
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	err = r.db.Model(&session).Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	return session, err
+}
+func (r *sessionRepository) RevokeSessionByFamily(familyID string) error {
+	return r.revokeWhere("family_id = ?", familyID)
+}
+func (r *sessionRepository) RevokeUserSessions(userID uint) error {
+	return r.revokeWhere("user_id = ?", userID)
+}
+func (r *sessionRepository) revokeWhere(query string, args ...interface{}) error {
+	now := time.Now()
+	return r.db.Model(&models.Session{}).Where(query, args...).Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+}
@@ -5,40 +5,36 @@ import (
 
 	"finalassignment.id/finalassignment/dto"
 	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
 )
 
-func UpdateSocialMedia(socmedID, userID uint, socmedDto *dto.SocialMedia) (UpdatedAt time.Time, err error) {
-	socmed, err := GetSingleSocialMedia(socmedID)
+type socialMediaRepository struct {
+	db *gorm.DB
+}
+
+func (r *socialMediaRepository) UpdateSocialMedia(socmedID uint, socmedDto *dto.SocialMedia) (UpdatedAt time.Time, err error) {
+	socmed, err := r.GetSingleSocialMedia(socmedID)
 	if err != nil {
 		return
 	}
-	if socmed.UserID != userID {
-		err = ErrIllegalUpdate
-		return
-	}
 	socmed.Name = socmedDto.Name
 	socmed.SocialMediaUrl = socmedDto.SocialMediaUrl
 	socmed.UpdatedAt = time.Now()
-	err = db.Save(&socmed).Error
+	err = r.db.Save(&socmed).Error
+	UpdatedAt = socmed.UpdatedAt
 	return
 }
-func DeleteSocialMedia(socmedID, userID uint) error {
-	socmed, err := GetSingleSocialMedia(socmedID)
+func (r *socialMediaRepository) DeleteSocialMedia(socmedID uint) error {
+	socmed, err := r.GetSingleSocialMedia(socmedID)
 	if err != nil {
 		return err
 	}
-	if socmed.UserID != userID {
-		return ErrIllegalUpdate
-	}
-	if err := db.Delete(&socmed, socmedID).Error; err != nil {
+	if err := r.db.Delete(&socmed, socmedID).Error; err != nil {
 		return err
 	}
 	return nil
 }
-func CreateSocialMedia(userID uint, socmedDto *dto.SocialMedia) (models.SocialMedia, error) {
-	if db == nil {
-		return models.SocialMedia{}, ErrDbNotStarted
-	}
+func (r *socialMediaRepository) CreateSocialMedia(userID uint, socmedDto *dto.SocialMedia) (models.SocialMedia, error) {
 	newSocmed := models.SocialMedia{
 		UserID:         userID,
 		Name:           socmedDto.Name,
@@ -48,23 +44,26 @@ func CreateSocialMedia(userID uint, socmedDto *dto.SocialMedia) (models.SocialMe
 			UpdatedAt: time.Now(),
 		},
 	}
-	if err := db.Create(&newSocmed).Error; err != nil {
+	if err := r.db.Create(&newSocmed).Error; err != nil {
 		return models.SocialMedia{}, err
 	}
 	return newSocmed, nil
 }
-func GetAllSocialMedias() ([]models.SocialMedia, error) {
-	socmeds := make([]models.SocialMedia, 1)
-	if err := db.Model(&models.SocialMedia{}).Find(&socmeds).Error; err != nil {
-		return nil, err
+func (r *socialMediaRepository) GetAllSocialMedias(options ListOptions) ([]models.SocialMediaWithUser, *Cursor, error) {
+	socmeds := []models.SocialMediaWithUser{}
+	if err := preloadUser(options.apply(r.db.Model(&models.SocialMediaWithUser{}))).Find(&socmeds).Error; err != nil {
+		return nil, nil, err
 	}
-	return socmeds, nil
+	socmeds, next := NextPage(socmeds, options, func(socmed models.SocialMediaWithUser) models.Model { return socmed.Model })
+	return socmeds, next, nil
 }
-func GetSingleSocialMedia(socmedID uint) (models.SocialMedia, error) {
+func (r *socialMediaRepository) GetSingleSocialMedia(socmedID uint) (models.SocialMedia, error) {
 	socmed := models.SocialMedia{}
-	if db == nil {
-		return socmed, ErrDbNotStarted
-	}
-	err := db.Model(&models.SocialMedia{}).Take(&socmed, socmedID).Error
+	err := r.db.Model(&models.SocialMedia{}).Take(&socmed, socmedID).Error
+	return socmed, err
+}
+func (r *socialMediaRepository) GetSocialMediaWithUser(socmedID uint) (models.SocialMediaWithUser, error) {
+	socmed := models.SocialMediaWithUser{}
+	err := preloadUser(r.db.Model(&models.SocialMediaWithUser{})).Take(&socmed, socmedID).Error
 	return socmed, err
 }
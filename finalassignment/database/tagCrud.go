@@ -0,0 +1,95 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func (r *tagRepository) GetTags(prefix string, limit int) ([]models.TagCount, error) {
+	query := r.db.Model(&models.Tag{}).Joins("JOIN photo_tags ON photo_tags.tag_id = tags.id")
+	if prefix != "" {
+		query = query.Where(`tags.name LIKE ? ESCAPE '\'`, escapeLike(prefix)+"%")
+	}
+	return countTags(query, limit)
+}
+func (r *tagRepository) GetTrendingTags(since time.Time, limit int) ([]models.TagCount, error) {
+	// SQLite compares timestamps as text, see ListOptions.apply.
+	query := r.db.Model(&models.Tag{}).Joins("JOIN photo_tags ON photo_tags.tag_id = tags.id").
+		Where("photo_tags.created_at >= ?", since.Local())
+	return countTags(query, limit)
+}
+
+// countTags counts the photo_tags rows query joined to the tags, and
+// returns the limit most counted tags, most counted first.
+func countTags(query *gorm.DB, limit int) ([]models.TagCount, error) {
+	counts := []models.TagCount{}
+	err := query.Select("tags.name, COUNT(*) AS photo_count").Group("tags.id, tags.name").
+		Order("photo_count DESC").Order("tags.name").Limit(limit).Scan(&counts).Error
+	return counts, err
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with a backslash.
+func escapeLike(pattern string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(pattern)
+}
+
+// tagPhotos tags the photos posted before tags existed with the hashtags of
+// their captions, when the tags tables are created.
+func tagPhotos(tx *gorm.DB) error {
+	photos := []models.Photo{}
+	return tx.Select("id", "caption").Where("caption LIKE ?", "%#%").
+		FindInBatches(&photos, 100, func(*gorm.DB, int) error {
+			for _, photo := range photos {
+				if err := setPhotoTags(tx, photo.ID, photo.Caption); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// setPhotoTags tags a photo with the hashtags of its caption. Tags the
+// photo already had keep when they were first used on it.
+func setPhotoTags(tx *gorm.DB, photoID uint, caption string) error {
+	names := ParseHashtags(caption)
+	tags := []models.Tag{}
+	if len(names) > 0 {
+		for _, name := range names {
+			tags = append(tags, models.Tag{Name: name, CreatedAt: time.Now()})
+		}
+		err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags).Error
+		if err != nil {
+			return err
+		}
+		// Tags that existed already were not returned by the insert.
+		tags = []models.Tag{}
+		if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+	untag := tx.Where("photo_id = ?", photoID)
+	photoTags := []models.PhotoTag{}
+	if len(tags) > 0 {
+		tagIDs := make([]uint, len(tags))
+		for i, tag := range tags {
+			tagIDs[i] = tag.ID
+			photoTags = append(photoTags, models.PhotoTag{PhotoID: photoID, TagID: tag.ID, CreatedAt: time.Now()})
+		}
+		untag = untag.Where("tag_id NOT IN ?", tagIDs)
+	}
+	if err := untag.Delete(&models.PhotoTag{}).Error; err != nil {
+		return err
+	}
+	if len(photoTags) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&photoTags).Error
+}
@@ -0,0 +1,52 @@
+package database_test
+
+import (
+	"reflect"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/dto"
+	"finalassignment.id/finalassignment/models"
+)
+
+// TestMigrationTagsPhotos checks the migration creating the tags tables
+// tags the photos posted before them.
+func TestMigrationTagsPhotos(t *testing.T) {
+	db := openSQLite(t)
+	repos := database.NewRepositories(db)
+	user := models.User{Username: "owner", Email: "owner@example.com", Password: "x", Age: 20, Role: models.RoleUser}
+	if err := repos.Users.CreateUser(&user); err != nil {
+		t.Fatal(err)
+	}
+	for _, caption := range []string{"#Sunset at the #beach", "no tags", "#sunset again", "100% #"} {
+		photo := dto.Photo{Title: "title", Caption: caption, PhotoUrl: "https://example.com/a.jpg"}
+		if _, err := repos.Photos.CreatePhoto(user.ID, &photo, models.PhotoFile{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Rolling back the tags tables leaves the photos as if they were posted
+	// before tags existed.
+	migrator, err := database.NewMigrator(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rolledBack, err := migrator.Down(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolledBack) != 1 || rolledBack[0].Name != "create_tags" {
+		t.Fatalf("rolled back %+v, want create_tags", rolledBack)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := repos.Tags.GetTags("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.TagCount{{Name: "sunset", PhotoCount: 2}, {Name: "beach", PhotoCount: 1}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %+v, want %+v", tags, want)
+	}
+}
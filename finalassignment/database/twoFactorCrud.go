@@ -0,0 +1,76 @@
+package database
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func (r *twoFactorRepository) GetTOTPCredential(userID uint) (models.TOTPCredential, error) {
+	credential := models.TOTPCredential{}
+	err := r.db.Where("user_id = ?", userID).Take(&credential).Error
+	return credential, err
+}
+func (r *twoFactorRepository) SaveTOTPCredential(credential *models.TOTPCredential) error {
+	credential.CreatedAt = time.Now()
+	credential.UpdatedAt = time.Now()
+	credential.ConfirmedAt = nil
+	credential.LastCounter = 0
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"created_at", "updated_at", "secret", "confirmed_at", "last_counter"}),
+	}).Create(credential).Error
+}
+func (r *twoFactorRepository) ConfirmTOTP(userID uint, counter int64, recoveryCodeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.TOTPCredential{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{"confirmed_at": now, "last_counter": counter, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(recoveryCodeHashes))
+		for i, hash := range recoveryCodeHashes {
+			codes[i] = models.RecoveryCode{
+				UserID:   userID,
+				CodeHash: hash,
+				Model:    models.Model{CreatedAt: now, UpdatedAt: now},
+			}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+func (r *twoFactorRepository) UseTOTPCounter(userID uint, counter int64) (bool, error) {
+	result := r.db.Model(&models.TOTPCredential{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_counter < ?", userID, counter).
+		Updates(map[string]interface{}{"last_counter": counter, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
+func (r *twoFactorRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Updates(map[string]interface{}{"used_at": now, "updated_at": now})
+	return result.RowsAffected == 1, result.Error
+}
+func (r *twoFactorRepository) DeleteTOTPCredential(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TOTPCredential{}).Error
+	})
+}
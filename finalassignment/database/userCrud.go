@@ -5,105 +5,98 @@ import (
 
 	"finalassignment.id/finalassignment/dto"
 	"finalassignment.id/finalassignment/models"
-	"finalassignment.id/finalassignment/utils/token"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-var ErrPasswordMismatch = bcrypt.ErrMismatchedHashAndPassword
-
-func GenerateToken(userDto dto.UserLogin) (jwt string, err error) {
-	user, err := getUserByEmail(userDto.Email)
-	if err != nil {
-		return
-	}
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userDto.Password))
-	if err != nil {
-		return
-	}
-	jwt, err = token.GenerateToken(user.ID)
-	return
+type userRepository struct {
+	db *gorm.DB
 }
-func DeleteUserById(id uint) error {
-	user, err := GetUserWithoutPreload(id)
+
+func (r *userRepository) DeleteUserById(id uint) error {
+	user, err := r.GetUserWithoutPreload(id)
 	if err != nil {
 		return err
 	}
-	if err := db.Delete(&user, id).Error; err != nil {
+	if err := r.db.Delete(&user, id).Error; err != nil {
 		return err
 	}
 	return nil
 }
-func getUserByEmail(email string) (models.User, error) {
+func (r *userRepository) GetUserByEmail(email string) (models.User, error) {
 	user := models.User{}
-	if db == nil {
-		return user, ErrDbNotStarted
-	}
-	err := db.Model(&models.User{}).Where("email = ?", email).Take(&user).Error
+	err := r.db.Model(&models.User{}).Where("email = ?", email).Take(&user).Error
 	if err != nil {
 		return user, err
 	}
 	return user, nil
 }
-func GetUsernameAndEmail(id uint) (dto.UserUpdate, error) {
-	userDto := dto.UserUpdate{}
-	if db == nil {
-		return userDto, ErrDbNotStarted
+func (r *userRepository) CreateUser(user *models.User) error {
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	return translateError(r.db.Create(user).Error)
+}
+func (r *userRepository) UpdateUser(id uint, userDto *dto.UserUpdate) (models.User, error) {
+	user, err := r.GetUserWithoutPreload(id)
+	if err != nil {
+		return user, err
 	}
-	user := models.User{}
-	if err := db.Select("username", "email").Take(&user, id).Error; err != nil {
-		return userDto, err
+	if userDto.Email != "" && userDto.Email != user.Email {
+		user.Email = userDto.Email
+		user.EmailVerifiedAt = nil
+		user.VerificationSentAt = nil
 	}
-	userDto.Username = user.Username
-	userDto.Email = user.Email
-	return userDto, nil
-}
-func CreateUser(userRegister *dto.UserRegister) (ID uint, err error) {
-	if db == nil {
-		err = ErrDbNotStarted
-		return
+	if userDto.Username != "" {
+		user.Username = userDto.Username
 	}
-	passwordBytes, err := bcrypt.GenerateFromPassword([]byte(userRegister.Password), 4)
-	if err != nil {
-		return
+	user.UpdatedAt = time.Now()
+	err = translateError(r.db.Save(&user).Error)
+	return user, err
+}
+func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"password": passwordHash, "updated_at": time.Now()})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
 	}
-	newUser := models.User{
-		Username: userRegister.Username,
-		Email:    userRegister.Email,
-		Password: string(passwordBytes),
-		Age:      userRegister.Age,
-		Model: models.Model{
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		},
+	return result.Error
+}
+func (r *userRepository) VerifyEmail(id uint, verifiedAt time.Time) error {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email_verified_at": verifiedAt, "updated_at": time.Now()})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
 	}
-	err = db.Create(&newUser).Error
+	return result.Error
+}
+func (r *userRepository) ReserveVerificationEmail(id uint, sentAt, since time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", id, since).
+		Update("verification_sent_at", sentAt)
+	return result.RowsAffected == 1, result.Error
+}
+func (r *userRepository) SuspendUser(id uint, suspendedAt *time.Time) (models.User, error) {
+	user, err := r.GetUserWithoutPreload(id)
 	if err != nil {
-		return
+		return user, err
 	}
-	ID = newUser.ID
-	return
+	user.SuspendedAt = suspendedAt
+	user.UpdatedAt = time.Now()
+	err = r.db.Save(&user).Error
+	return user, err
 }
-func UpdateUser(id uint, userDto *dto.UserUpdate) (models.User, error) {
-	user, err := GetUserWithoutPreload(id)
+func (r *userRepository) UpdateUserRole(id uint, role string) (models.User, error) {
+	user, err := r.GetUserWithoutPreload(id)
 	if err != nil {
 		return user, err
 	}
-	if userDto.Email != "" {
-		user.Email = userDto.Email
-	}
-	if userDto.Username != "" {
-		user.Username = userDto.Username
-	}
+	user.Role = role
 	user.UpdatedAt = time.Now()
-	err = db.Save(&user).Error
+	err = r.db.Save(&user).Error
 	return user, err
 }
-func GetUserWithoutPreload(id uint) (models.User, error) {
+func (r *userRepository) GetUserWithoutPreload(id uint) (models.User, error) {
 	user := models.User{}
-	if db == nil {
-		return user, ErrDbNotStarted
-	}
-	err := db.Model(&models.User{}).Take(&user, id).Error
+	err := r.db.Model(&models.User{}).Take(&user, id).Error
 	if err != nil {
 		return user, err
 	}
@@ -23,52 +23,37 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/comments": {
+        "/.well-known/jwks.json": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get comments.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Public keys other services can verify our RS256 and EdDSA access tokens with. Tokens name their key in the kid header. HMAC keys are never published.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "keys"
                 ],
-                "summary": "Get comments",
+                "summary": "Get the JSON Web Key Set",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/responses.GetComment"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorMessage"
+                            "$ref": "#/definitions/token.JWKS"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error"
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/albums/{albumId}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a Comment associated with the logged in user.",
+                "description": "Delete an album associated with logged in user, its photos are kept. Moderators can delete any album.",
                 "consumes": [
                     "application/json"
                 ],
@@ -76,25 +61,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "albums"
                 ],
-                "summary": "Create a Comment",
+                "summary": "Delete an album",
                 "parameters": [
                     {
-                        "description": "JSON of the comment to be made. Caption is not mandatory.",
-                        "name": "comment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.Comment"
-                        }
+                        "type": "integer",
+                        "description": "ID number of the album to be deleted",
+                        "name": "albumId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.CreateComment"
+                            "$ref": "#/definitions/responses.Message"
                         }
                     },
                     "400": {
@@ -103,20 +86,35 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/comments/{commentId}": {
-            "put": {
+        "/admin/comments/{commentId}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a comment associated with logged in user.",
+                "description": "Delete a comment associated with logged in user. Moderators can delete any comment. A comment with replies is kept without its message and user until they are deleted too.",
                 "consumes": [
                     "application/json"
                 ],
@@ -126,30 +124,21 @@ const docTemplate = `{
                 "tags": [
                     "comments"
                 ],
-                "summary": "Update a comment",
+                "summary": "Delete a comment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID number of the comment",
+                        "description": "ID number of the comment to be deleted",
                         "name": "commentId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "New JSON of the comment.",
-                        "name": "comment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CommentMessage"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Comment"
+                            "$ref": "#/definitions/responses.Message"
                         }
                     },
                     "400": {
@@ -174,14 +163,19 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            },
+            }
+        },
+        "/admin/photos/{photoId}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a comment associated with logged in user.",
+                "description": "Delete a photo associated with logged in user. Moderators can delete any photo.",
                 "consumes": [
                     "application/json"
                 ],
@@ -189,14 +183,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "photos"
                 ],
-                "summary": "Delete a comment",
+                "summary": "Delete a photo",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID number of the comment to be deleted",
-                        "name": "commentId",
+                        "description": "ID number of the photo to be deleted",
+                        "name": "photoId",
                         "in": "path",
                         "required": true
                     }
@@ -232,14 +226,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/photos": {
-            "get": {
+        "/admin/socialmedias/{socialMediaId}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get photos.",
+                "description": "Delete a social media associated with logged in user. Moderators can delete any social media.",
                 "consumes": [
                     "application/json"
                 ],
@@ -247,17 +244,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "photos"
+                    "socialMedias"
+                ],
+                "summary": "Delete a social media",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the social media to be deleted",
+                        "name": "socialMediaId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get photos",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/responses.GetPhoto"
-                            }
+                            "$ref": "#/definitions/responses.Message"
                         }
                     },
                     "400": {
@@ -266,18 +269,32 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/users/{userId}/role": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a Photo associated with the logged in user identified by bearer token.",
+                "description": "Change the role of a user to user, moderator or admin. Every token issued to them is revoked so the new role applies from their next login. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -285,25 +302,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "photos"
+                    "admin"
                 ],
-                "summary": "Create a Photo",
+                "summary": "Change the role of a user",
                 "parameters": [
                     {
-                        "description": "JSON of the photo to be made. Caption is not mandatory.",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "ID number of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The new role.",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Photo"
+                            "$ref": "#/definitions/dto.UserRole"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.CreatePhoto"
+                            "$ref": "#/definitions/responses.Message"
                         }
                     },
                     "400": {
@@ -312,20 +336,32 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/photos/{photoId}": {
-            "put": {
+        "/admin/users/{userId}/suspend": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a photo associated with logged in user.",
+                "description": "Suspend a user and revoke every token issued to them. Suspended users cannot login until the suspension is lifted. Moderators can only suspend users with a less privileged role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -333,32 +369,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "photos"
+                    "admin"
                 ],
-                "summary": "Update a photo",
+                "summary": "Suspend a user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID number of the photo",
-                        "name": "photoId",
+                        "description": "ID number of the user to be suspended",
+                        "name": "userId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "New JSON of the photo.",
-                        "name": "photo",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.Photo"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.UpdatePhoto"
+                            "$ref": "#/definitions/responses.Message"
                         }
                     },
                     "400": {
@@ -390,7 +417,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a photo associated with logged in user.",
+                "description": "Lift the suspension of a user so they can login again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -398,14 +425,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "photos"
+                    "admin"
                 ],
-                "summary": "Delete a photo",
+                "summary": "Lift the suspension of a user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID number of the photo to be deleted",
-                        "name": "photoId",
+                        "description": "ID number of the suspended user",
+                        "name": "userId",
                         "in": "path",
                         "required": true
                     }
@@ -441,14 +468,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/socialmedias": {
+        "/albums": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get social medias.",
+                "description": "Get a page of the public albums and those of the logged in user, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.",
                 "consumes": [
                     "application/json"
                 ],
@@ -456,14 +486,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "socialMedias"
+                    "albums"
+                ],
+                "summary": "Get albums",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor is the X-Next-Cursor header of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 20,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at"
+                        ],
+                        "type": "string",
+                        "example": "-created_at",
+                        "description": "Sort is created_at or updated_at, descending when prefixed with -.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "user_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get social medias",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.GetAllSocialMedias"
+                            "$ref": "#/definitions/responses.GetAllAlbums"
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, left out on the last page"
+                            }
                         }
                     },
                     "400": {
@@ -481,9 +564,12 @@ const docTemplate = `{
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a social media associated with the logged in user.",
+                "description": "Create an album associated with the logged in user. The cover photo has to be one of their photos. Users have to verify their email first, unless verification.required is off.",
                 "consumes": [
                     "application/json"
                 ],
@@ -491,17 +577,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "socialMedias"
+                    "albums"
                 ],
-                "summary": "Create a social media",
+                "summary": "Create an album",
                 "parameters": [
                     {
-                        "description": "JSON of the social media to be made.",
-                        "name": "socialMedia",
+                        "description": "JSON of the album to be made.",
+                        "name": "album",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.SocialMedia"
+                            "$ref": "#/definitions/dto.Album"
                         }
                     }
                 ],
@@ -509,7 +595,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/responses.CreateSocialMedia"
+                            "$ref": "#/definitions/responses.CreateAlbum"
                         }
                     },
                     "400": {
@@ -518,20 +604,35 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/socialmedias/{socialMediaId}": {
-            "put": {
+        "/albums/{albumId}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a social media associated with logged in user.",
+                "description": "Get an album along with its user and its photos in their order. Private albums are only found by their user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -539,32 +640,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "socialMedias"
+                    "albums"
                 ],
-                "summary": "Update a social media",
+                "summary": "Get an album",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID number of the social media",
-                        "name": "socialMediaId",
+                        "description": "ID number of the album",
+                        "name": "albumId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "New JSON of the social media.",
-                        "name": "socialMedia",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.SocialMedia"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.UpdateSocialMedia"
+                            "$ref": "#/definitions/responses.GetAlbum"
                         }
                     },
                     "400": {
@@ -573,12 +665,74 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorMessage"
-                        }
-                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update an album associated with logged in user. The visibility is kept when left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Update an album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the album",
+                        "name": "albumId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New JSON of the album.",
+                        "name": "album",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Album"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UpdateAlbum"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -594,9 +748,12 @@ const docTemplate = `{
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a social media associated with logged in user.",
+                "description": "Delete an album associated with logged in user, its photos are kept. Moderators can delete any album.",
                 "consumes": [
                     "application/json"
                 ],
@@ -604,14 +761,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "socialMedias"
+                    "albums"
                 ],
-                "summary": "Delete a social media",
+                "summary": "Delete an album",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID number of the social media to be deleted",
-                        "name": "socialMediaId",
+                        "description": "ID number of the album to be deleted",
+                        "name": "albumId",
                         "in": "path",
                         "required": true
                     }
@@ -647,14 +804,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
-            "put": {
+        "/albums/{albumId}/photos": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "update logged in user identified by their bearer token.",
+                "description": "Add photos of the logged in user to the end of their album, in the order given. Photos in the album already stay where they are.",
                 "consumes": [
                     "application/json"
                 ],
@@ -662,17 +822,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "albums"
                 ],
-                "summary": "Update logged in user",
+                "summary": "Add photos to an album",
                 "parameters": [
                     {
-                        "description": "New email and new username of the logged in user. Leave one of it empty if you want it to stay the same.",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "ID number of the album",
+                        "name": "albumId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "IDs of the photos to be added.",
+                        "name": "photos",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserUpdate"
+                            "$ref": "#/definitions/dto.AlbumPhotos"
                         }
                     }
                 ],
@@ -680,7 +847,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.UserUpdate"
+                            "$ref": "#/definitions/responses.AlbumPhotos"
                         }
                     },
                     "400": {
@@ -689,6 +856,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
@@ -698,9 +877,12 @@ const docTemplate = `{
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete logged in user identified by their bearer token.",
+                "description": "Remove photos from an album of the logged in user. The photos themselves are kept, and the ones not in the album are ignored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -708,14 +890,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "albums"
+                ],
+                "summary": "Remove photos from an album",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the album",
+                        "name": "albumId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "IDs of the photos to be removed.",
+                        "name": "photos",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AlbumPhotos"
+                        }
+                    }
                 ],
-                "summary": "Delete logged in user",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.Message"
+                            "$ref": "#/definitions/responses.AlbumPhotos"
                         }
                     },
                     "400": {
@@ -724,15 +924,35 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/users/login": {
-            "post": {
-                "description": "Login a user.",
+        "/albums/{albumId}/photos/order": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move photos of an album of the logged in user, in the order given, to a position counting from 1. The other photos keep their order around them, and positions past the end move the photos to the end.",
                 "consumes": [
                     "application/json"
                 ],
@@ -740,17 +960,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "albums"
                 ],
-                "summary": "Login a user",
+                "summary": "Reorder the photos of an album",
                 "parameters": [
                     {
-                        "description": "JSON of the user to login. Minimum password length is 6.",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "ID number of the album",
+                        "name": "albumId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "IDs of the photos to be moved and where to.",
+                        "name": "order",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserLogin"
+                            "$ref": "#/definitions/dto.AlbumPhotoOrder"
                         }
                     }
                 ],
@@ -758,7 +985,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.UserLogin"
+                            "$ref": "#/definitions/responses.AlbumPhotos"
                         }
                     },
                     "400": {
@@ -767,15 +994,135 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/users/register": {
+        "/comments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of comments, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor is the X-Next-Cursor header of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 20,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "photo_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at"
+                        ],
+                        "type": "string",
+                        "example": "-created_at",
+                        "description": "Sort is created_at or updated_at, descending when prefixed with -.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.GetComment"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, left out on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
             "post": {
-                "description": "Register a new user.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a Comment associated with the logged in user, or a reply to another comment on the same photo with parent_id. Users have to verify their email first, unless verification.required is off.",
                 "consumes": [
                     "application/json"
                 ],
@@ -783,17 +1130,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "comments"
                 ],
-                "summary": "Register a new user",
+                "summary": "Create a Comment",
                 "parameters": [
                     {
-                        "description": "JSON of the user to be made. Minimum age is 9. Minimum password length is 6",
-                        "name": "user",
+                        "description": "JSON of the comment to be made. Caption is not mandatory.",
+                        "name": "comment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserRegister"
+                            "$ref": "#/definitions/dto.Comment"
                         }
                     }
                 ],
@@ -801,17 +1148,23 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/responses.UserRegister"
+                            "$ref": "#/definitions/responses.CreateComment"
                         }
                     },
-                    "209": {
-                        "description": "",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/responses.Message"
+                            "$ref": "#/definitions/responses.ErrorMessage"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorMessage"
                         }
@@ -821,137 +1174,3522 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.Comment": {
+        },
+        "/comments/{commentId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a comment along with its user and the photo it is on.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the comment",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetComment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a comment associated with logged in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Update a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the comment",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New JSON of the comment.",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CommentMessage"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a comment associated with logged in user. Moderators can delete any comment. A comment with replies is kept without its message and user until they are deleted too.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Delete a comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the comment to be deleted",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/photos": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of photos, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Get photos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor is the X-Next-Cursor header of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 20,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at"
+                        ],
+                        "type": "string",
+                        "example": "-created_at",
+                        "description": "Sort is created_at or updated_at, descending when prefixed with -.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.GetPhoto"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, left out on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a Photo associated with the logged in user identified by bearer token. Users have to verify their email first, unless verification.required is off.\nEither link an image by photo_url, name a finished resumable upload by upload_id, or send the image itself as the photo field of a multipart/form-data body along with title and caption.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Create a Photo",
+                "parameters": [
+                    {
+                        "description": "JSON of the photo to be made. Caption is not mandatory.",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Photo"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CreatePhoto"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/photos/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start uploading a photo of the given size in chunks. Send the chunks in order with PATCH /photos/uploads/{uploadId}, then create the photo with its upload_id. Unfinished uploads expire after storage.upload_ttl.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Start a resumable photo upload",
+                "parameters": [
+                    {
+                        "description": "Size of the whole photo in bytes.",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PhotoUploadStart"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PhotoUpload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/photos/uploads/{uploadId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get how far an upload of the logged in user has got, to resume it after a broken connection.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Get a resumable photo upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the upload",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PhotoUpload"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Append the request body to an upload. Upload-Offset has to be the number of bytes received so far; if it is not, nothing is stored and the current offset is sent back in the Upload-Offset header.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Upload a chunk of a photo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the upload",
+                        "name": "uploadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of the chunk in the photo",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Bytes of the chunk",
+                        "name": "chunk",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PhotoUpload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "411": {
+                        "description": "Length Required",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/photos/{photoId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a photo along with the user who posted it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Get a photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the photo",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetPhoto"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a photo associated with logged in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Update a photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the photo",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New JSON of the photo.",
+                        "name": "photo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Photo"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UpdatePhoto"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a photo associated with logged in user. Moderators can delete any photo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Delete a photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the photo to be deleted",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/photos/{photoId}/comments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of the top-level comments of a photo, or of the replies to one of them with parent_id, oldest first unless sorted otherwise. Comments deleted while they had replies are kept without their message and user, with deleted_at set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get the comments of a photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the photo",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor is the X-Next-Cursor header of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 20,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ParentID lists the replies to a comment instead of the top-level\ncomments.",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at"
+                        ],
+                        "type": "string",
+                        "example": "-created_at",
+                        "description": "Sort is created_at or updated_at, descending when prefixed with -.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.GetComment"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, left out on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a comment on a photo associated with the logged in user, or a reply to another comment on it with parent_id. Users have to verify their email first, unless verification.required is off.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Comment on a photo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the photo",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON of the comment to be made.",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PhotoComment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CreateComment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/socialmedias": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of social medias, oldest first unless sorted otherwise. Pass the X-Next-Cursor header of a page as cursor, along with the same filters, to get the next one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "socialMedias"
+                ],
+                "summary": "Get social medias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor is the X-Next-Cursor header of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 20,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at"
+                        ],
+                        "type": "string",
+                        "example": "-created_at",
+                        "description": "Sort is created_at or updated_at, descending when prefixed with -.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetAllSocialMedias"
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, left out on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a social media associated with the logged in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "socialMedias"
+                ],
+                "summary": "Create a social media",
+                "parameters": [
+                    {
+                        "description": "JSON of the social media to be made.",
+                        "name": "socialMedia",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SocialMedia"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CreateSocialMedia"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/socialmedias/{socialMediaId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a social media along with its user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "socialMedias"
+                ],
+                "summary": "Get a social media",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the social media",
+                        "name": "socialMediaId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetSocialMedia"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a social media associated with logged in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "socialMedias"
+                ],
+                "summary": "Update a social media",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the social media",
+                        "name": "socialMediaId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New JSON of the social media.",
+                        "name": "socialMedia",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SocialMedia"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UpdateSocialMedia"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a social media associated with logged in user. Moderators can delete any social media.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "socialMedias"
+                ],
+                "summary": "Delete a social media",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the social media to be deleted",
+                        "name": "socialMediaId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the tags starting with a prefix, with or without the #, on the most photos first, to complete hashtags as they are typed. Without a prefix the most used tags are listed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get tags",
+                "parameters": [
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 65,
+                        "type": "string",
+                        "example": "sun",
+                        "description": "Prefix is the start of the tags, with or without the #.",
+                        "name": "prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetAllTags"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tags/trending": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the tags put on the most photos within a window of time up to now, along with the number of those photos. A tag counts for a photo from when it first appeared in the caption of the photo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get trending tags",
+                "parameters": [
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "24h",
+                        "description": "Window is how far back photos are counted, 24h unless given, up to\n720h.",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetAllTags"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tags/{name}/photos": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a page of the photos with a hashtag in their caption, oldest first unless sorted otherwise. The name is matched regardless of case, with or without the #.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get the photos of a tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the tag",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2019-11-09T21:21:46+00:00",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor is the X-Next-Cursor header of the previous page.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 20,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "updated_at",
+                            "-updated_at"
+                        ],
+                        "type": "string",
+                        "example": "-created_at",
+                        "description": "Sort is created_at or updated_at, descending when prefixed with -.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.GetPhoto"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, left out on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/uploads/{key}": {
+            "get": {
+                "description": "Get the image of a photo that was uploaded instead of linked. Its photo_url points here unless storage.public_url is set elsewhere.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
+                ],
+                "tags": [
+                    "photos"
+                ],
+                "summary": "Get an uploaded photo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Storage key of the photo, such as photos/0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11.jpg",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "update logged in user identified by their bearer token. Changing the email marks it unverified and sends a verification link to the new address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update logged in user",
+                "parameters": [
+                    {
+                        "description": "New email and new username of the logged in user. Leave one of it empty if you want it to stay the same.",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserUpdate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete logged in user identified by their bearer token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete logged in user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/2fa": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn two-factor login off for the logged in user. A code from the authenticator app or a recovery code is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Disable two-factor login",
+                "parameters": [
+                    {
+                        "description": "Code from the authenticator app or a recovery code.",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TwoFactorCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn two-factor login on with a code from the authenticator app enrolled by /users/2fa/enroll. The response holds recovery codes that can each be used once instead of a code; they are not shown again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Enable two-factor login",
+                "parameters": [
+                    {
+                        "description": "Code from the authenticator app.",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TwoFactorCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a new TOTP secret for the logged in user. Add the otpauth URI to an authenticator app, then send a code from it to /users/2fa/confirm to turn two-factor login on.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Start enabling two-factor login",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.TwoFactorEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/apikeys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the API keys of the logged in user. The keys themselves are not shown, only their prefix.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apiKeys"
+                ],
+                "summary": "Get API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.APIKey"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an API key for the logged in user. Scripts send it as \"Authorization: ApiKey \u003ckey\u003e\" to act as the user on photos, comments and social medias, within the scopes of the key. The key is only shown in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apiKeys"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "Name and scopes of the key.",
+                        "name": "apiKey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.APIKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CreateAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/apikeys/{apiKeyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke an API key of the logged in user. Requests using it are rejected from now on.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apiKeys"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the API key",
+                        "name": "apiKeyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/identities": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the identities at the OpenID Connect provider linked to the logged in user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get linked identities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.Identity"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/identities/{identityId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unlink an identity from the logged in user. Users without a password can't unlink their last identity, as they could not login anymore.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unlink an identity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the identity",
+                        "name": "identityId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "description": "Login a user. Returns a short lived access token and a refresh token to renew it with /users/refresh. Users with two-factor authentication enabled get a challenge token instead, to be sent to /users/login/2fa along with a code. Repeated failures lock the account and the client IP out for a while.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Login a user",
+                "parameters": [
+                    {
+                        "description": "JSON of the user to login. Minimum password length is 6.",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserLogin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserLogin"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginChallenge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/login/2fa": {
+            "post": {
+                "description": "Exchange the challenge token returned by /users/login and a code from the authenticator app, or an unused recovery code, for an access token and a refresh token. Wrong codes count as failed logins, and a challenge can only be exchanged once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Finish a two-factor login",
+                "parameters": [
+                    {
+                        "description": "Challenge token and code.",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginTwoFactor"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserLogin"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the bearer token of the request and sign out its session, revoking the refresh tokens of the same login. A refresh token given in the body is revoked along with every refresh token rotated from the same login, for tokens issued before sessions were recorded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Logout",
+                "parameters": [
+                    {
+                        "description": "Refresh token to revoke along with the access token.",
+                        "name": "logout",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Logout"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/oidc/callback": {
+            "get": {
+                "description": "The OpenID Connect provider redirects here. When linking, the identity is linked to the user who started it. Otherwise the user linked to the identity is logged in; if there is none a new user is registered with the email and username from the provider, unless the email is already registered. Logins answer like /users/login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Finish a login with the identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserLogin"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Identity"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginChallenge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oidc/link": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start linking an account at the OpenID Connect provider to the logged in user, so they can login with it. Open the returned URL in the browser that made this request; the provider redirects back to /users/oidc/callback, which links the identity.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Link an identity",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.AuthorizationURL"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oidc/login": {
+            "get": {
+                "description": "Redirect the browser to the OpenID Connect provider to login. The provider redirects back to /users/oidc/callback, which logs in the user linked to the identity, or registers a new user. Only available when OpenID Connect login is configured.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Login with the identity provider",
+                "responses": {
+                    "302": {
+                        "description": ""
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the password of the logged in user. Every token issued to them is revoked, so they have to login again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "The current password and the new one. Minimum password length is 6.",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PasswordChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/password/forgot": {
+            "post": {
+                "description": "Email a single use password reset token to the user with the given email. The response is the same whether or not the email is registered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Email of the user who forgot their password.",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PasswordForgot"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/password/reset": {
+            "post": {
+                "description": "Choose a new password with a token sent by /users/password/forgot. Every token issued to the user is revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "The reset token and the new password. Minimum password length is 6.",
+                        "name": "reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PasswordReset"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. Each refresh token can only be used once, using it again revokes every refresh token descended from the same login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Refresh an access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token returned by the last login or refresh.",
+                        "name": "refreshToken",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RefreshToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserLogin"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/register": {
+            "post": {
+                "description": "Register a new user. A link to verify the email is sent to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "JSON of the user to be made. Minimum age is 9. Minimum password length is 6",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserRegister"
+                        }
+                    },
+                    "209": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the sessions the logged in user is logged in with, most recently used first. The session of the bearer token is marked current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get active sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.Session"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/sessions/{sessionId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign out a session of the logged in user, e.g. on a lost device. Its access tokens are rejected and its refresh token revoked from now on.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Sign out a session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the session",
+                        "name": "sessionId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/verify": {
+            "get": {
+                "description": "Mark the email of a user verified. This is the link sent by email after registering or changing the email.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Verify an email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the verification link.",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/verify/resend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Send the email verification link of the logged in user again. It can only be asked for once per resend interval.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Resend the verification email",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/{userId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the public profile of a user. Their email and age are left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get the profile of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID number of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.APIKey": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "backup script"
+                },
+                "scopes": {
+                    "description": "Scopes are photos:read, photos:write, comments:read, comments:write,\nsocialmedias:read or socialmedias:write.",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "photos:read",
+                        "comments:write"
+                    ]
+                }
+            }
+        },
+        "dto.Album": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "cover_photo_id": {
+                    "description": "CoverPhotoID has to be a photo of the user of the album.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "description": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "description": "Visibility is public, the default, or private to only show the album\nto its user.",
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "private"
+                    ],
+                    "example": "public"
+                }
+            }
+        },
+        "dto.AlbumPhotoOrder": {
+            "type": "object",
+            "required": [
+                "photo_ids",
+                "position"
+            ],
+            "properties": {
+                "photo_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                }
+            }
+        },
+        "dto.AlbumPhotos": {
+            "type": "object",
+            "required": [
+                "photo_ids"
+            ],
+            "properties": {
+                "photo_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
+                }
+            }
+        },
+        "dto.Comment": {
+            "type": "object",
+            "required": [
+                "message",
+                "photo_id"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "ParentID is the comment replied to, left out for top-level comments.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "photo_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.CommentMessage": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LoginTwoFactor": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "dto.Logout": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PasswordChange": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "dto.PasswordForgot": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "name@org.dom.ge"
+                }
+            }
+        },
+        "dto.PasswordReset": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.Photo": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "caption": {
+                    "type": "string"
+                },
+                "photo_url": {
+                    "type": "string",
+                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "upload_id": {
+                    "description": "UploadID names a finished resumable upload to make the photo of,\ninstead of linking PhotoUrl.",
+                    "type": "string",
+                    "example": "0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11"
+                }
+            }
+        },
+        "dto.PhotoComment": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.PhotoUploadStart": {
+            "type": "object",
+            "required": [
+                "size"
+            ],
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 204800
+                }
+            }
+        },
+        "dto.RefreshToken": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SocialMedia": {
+            "type": "object",
+            "required": [
+                "name",
+                "social_media_url"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "social_media_url": {
+                    "type": "string",
+                    "example": "https://subdomain.domain.dom.ge/path"
+                }
+            }
+        },
+        "dto.TwoFactorCode": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is a 6 digit code from the authenticator app or a recovery code.",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "dto.UserLogin": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "name@org.dom.ge"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "dto.UserRegister": {
+            "type": "object",
+            "required": [
+                "age",
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "age": {
+                    "type": "integer",
+                    "example": 23
+                },
+                "email": {
+                    "type": "string",
+                    "example": "name@org.dom.ge"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UserRole": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "moderator",
+                        "admin"
+                    ],
+                    "example": "moderator"
+                }
+            }
+        },
+        "dto.UserUpdate": {
+            "type": "object",
+            "required": [
+                "email",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "name@org.dom.ge"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Comment": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt is set when the comment was deleted while it had replies.\nIts message and user are cleared, but it stays so the replies keep\ntheir place in the thread.",
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "ParentID is the comment this one replies to, nil for top-level\ncomments. Depth counts the comments above it.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "photo_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reply_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.Photo": {
+            "type": "object",
+            "properties": {
+                "byte_size": {
+                    "type": "integer",
+                    "example": 204800
+                },
+                "caption": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 768
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link_checked_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "link_error": {
+                    "description": "LinkError tells why a broken link is broken.",
+                    "type": "string",
+                    "example": "the server answered 404 Not Found"
+                },
+                "link_status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "photo_url": {
+                    "type": "string",
+                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
+                },
+                "source_url": {
+                    "description": "SourceUrl is the link a photo was copied from, when links are\ningested. PhotoUrl then points to the copy.",
+                    "type": "string",
+                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "variants_status": {
+                    "description": "VariantsStatus tells how far the resized variants of an uploaded photo\nhave got, it is empty for photos linked by URL.",
+                    "type": "string",
+                    "example": "ready"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1024
+                }
+            }
+        },
+        "responses.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "backup script"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "fa_Xq3vT9bL"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "photos:read",
+                        "comments:write"
+                    ]
+                }
+            }
+        },
+        "responses.AlbumPhotos": {
             "type": "object",
-            "required": [
-                "message",
-                "photo_id"
-            ],
             "properties": {
-                "message": {
+                "album_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "photo_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
+                }
+            }
+        },
+        "responses.AuthorizationURL": {
+            "type": "object",
+            "properties": {
+                "authorization_url": {
+                    "type": "string",
+                    "example": "https://accounts.example.com/authorize?client_id=photos\u0026code_challenge=..."
+                }
+            }
+        },
+        "responses.CreateAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "key": {
+                    "description": "Key is only returned once.",
+                    "type": "string",
+                    "example": "fa_Xq3vT9bLm0c8YHn2d5QeWJ1uK7rAzP4sGtVfxE6iNoB"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "backup script"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "fa_Xq3vT9bL"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "photos:read",
+                        "comments:write"
+                    ]
+                }
+            }
+        },
+        "responses.CreateAlbum": {
+            "type": "object",
+            "properties": {
+                "cover_photo_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "description": {
                     "type": "string"
                 },
-                "photo_id": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "title": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "integer",
                     "example": 1
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "public"
                 }
             }
         },
-        "dto.CommentMessage": {
+        "responses.CreateComment": {
             "type": "object",
-            "required": [
-                "message"
-            ],
             "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "message": {
                     "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "photo_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "dto.Photo": {
+        "responses.CreatePhoto": {
             "type": "object",
-            "required": [
-                "photo_url",
-                "title"
-            ],
             "properties": {
+                "byte_size": {
+                    "type": "integer",
+                    "example": 204800
+                },
                 "caption": {
                     "type": "string"
                 },
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 768
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link_status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
                 "photo_url": {
                     "type": "string",
                     "example": "https://subdomain.domain.dom.ge/path?arg=1"
                 },
                 "title": {
                     "type": "string"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "variants_status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1024
                 }
             }
         },
-        "dto.SocialMedia": {
+        "responses.CreateSocialMedia": {
             "type": "object",
-            "required": [
-                "name",
-                "social_media_url"
-            ],
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "name": {
                     "type": "string"
                 },
                 "social_media_url": {
                     "type": "string",
                     "example": "https://subdomain.domain.dom.ge/path"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.UserLogin": {
+        "responses.ErrorMessage": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "name@org.dom.ge"
-                },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
+                "error_message": {
+                    "type": "string"
                 }
             }
         },
-        "dto.UserRegister": {
+        "responses.GetAlbum": {
             "type": "object",
-            "required": [
-                "age",
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "age": {
+                "cover_photo_id": {
+                    "description": "CoverPhotoID is the photo the album is shown with, nil when none was\nchosen or it was deleted.",
                     "type": "integer",
-                    "example": 23
+                    "example": 1
                 },
-                "email": {
+                "cover_photo_url": {
                     "type": "string",
-                    "example": "name@org.dom.ge"
+                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
                 },
-                "password": {
+                "created_at": {
                     "type": "string",
-                    "minLength": 6
+                    "example": "2019-11-09T21:21:46+00:00"
                 },
-                "username": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "photos": {
+                    "description": "Photos are the photos of the album in their order. They are only\nlisted for a single album.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.GetPhoto"
+                    }
+                },
+                "title": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "user": {
+                    "$ref": "#/definitions/responses.UserAlbum"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "public"
                 }
             }
         },
-        "dto.UserUpdate": {
+        "responses.GetAllAlbums": {
             "type": "object",
-            "required": [
-                "email",
-                "username"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "name@org.dom.ge"
-                },
-                "username": {
-                    "type": "string"
+                "albums": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.GetAlbum"
+                    }
                 }
             }
         },
-        "models.Comment": {
+        "responses.GetAllSocialMedias": {
+            "type": "object",
+            "properties": {
+                "social_medias": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.GetSocialMedia"
+                    }
+                }
+            }
+        },
+        "responses.GetAllTags": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.Tag"
+                    }
+                }
+            }
+        },
+        "responses.GetComment": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
+                "deleted_at": {
+                    "description": "DeletedAt is set on comments deleted while they had replies, which\nare kept without their message and user.",
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
@@ -959,23 +4697,40 @@ const docTemplate = `{
                 "message": {
                     "type": "string"
                 },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "photo": {
+                    "$ref": "#/definitions/models.Photo"
+                },
                 "photo_id": {
                     "type": "integer",
                     "example": 1
                 },
+                "reply_count": {
+                    "type": "integer"
+                },
                 "updated_at": {
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
+                "user": {
+                    "$ref": "#/definitions/responses.UserComment"
+                },
                 "user_id": {
                     "type": "integer",
                     "example": 1
                 }
             }
         },
-        "models.Photo": {
+        "responses.GetPhoto": {
             "type": "object",
             "properties": {
+                "byte_size": {
+                    "type": "integer",
+                    "example": 204800
+                },
                 "caption": {
                     "type": "string"
                 },
@@ -983,14 +4738,40 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
+                "height": {
+                    "type": "integer",
+                    "example": 768
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
+                "link_checked_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "link_error": {
+                    "description": "LinkError tells why a broken link is broken.",
+                    "type": "string",
+                    "example": "the server answered 404 Not Found"
+                },
+                "link_status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
                 "photo_url": {
                     "type": "string",
                     "example": "https://subdomain.domain.dom.ge/path?arg=1"
                 },
+                "source_url": {
+                    "description": "SourceUrl is the link a photo was copied from, when links are\ningested. PhotoUrl then points to the copy.",
+                    "type": "string",
+                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
+                },
                 "title": {
                     "type": "string"
                 },
@@ -998,13 +4779,32 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
+                "user": {
+                    "$ref": "#/definitions/dto.UserUpdate"
+                },
                 "user_id": {
                     "type": "integer",
                     "example": 1
+                },
+                "variants": {
+                    "description": "Variants are the resized copies of an uploaded photo by name, such as\n640_jpeg, once its variants_status is ready.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/responses.PhotoVariant"
+                    }
+                },
+                "variants_status": {
+                    "description": "VariantsStatus tells how far the resized variants of an uploaded photo\nhave got, it is empty for photos linked by URL.",
+                    "type": "string",
+                    "example": "ready"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1024
                 }
             }
         },
-        "responses.CreateComment": {
+        "responses.GetSocialMedia": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -1015,202 +4815,248 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1
                 },
-                "message": {
+                "name": {
                     "type": "string"
                 },
-                "photo_id": {
-                    "type": "integer",
-                    "example": 1
+                "social_media_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "user": {
+                    "$ref": "#/definitions/responses.UserSocialMedia"
                 },
                 "user_id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "integer"
                 }
             }
         },
-        "responses.CreatePhoto": {
+        "responses.Identity": {
             "type": "object",
             "properties": {
-                "caption": {
-                    "type": "string"
-                },
                 "created_at": {
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
+                "email": {
+                    "type": "string",
+                    "example": "name@org.dom.ge"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "photo_url": {
+                "issuer": {
                     "type": "string",
-                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
-                },
-                "title": {
-                    "type": "string"
+                    "example": "https://accounts.example.com"
                 },
-                "user_id": {
-                    "type": "integer",
-                    "example": 1
+                "subject": {
+                    "type": "string",
+                    "example": "248289761001"
                 }
             }
         },
-        "responses.CreateSocialMedia": {
+        "responses.LoginChallenge": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 1
-                },
-                "name": {
-                    "type": "string"
-                },
-                "social_media_url": {
+                "challenge_token": {
                     "type": "string",
-                    "example": "https://subdomain.domain.dom.ge/path"
+                    "example": "header.payload.signature"
                 },
-                "user_id": {
-                    "type": "integer"
+                "expires_in": {
+                    "type": "integer",
+                    "example": 300
                 }
             }
         },
-        "responses.ErrorMessage": {
+        "responses.Message": {
             "type": "object",
             "properties": {
-                "error_message": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "responses.GetAllSocialMedias": {
+        "responses.PhotoUpload": {
             "type": "object",
             "properties": {
-                "social_medias": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/responses.GetSocialMedia"
-                    }
+                "expires_at": {
+                    "type": "string",
+                    "example": "2019-11-10T21:21:46+00:00"
+                },
+                "received": {
+                    "description": "Received is the offset the next chunk has to start at.",
+                    "type": "integer",
+                    "example": 65536
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 204800
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11"
                 }
             }
         },
-        "responses.GetComment": {
+        "responses.PhotoVariant": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string",
-                    "example": "2019-11-09T21:21:46+00:00"
-                },
-                "id": {
+                "byte_size": {
                     "type": "integer",
-                    "example": 1
-                },
-                "message": {
-                    "type": "string"
-                },
-                "photo": {
-                    "$ref": "#/definitions/models.Photo"
+                    "example": 204800
                 },
-                "photo_id": {
+                "height": {
                     "type": "integer",
-                    "example": 1
+                    "example": 768
                 },
-                "updated_at": {
+                "mime_type": {
                     "type": "string",
-                    "example": "2019-11-09T21:21:46+00:00"
+                    "example": "image/jpeg"
                 },
-                "user": {
-                    "$ref": "#/definitions/responses.UserComment"
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/uploads/photos/0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11_640_jpeg.jpg"
                 },
-                "user_id": {
+                "width": {
                     "type": "integer",
-                    "example": 1
+                    "example": 1024
                 }
             }
         },
-        "responses.GetPhoto": {
+        "responses.RecoveryCodes": {
             "type": "object",
             "properties": {
-                "caption": {
+                "message": {
                     "type": "string"
                 },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "responses.Session": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
+                "current": {
+                    "description": "Current is true for the session of the request.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2019-12-09T21:21:46+00:00"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "photo_url": {
+                "ip": {
                     "type": "string",
-                    "example": "https://subdomain.domain.dom.ge/path?arg=1"
-                },
-                "title": {
-                    "type": "string"
+                    "example": "203.0.113.7"
                 },
-                "updated_at": {
+                "last_seen_at": {
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
-                "user": {
-                    "$ref": "#/definitions/dto.UserUpdate"
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0"
+                }
+            }
+        },
+        "responses.Tag": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "sunset"
                 },
-                "user_id": {
+                "photo_count": {
                     "type": "integer",
-                    "example": 1
+                    "example": 3
                 }
             }
         },
-        "responses.GetSocialMedia": {
+        "responses.TwoFactorEnrollment": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "otpauth_uri": {
                     "type": "string",
-                    "example": "2019-11-09T21:21:46+00:00"
+                    "example": "otpauth://totp/finalassignment:name@org.dom.ge?secret=JBSWY3DPEHPK3PXP\u0026issuer=finalassignment"
                 },
-                "id": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "responses.UpdateAlbum": {
+            "type": "object",
+            "properties": {
+                "cover_photo_id": {
                     "type": "integer",
                     "example": 1
                 },
-                "name": {
+                "description": {
                     "type": "string"
                 },
-                "social_media_url": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "title": {
                     "type": "string"
                 },
                 "updated_at": {
                     "type": "string",
                     "example": "2019-11-09T21:21:46+00:00"
                 },
-                "user": {
-                    "$ref": "#/definitions/responses.UserSocialMedia"
-                },
                 "user_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "responses.Message": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
+                    "type": "integer",
+                    "example": 1
+                },
+                "visibility": {
+                    "type": "string",
+                    "example": "public"
                 }
             }
         },
         "responses.UpdatePhoto": {
             "type": "object",
             "properties": {
+                "byte_size": {
+                    "type": "integer",
+                    "example": 204800
+                },
                 "caption": {
                     "type": "string"
                 },
+                "height": {
+                    "type": "integer",
+                    "example": 768
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
+                "link_status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
                 "photo_url": {
                     "type": "string",
                     "example": "https://subdomain.domain.dom.ge/path?arg=1"
@@ -1225,6 +5071,14 @@ const docTemplate = `{
                 "user_id": {
                     "type": "integer",
                     "example": 1
+                },
+                "variants_status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 1024
                 }
             }
         },
@@ -1252,6 +5106,18 @@ const docTemplate = `{
                 }
             }
         },
+        "responses.UserAlbum": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "responses.UserComment": {
             "type": "object",
             "properties": {
@@ -1271,12 +5137,35 @@ const docTemplate = `{
         "responses.UserLogin": {
             "type": "object",
             "properties": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
                 "token": {
                     "type": "string",
                     "example": "header.payload.signature"
                 }
             }
         },
+        "responses.UserProfile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2019-11-09T21:21:46+00:00"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "responses.UserRegister": {
             "type": "object",
             "properties": {
@@ -1331,9 +5220,58 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "token.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "RS256"
+                },
+                "crv": {
+                    "type": "string",
+                    "example": "Ed25519"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string",
+                    "example": "RSA"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string",
+                    "example": "sig"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "token.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/token.JWK"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
         "BearerAuth": {
             "type": "apiKey",
             "name": "Authorization",
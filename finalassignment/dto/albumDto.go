@@ -0,0 +1,23 @@
+package dto
+
+type Album struct {
+	Title       string `validate:"required"`
+	Description string
+	// CoverPhotoID has to be a photo of the user of the album.
+	CoverPhotoID *uint `json:"cover_photo_id" example:"1"`
+	// Visibility is public, the default, or private to only show the album
+	// to its user.
+	Visibility string `validate:"omitempty,oneof=public private" json:"visibility" example:"public"`
+}
+
+// AlbumPhotos are photos added to or removed from an album.
+type AlbumPhotos struct {
+	PhotoIDs []uint `validate:"required,min=1,max=100,unique,dive,min=1" json:"photo_ids" example:"3,1,2"`
+}
+
+// AlbumPhotoOrder moves photos of an album, in the order of PhotoIDs, to
+// Position counting from 1. The other photos keep their order around them.
+type AlbumPhotoOrder struct {
+	PhotoIDs []uint `validate:"required,min=1,max=100,unique,dive,min=1" json:"photo_ids" example:"3,1,2"`
+	Position int    `validate:"required,min=1" json:"position" example:"1"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+type APIKey struct {
+	Name string `validate:"required,max=100" json:"name" example:"backup script"`
+	// Scopes are photos:read, photos:write, comments:read, comments:write,
+	// socialmedias:read or socialmedias:write.
+	Scopes []string `validate:"required,min=1,dive,oneof=photos:read photos:write comments:read comments:write socialmedias:read socialmedias:write" json:"scopes" example:"photos:read,comments:write"`
+}
@@ -6,4 +6,12 @@ type CommentMessage struct {
 type Comment struct {
 	Message string `validate:"required"`
 	PhotoID uint   `validate:"required" json:"photo_id" example:"1"`
+	// ParentID is the comment replied to, left out for top-level comments.
+	ParentID uint `json:"parent_id,omitempty" example:"1"`
+}
+
+// PhotoComment is a Comment on the photo in the path.
+type PhotoComment struct {
+	Message  string `validate:"required"`
+	ParentID uint   `json:"parent_id,omitempty" example:"1"`
 }
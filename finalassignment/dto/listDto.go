@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// ListQuery is the query string of the list endpoints.
+type ListQuery struct {
+	Limit int `form:"limit" json:"limit" validate:"omitempty,min=1,max=100" example:"20"`
+	// Cursor is the X-Next-Cursor header of the previous page.
+	Cursor string `form:"cursor" json:"cursor"`
+	// Sort is created_at or updated_at, descending when prefixed with -.
+	Sort          string    `form:"sort" json:"sort" validate:"omitempty,oneof=created_at -created_at updated_at -updated_at" example:"-created_at"`
+	UserID        uint      `form:"user_id" json:"user_id" example:"1"`
+	CreatedAfter  time.Time `form:"created_after" json:"created_after" time_format:"2006-01-02T15:04:05Z07:00" example:"2019-11-09T21:21:46+00:00"`
+	CreatedBefore time.Time `form:"created_before" json:"created_before" time_format:"2006-01-02T15:04:05Z07:00" example:"2019-11-09T21:21:46+00:00"`
+}
+type CommentListQuery struct {
+	ListQuery
+	PhotoID uint `form:"photo_id" json:"photo_id" example:"1"`
+}
+
+// PhotoCommentListQuery is the query string of the comments of a photo.
+type PhotoCommentListQuery struct {
+	ListQuery
+	// ParentID lists the replies to a comment instead of the top-level
+	// comments.
+	ParentID uint `form:"parent_id" json:"parent_id" example:"1"`
+}
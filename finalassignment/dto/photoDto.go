@@ -3,5 +3,20 @@ package dto
 type Photo struct {
 	Title    string `validate:"required"`
 	Caption  string
-	PhotoUrl string `validate:"required,url" json:"photo_url" example:"https://subdomain.domain.dom.ge/path?arg=1"`
+	PhotoUrl string `validate:"required_without=UploadID,excluded_with=UploadID,omitempty,url" json:"photo_url" example:"https://subdomain.domain.dom.ge/path?arg=1"`
+	// UploadID names a finished resumable upload to make the photo of,
+	// instead of linking PhotoUrl.
+	UploadID string `validate:"omitempty,uuid" json:"upload_id,omitempty" example:"0b7c0c1e-9a3f-4c36-8f0e-2f9c1d7b5a11"`
+}
+
+// PhotoForm is the form of a photo uploaded as multipart/form-data, along
+// with the image in the photo field.
+type PhotoForm struct {
+	Title   string `form:"title" validate:"required"`
+	Caption string `form:"caption"`
+}
+
+// PhotoUploadStart starts a resumable upload of a photo of Size bytes.
+type PhotoUploadStart struct {
+	Size int64 `json:"size" validate:"required,min=1" example:"204800"`
 }
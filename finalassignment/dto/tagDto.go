@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+// TagQuery is the query string of the tag autocomplete.
+type TagQuery struct {
+	// Prefix is the start of the tags, with or without the #.
+	Prefix string `form:"prefix" json:"prefix" validate:"max=65" example:"sun"`
+	Limit  int    `form:"limit" json:"limit" validate:"omitempty,min=1,max=100" example:"10"`
+}
+
+// TrendingTagQuery is the query string of the trending tags.
+type TrendingTagQuery struct {
+	// Window is how far back photos are counted, 24h unless given, up to
+	// 720h.
+	Window time.Duration `form:"window" json:"window" validate:"omitempty,min=1m,max=720h" swaggertype:"string" example:"24h"`
+	Limit  int           `form:"limit" json:"limit" validate:"omitempty,min=1,max=100" example:"10"`
+}
@@ -14,3 +14,31 @@ type UserUpdate struct {
 	Username string `validate:"required" json:"username"`
 	Email    string `validate:"required,email" json:"email" example:"name@org.dom.ge"`
 }
+type RefreshToken struct {
+	RefreshToken string `validate:"required" json:"refresh_token"`
+}
+type UserRole struct {
+	Role string `validate:"required,oneof=user moderator admin" json:"role" example:"moderator"`
+}
+type Logout struct {
+	RefreshToken string `json:"refresh_token"`
+}
+type PasswordChange struct {
+	CurrentPassword string `validate:"required" json:"current_password"`
+	NewPassword     string `validate:"required,min=6" json:"new_password"`
+}
+type PasswordForgot struct {
+	Email string `validate:"required,email" json:"email" example:"name@org.dom.ge"`
+}
+type PasswordReset struct {
+	Token       string `validate:"required" json:"token"`
+	NewPassword string `validate:"required,min=6" json:"new_password"`
+}
+type TwoFactorCode struct {
+	// Code is a 6 digit code from the authenticator app or a recovery code.
+	Code string `validate:"required" json:"code" example:"123456"`
+}
+type LoginTwoFactor struct {
+	ChallengeToken string `validate:"required" json:"challenge_token"`
+	Code           string `validate:"required" json:"code" example:"123456"`
+}
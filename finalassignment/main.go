@@ -1,9 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	"finalassignment.id/finalassignment/config"
 	"finalassignment.id/finalassignment/database"
 	_ "finalassignment.id/finalassignment/docs"
 	"finalassignment.id/finalassignment/routers"
+	"finalassignment.id/finalassignment/utils/links"
+	"finalassignment.id/finalassignment/utils/mailer"
+	"finalassignment.id/finalassignment/utils/storage"
+	"finalassignment.id/finalassignment/utils/token"
+	"finalassignment.id/finalassignment/utils/variants"
 )
 
 // @title           Final Assignment
@@ -22,8 +34,45 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
 func main() {
-	database.StartDB()
-	var port = ":8080"
-	routers.StartServer().Run(port)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatal(err)
+		}
+		return
+	}
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
+	keys, err := token.LoadKeys(cfg.JWT)
+	if err != nil {
+		log.Fatal(err)
+	}
+	token.Configure(keys, cfg.JWT.TokenTTL, cfg.JWT.RefreshTTL)
+	db, err := database.StartDB(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatal(err)
+	}
+	blobs, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+	repos := database.NewRepositories(db)
+	worker := variants.NewWorker(repos.Photos, blobs, cfg.Variants, cfg.Storage.PublicURL)
+	worker.Start(context.Background())
+	checker := links.NewChecker(repos.Photos, blobs, worker, cfg.Links, cfg.Storage.PublicURL)
+	checker.Start(context.Background())
+	log.Fatal(routers.StartServer(repos, mail, blobs, worker, checker, cfg).Run(cfg.HTTP.Addr()))
 }
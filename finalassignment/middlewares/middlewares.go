@@ -2,17 +2,43 @@ package middlewares
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"finalassignment.id/finalassignment/database"
 	"finalassignment.id/finalassignment/utils/token"
 	"github.com/gin-gonic/gin"
 )
 
 const errorMessageStr = "error_message"
 
-func JwtAuthMiddleware() gin.HandlerFunc {
+var (
+	ErrTokenRevoked = errors.New("This token has been revoked, please login again.")
+	ErrSignedOut    = errors.New("This session has been signed out, please login again.")
+	ErrRoleRequired = errors.New("Your role is not allowed to access this resource.")
+	ErrUnverified   = errors.New("Please verify your email first, follow the link sent to it or ask for another at /users/verify/resend.")
+	ErrInvalidKey   = errors.New("The API key is invalid or has been revoked.")
+	ErrKeyForbidden = errors.New("API keys can't be used here, login for an access token instead.")
+	ErrSuspended    = errors.New("Your account has been suspended.")
+)
+
+// sessionTouchInterval is how stale the last seen time of a session may get,
+// so that not every request writes it.
+const sessionTouchInterval = time.Minute
+
+// JwtAuthMiddleware rejects requests without a valid, unrevoked bearer token
+// of a session that wasn't signed out, and stores the Principal it was issued
+// to, see CurrentPrincipal.
+func JwtAuthMiddleware(revocations database.RevocationStore, sessions database.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		err := token.TokenValid(c)
+		if _, ok := token.ExtractAPIKey(c); ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				errorMessageStr: ErrKeyForbidden.Error(),
+			})
+			return
+		}
+		claims, err := token.ExtractClaims(c)
 		if err != nil {
 			status := http.StatusUnauthorized
 			if errors.Is(err, token.ErrNoToken) {
@@ -23,6 +49,137 @@ func JwtAuthMiddleware() gin.HandlerFunc {
 			})
 			return
 		}
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		revoked, err := revocations.IsTokenRevoked(claims.ID, claims.UserID, issuedAt)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				errorMessageStr: ErrTokenRevoked.Error(),
+			})
+			return
+		}
+		if claims.SessionID != 0 {
+			session, err := sessions.GetSession(claims.SessionID)
+			if err != nil && !errors.Is(err, database.ErrNotFound) {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			if err != nil || session.RevokedAt != nil || session.UserID != claims.UserID {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					errorMessageStr: ErrSignedOut.Error(),
+				})
+				return
+			}
+			if now := time.Now(); now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+				if err := sessions.TouchSession(session.ID, now); err != nil {
+					c.AbortWithError(http.StatusInternalServerError, err)
+					return
+				}
+			}
+		}
+		c.Set(principalKey, newPrincipal(claims))
+		c.Next()
+	}
+}
+
+// AuthMiddleware is JwtAuthMiddleware that also accepts API keys sent as
+// "Authorization: ApiKey <key>". Their principal is the owner of the key,
+// limited to its scopes, see RequireScope.
+func AuthMiddleware(revocations database.RevocationStore, sessions database.SessionRepository, apiKeys database.APIKeyRepository, users database.UserRepository) gin.HandlerFunc {
+	jwtAuth := JwtAuthMiddleware(revocations, sessions)
+	return func(c *gin.Context) {
+		apiKey, ok := token.ExtractAPIKey(c)
+		if !ok {
+			jwtAuth(c)
+			return
+		}
+		key, err := apiKeys.GetAPIKeyByHash(token.HashOpaqueToken(apiKey))
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					errorMessageStr: ErrInvalidKey.Error(),
+				})
+				return
+			}
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		// Keys don't carry the role like access tokens do, and outlive
+		// suspensions, so both are read on every request.
+		user, err := users.GetUserWithoutPreload(key.UserID)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if user.SuspendedAt != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				errorMessageStr: ErrSuspended.Error(),
+			})
+			return
+		}
+		if err := apiKeys.TouchAPIKey(key.ID, time.Now()); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Set(principalKey, Principal{
+			UserID:   user.ID,
+			Roles:    []string{user.Role},
+			APIKeyID: key.ID,
+			Scopes:   key.Scopes(),
+		})
+		c.Next()
+	}
+}
+
+// RequireScope rejects API keys without scope. Access tokens are not limited
+// by scopes. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentPrincipal(c).HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				errorMessageStr: fmt.Sprintf("The API key needs the %s scope.", scope),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests whose principal does not have role. It must
+// run after JwtAuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentPrincipal(c).HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				errorMessageStr: ErrRoleRequired.Error(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail rejects requests of users who haven't verified their
+// email yet. It must run after JwtAuthMiddleware.
+func RequireVerifiedEmail(users database.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.GetUserWithoutPreload(CurrentPrincipal(c).UserID)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if user.EmailVerifiedAt == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				errorMessageStr: ErrUnverified.Error(),
+			})
+			return
+		}
 		c.Next()
 	}
 }
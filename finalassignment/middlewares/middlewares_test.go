@@ -0,0 +1,202 @@
+package middlewares_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/database/memory"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+func configureKeys(t *testing.T) {
+	t.Helper()
+	keys, err := token.NewKeySet(config.SecretKeyID, token.NewHMACKey(config.SecretKeyID, []byte(testSecret)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.Configure(keys, time.Hour, time.Hour)
+}
+
+// newRouter serves GET / behind handlers, answering with the principal.
+func newRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.GET("/", append(handlers, func(c *gin.Context) {
+		c.JSON(http.StatusOK, middlewares.CurrentPrincipal(c))
+	})...)
+	return router
+}
+
+func get(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expectResponse checks the status of rec and, unless message is empty, its
+// error message.
+func expectResponse(t *testing.T, rec *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+	if message == "" {
+		return
+	}
+	var body struct {
+		ErrorMessage string `json:"error_message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	if body.ErrorMessage != message {
+		t.Errorf("got error %q, want %q", body.ErrorMessage, message)
+	}
+}
+
+func createUser(t *testing.T, repos database.Repositories, name string) models.User {
+	t.Helper()
+	user := models.User{Username: name, Email: name + "@example.com", Password: "x", Age: 20, Role: models.RoleUser}
+	if err := repos.Users.CreateUser(&user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createSession(t *testing.T, repos database.Repositories, userID uint, family string) models.Session {
+	t.Helper()
+	session := models.Session{UserID: userID, FamilyID: family, LastSeenAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Sessions.CreateSession(&session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func bearer(t *testing.T, userID, sessionID uint) string {
+	t.Helper()
+	accessToken, err := token.GenerateToken(userID, []string{models.RoleUser}, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + accessToken
+}
+
+// legacyBearer is an access token issued before jti and sessions existed.
+func legacyBearer(t *testing.T, userID uint, issuedAt time.Time) string {
+	t.Helper()
+	claims := token.Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(time.Hour)),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func TestJwtAuthMiddleware(t *testing.T) {
+	configureKeys(t)
+	repos := memory.New()
+	router := newRouter(middlewares.JwtAuthMiddleware(repos.Revocations, repos.Sessions))
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+	session := createSession(t, repos, alice.ID, "family")
+	verification, err := token.GenerateEmailVerificationToken(alice.ID, alice.Email, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectResponse(t, get(router, ""), http.StatusBadRequest, token.ErrNoToken.Error())
+	expectResponse(t, get(router, "Bearer not.a.token"), http.StatusUnauthorized, "")
+	expectResponse(t, get(router, "Bearer "+verification), http.StatusUnauthorized, token.ErrNotAccess.Error())
+	expectResponse(t, get(router, "ApiKey anything"), http.StatusUnauthorized, middlewares.ErrKeyForbidden.Error())
+	expectResponse(t, get(router, bearer(t, alice.ID, session.ID)), http.StatusOK, "")
+	expectResponse(t, get(router, bearer(t, alice.ID, 0)), http.StatusOK, "")
+	expectResponse(t, get(router, legacyBearer(t, alice.ID, time.Now())), http.StatusOK, "")
+	// Sessions that don't exist or belong to someone else sign the token out.
+	expectResponse(t, get(router, bearer(t, alice.ID, session.ID+100)), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+	expectResponse(t, get(router, bearer(t, bob.ID, session.ID)), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+
+	rec := get(router, bearer(t, alice.ID, session.ID))
+	var principal middlewares.Principal
+	if err := json.Unmarshal(rec.Body.Bytes(), &principal); err != nil {
+		t.Fatal(err)
+	}
+	if principal.UserID != alice.ID || principal.SessionID != session.ID || principal.TokenID == "" || !principal.HasRole(models.RoleUser) {
+		t.Errorf("got principal %+v", principal)
+	}
+}
+
+// TestJwtAuthMiddlewareRevoked checks the tokens logging out and revoking
+// every token of a user leave behind are refused.
+func TestJwtAuthMiddlewareRevoked(t *testing.T) {
+	configureKeys(t)
+	repos := memory.New()
+	router := newRouter(middlewares.JwtAuthMiddleware(repos.Revocations, repos.Sessions))
+	alice := createUser(t, repos, "alice")
+	bob := createUser(t, repos, "bob")
+
+	t.Run("token", func(t *testing.T) {
+		revoked, kept := bearer(t, alice.ID, 0), bearer(t, alice.ID, 0)
+		claims := &token.Claims{}
+		if _, _, err := new(jwt.Parser).ParseUnverified(revoked[len("Bearer "):], claims); err != nil {
+			t.Fatal(err)
+		}
+		if err := repos.Revocations.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			t.Fatal(err)
+		}
+		expectResponse(t, get(router, revoked), http.StatusUnauthorized, middlewares.ErrTokenRevoked.Error())
+		expectResponse(t, get(router, kept), http.StatusOK, "")
+	})
+
+	t.Run("session", func(t *testing.T) {
+		session := createSession(t, repos, alice.ID, "signed-out")
+		other := createSession(t, repos, alice.ID, "other")
+		if _, err := repos.Sessions.RevokeSession(alice.ID, session.ID); err != nil {
+			t.Fatal(err)
+		}
+		expectResponse(t, get(router, bearer(t, alice.ID, session.ID)), http.StatusUnauthorized, middlewares.ErrSignedOut.Error())
+		expectResponse(t, get(router, bearer(t, alice.ID, other.ID)), http.StatusOK, "")
+	})
+
+	t.Run("issued before", func(t *testing.T) {
+		before := bearer(t, alice.ID, 0)
+		legacy := legacyBearer(t, alice.ID, time.Now())
+		bobs := bearer(t, bob.ID, 0)
+		time.Sleep(time.Millisecond)
+		if err := repos.Revocations.RevokeUserTokens(alice.ID, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+		expectResponse(t, get(router, before), http.StatusUnauthorized, middlewares.ErrTokenRevoked.Error())
+		expectResponse(t, get(router, legacy), http.StatusUnauthorized, middlewares.ErrTokenRevoked.Error())
+		expectResponse(t, get(router, bobs), http.StatusOK, "")
+		// Logging in again after the cutoff works.
+		expectResponse(t, get(router, bearer(t, alice.ID, 0)), http.StatusOK, "")
+		expectResponse(t, get(router, legacyBearer(t, alice.ID, time.Now())), http.StatusOK, "")
+	})
+}
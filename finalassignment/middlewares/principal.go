@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"time"
+
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID uint
+	Roles  []string
+	// TokenID is the jti of the access token, empty for tokens issued before
+	// jti existed.
+	TokenID   string
+	ExpiresAt time.Time
+	// SessionID is the login the access token was issued for, zero for API
+	// keys and tokens issued before sessions were recorded.
+	SessionID uint
+	// APIKeyID is the key the request was authenticated with, zero for
+	// access tokens.
+	APIKeyID uint
+	// Scopes limit what an API key may do. Access tokens have none and may
+	// do anything their roles allow.
+	Scopes []string
+}
+
+func newPrincipal(claims *token.Claims) Principal {
+	principal := Principal{
+		UserID:    claims.UserID,
+		Roles:     claims.Roles,
+		TokenID:   claims.ID,
+		SessionID: claims.SessionID,
+	}
+	if claims.ExpiresAt != nil {
+		principal.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return principal
+}
+
+// CurrentPrincipal returns the Principal JwtAuthMiddleware or AuthMiddleware
+// stored in c. It panics when the route is behind neither.
+func CurrentPrincipal(c *gin.Context) Principal {
+	return c.MustGet(principalKey).(Principal)
+}
+
+// HasRole reports whether any role of the principal is allowed what role is.
+// Tokens issued before roles existed carry none and are treated as
+// models.RoleUser.
+func (p Principal) HasRole(role string) bool {
+	return p.Rank() >= models.RoleRank(role)
+}
+
+// Rank is the models.RoleRank of the most privileged role of the principal.
+func (p Principal) Rank() int {
+	rank := models.RoleRank(models.RoleUser)
+	for _, role := range p.Roles {
+		if models.RoleRank(role) > rank {
+			rank = models.RoleRank(role)
+		}
+	}
+	return rank
+}
+
+// HasScope reports whether the principal may do what scope allows.
+func (p Principal) HasScope(scope string) bool {
+	if p.APIKeyID == 0 {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/database"
+)
+
+const migrateUsage = `usage: finalassignment migrate [flags] up|down [steps]|status
+       finalassignment migrate create [-dir database/migrations] NAME`
+
+// runMigrate implements the migrate subcommand. args are the arguments after
+// "migrate".
+func runMigrate(args []string) error {
+	if len(args) > 0 && args[0] == "create" {
+		fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+		dir := fs.String("dir", "database/migrations", "directory holding one migrations directory per driver")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New(migrateUsage)
+		}
+		files, err := database.CreateMigration(*dir, fs.Arg(0))
+		for _, file := range files {
+			fmt.Println("created", file)
+		}
+		return err
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	db, err := database.OpenDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(db, cfg.Database.Driver)
+	if err != nil {
+		return err
+	}
+	switch cfg.Args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("already up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(cfg.Args) > 1 {
+			steps, err = strconv.Atoi(cfg.Args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", cfg.Args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
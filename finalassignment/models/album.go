@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Album groups photos of its user in the order they chose.
+type Album struct {
+	Model
+	Title       string `gorm:"not null" json:"title"`
+	Description string `gorm:"not null;default:''" json:"description"`
+	// CoverPhotoID is the photo the album is shown with, nil when none was
+	// chosen or it was deleted.
+	CoverPhotoID *uint  `json:"cover_photo_id,omitempty" example:"1"`
+	Visibility   string `gorm:"not null;default:'public'" json:"visibility" example:"public"`
+	UserID       uint   `gorm:"not null;index" json:"user_id" example:"1"`
+}
+
+// Visibility values.
+const (
+	// AlbumPublic albums are listed to everyone.
+	AlbumPublic = "public"
+	// AlbumPrivate albums are only shown to their user.
+	AlbumPrivate = "private"
+)
+
+// AlbumPhoto puts a photo in an album, Position counts from 1 at the start
+// of the album.
+type AlbumPhoto struct {
+	AlbumID   uint `gorm:"primaryKey"`
+	PhotoID   uint `gorm:"primaryKey;index"`
+	Position  int  `gorm:"not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes API keys can be limited to.
+const (
+	ScopePhotosRead        = "photos:read"
+	ScopePhotosWrite       = "photos:write"
+	ScopeCommentsRead      = "comments:read"
+	ScopeCommentsWrite     = "comments:write"
+	ScopeSocialMediasRead  = "socialmedias:read"
+	ScopeSocialMediasWrite = "socialmedias:write"
+)
+
+// APIKey lets scripts act as its user without their password. Only the
+// SHA-256 hash of the key is stored, Prefix is kept so users can tell their
+// keys apart.
+type APIKey struct {
+	Model
+	UserID  uint   `gorm:"not null;index"`
+	Name    string `gorm:"not null"`
+	Prefix  string `gorm:"not null"`
+	KeyHash string `gorm:"not null;uniqueIndex"`
+	// Scope is the space separated scopes of the key.
+	Scope      string `gorm:"not null"`
+	LastUsedAt *time.Time
+}
+
+func (key APIKey) Scopes() []string {
+	return strings.Fields(key.Scope)
+}
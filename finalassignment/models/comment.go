@@ -1,8 +1,19 @@
 package models
 
+import "time"
+
 type Comment struct {
 	Model
-	UserID  uint   `json:"user_id" example:"1"`
-	PhotoID uint   `json:"photo_id" example:"1"`
-	Message string `gorm:"not null;type:varchar(8192)" json:"message"`
+	UserID  uint `json:"user_id" example:"1"`
+	PhotoID uint `json:"photo_id" example:"1"`
+	// ParentID is the comment this one replies to, nil for top-level
+	// comments. Depth counts the comments above it.
+	ParentID   *uint  `gorm:"index" json:"parent_id,omitempty" example:"1"`
+	Depth      uint   `gorm:"not null;default:0" json:"depth"`
+	ReplyCount uint   `gorm:"not null;default:0" json:"reply_count"`
+	Message    string `gorm:"not null;type:varchar(8192)" json:"message"`
+	// DeletedAt is set when the comment was deleted while it had replies.
+	// Its message and user are cleared, but it stays so the replies keep
+	// their place in the thread.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
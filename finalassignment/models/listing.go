@@ -0,0 +1,48 @@
+package models
+
+// PhotoWithUser is a photo along with the user who posted it and its
+// variants. User is zero when the user was deleted.
+type PhotoWithUser struct {
+	Photo
+	User     User
+	Variants []PhotoVariant `gorm:"foreignKey:PhotoID"`
+}
+
+func (PhotoWithUser) TableName() string {
+	return "photos"
+}
+
+// CommentWithUserAndPhoto is a comment along with its user and the photo it
+// is on. Either is zero when it was deleted.
+type CommentWithUserAndPhoto struct {
+	Comment
+	User  User
+	Photo Photo
+}
+
+func (CommentWithUserAndPhoto) TableName() string {
+	return "comments"
+}
+
+// SocialMediaWithUser is a social media along with its user. User is zero
+// when the user was deleted.
+type SocialMediaWithUser struct {
+	SocialMedia
+	User User
+}
+
+func (SocialMediaWithUser) TableName() string {
+	return "social_media"
+}
+
+// AlbumWithUser is an album along with its user and its cover photo, nil
+// when it has none.
+type AlbumWithUser struct {
+	Album
+	User       User
+	CoverPhoto *Photo `gorm:"foreignKey:CoverPhotoID"`
+}
+
+func (AlbumWithUser) TableName() string {
+	return "albums"
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginAttempt counts the failed logins of an account or a client IP, told
+// apart by Key, e.g. "account:name@org.dom.ge" or "ip:192.0.2.1".
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey"`
+	Failures      int       `gorm:"not null"`
+	LastFailureAt time.Time `gorm:"not null;index"`
+}
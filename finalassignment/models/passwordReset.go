@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PasswordResetToken lets the owner of an email address choose a new
+// password once. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	Model
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
@@ -1,9 +1,53 @@
 package models
 
+import "time"
+
 type Photo struct {
 	Model
 	Title    string `gorm:"not null" json:"title"`
 	Caption  string `json:"caption"`
 	PhotoUrl string `gorm:"not null" json:"photo_url" example:"https://subdomain.domain.dom.ge/path?arg=1"`
 	UserID   uint   `json:"user_id" example:"1"`
+	PhotoFile
+	// VariantsStatus tells how far the resized variants of an uploaded photo
+	// have got, it is empty for photos linked by URL.
+	VariantsStatus string `gorm:"not null;default:'';index" json:"variants_status,omitempty" example:"ready"`
+	PhotoLink
+}
+
+// PhotoLink is what the last check of the URL of a linked photo found. It
+// is zero for uploaded photos.
+type PhotoLink struct {
+	LinkStatus    string     `gorm:"not null;default:'';index" json:"link_status,omitempty" example:"ok"`
+	LinkCheckedAt *time.Time `json:"link_checked_at,omitempty" example:"2019-11-09T21:21:46+00:00"`
+	// LinkError tells why a broken link is broken.
+	LinkError string `gorm:"not null;default:''" json:"link_error,omitempty" example:"the server answered 404 Not Found"`
+	// SourceUrl is the link a photo was copied from, when links are
+	// ingested. PhotoUrl then points to the copy.
+	SourceUrl string `gorm:"not null;default:''" json:"source_url,omitempty" example:"https://subdomain.domain.dom.ge/path?arg=1"`
+}
+
+// VariantsStatus values.
+const (
+	VariantsPending = "pending"
+	VariantsReady   = "ready"
+	VariantsFailed  = "failed"
+)
+
+// LinkStatus values.
+const (
+	LinkPending = "pending"
+	LinkOK      = "ok"
+	LinkBroken  = "broken"
+)
+
+// PhotoFile describes the stored bytes of an uploaded photo. It is zero for
+// photos linked by URL.
+type PhotoFile struct {
+	// StorageKey names the blob in the BlobStore.
+	StorageKey string `gorm:"not null;default:''" json:"-"`
+	MimeType   string `gorm:"not null;default:''" json:"mime_type,omitempty" example:"image/jpeg"`
+	ByteSize   int64  `gorm:"not null;default:0" json:"byte_size,omitempty" example:"204800"`
+	Width      int    `gorm:"not null;default:0" json:"width,omitempty" example:"1024"`
+	Height     int    `gorm:"not null;default:0" json:"height,omitempty" example:"768"`
 }
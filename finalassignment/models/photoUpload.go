@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// PhotoUpload is a photo sent in chunks, so a broken connection only loses
+// the chunk in flight. The chunks are stored as blobs until the upload is
+// finished into a photo.
+type PhotoUpload struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint `gorm:"not null;index"`
+	// Size is the length of the whole photo, Received how much of it has
+	// arrived in Chunks.
+	Size      int64              `gorm:"not null"`
+	Received  int64              `gorm:"not null;default:0"`
+	Chunks    []PhotoUploadChunk `gorm:"foreignKey:UploadID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ExpiresAt time.Time          `gorm:"not null"`
+}
+
+// ChunkKey is the storage key of a chunk of the upload sent by the request
+// named attempt. Every request stores its chunk under a key of its own, so
+// requests racing for the same offset can't overwrite the chunk that won.
+func (upload PhotoUpload) ChunkKey(attempt string) string {
+	return "uploads/" + upload.ID + "/" + attempt
+}
+
+// PhotoUploadChunk is a chunk counted for an upload, Position counts from 0
+// at the start of the photo.
+type PhotoUploadChunk struct {
+	UploadID   string `gorm:"primaryKey"`
+	Position   int    `gorm:"primaryKey;autoIncrement:false"`
+	StorageKey string `gorm:"not null"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PhotoVariant is a resized copy of an uploaded photo, such as the 640 pixel
+// JPEG one named 640_jpeg.
+type PhotoVariant struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	PhotoID   uint   `gorm:"not null;uniqueIndex:idx_photo_variants_photo_id_name"`
+	Name      string `gorm:"not null;uniqueIndex:idx_photo_variants_photo_id_name"`
+	URL       string `gorm:"not null"`
+	PhotoFile
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshToken is a single use refresh token. Only its SHA-256 hash is
+// stored. Every token issued by rotating another one shares its FamilyID, so
+// a whole login can be revoked at once.
+type RefreshToken struct {
+	Model
+	UserID    uint      `gorm:"not null"`
+	FamilyID  string    `gorm:"not null;index"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RevokedToken is an access token revoked before it expired, e.g. by logging
+// out. Rows are useless once ExpiresAt has passed.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// UserTokenRevocation revokes every access token issued to a user before
+// RevokedBefore.
+type UserTokenRevocation struct {
+	UserID        uint      `gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `gorm:"not null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Session is a login of a user on some device, which lasts as long as its
+// refresh token family. Access tokens name the session they were issued for,
+// so revoking it signs the device out.
+type Session struct {
+	Model
+	UserID   uint   `gorm:"not null;index"`
+	FamilyID string `gorm:"not null;uniqueIndex"`
+	// UserAgent and IP are those of the client that logged in.
+	UserAgent  string
+	IP         string
+	LastSeenAt time.Time `gorm:"not null"`
+	// ExpiresAt is when the latest refresh token of the session expires.
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Tag is a hashtag used in photo captions, named in lower case without
+// the #.
+type Tag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// PhotoTag tags a photo, CreatedAt is when the tag was first used in its
+// caption.
+type PhotoTag struct {
+	PhotoID   uint `gorm:"primaryKey"`
+	TagID     uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// TagCount is a tag along with the number of photos it was counted on.
+type TagCount struct {
+	Name       string
+	PhotoCount int64
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TOTPCredential is the RFC 6238 secret of a user. Two-factor login is on
+// once ConfirmedAt is set. The secret is needed to check codes, so unlike
+// passwords it can't be stored hashed.
+type TOTPCredential struct {
+	UserID      uint `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Secret      string `gorm:"not null"`
+	ConfirmedAt *time.Time
+	// LastCounter is the time step of the last accepted code, so a code
+	// can't be used twice.
+	LastCounter int64 `gorm:"not null;default:0"`
+}
+
+// RecoveryCode is a single use code that replaces a TOTP code when the
+// authenticator is lost. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	Model
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"not null"`
+	UsedAt   *time.Time
+}
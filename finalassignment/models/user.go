@@ -1,12 +1,35 @@
 package models
 
+import "time"
+
+// Roles a user can have, from least to most privileged. Every role is
+// allowed what the roles before it are.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+// RoleRank orders roles by privilege. Unknown roles rank 0, below RoleUser.
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
 type User struct {
 	Model
-	Username     string        `gorm:"not null;uniqueIndex"`
-	Email        string        `gorm:"not null;uniqueIndex"`
-	Password     string        `gorm:"not null"`
-	Age          uint          `gorm:"not null"`
-	Photos       []Photo       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	Comments     []Comment     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	SocialMedias []SocialMedia `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Username string `gorm:"not null;uniqueIndex"`
+	Email    string `gorm:"not null;uniqueIndex"`
+	Password string `gorm:"not null"`
+	Age      uint   `gorm:"not null"`
+	Role     string `gorm:"not null;default:user"`
+	// SuspendedAt is set while a moderator has suspended the user.
+	SuspendedAt *time.Time
+	// EmailVerifiedAt is nil until the user follows the link sent to Email.
+	EmailVerifiedAt    *time.Time
+	VerificationSentAt *time.Time
+	Photos             []Photo       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Comments           []Comment     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	SocialMedias       []SocialMedia `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 }
@@ -0,0 +1,12 @@
+package models
+
+// UserIdentity links an account at an OpenID Connect provider, known by the
+// issuer and the subject of its ID tokens, to a user.
+type UserIdentity struct {
+	Model
+	UserID  uint   `gorm:"not null;index"`
+	Issuer  string `gorm:"not null;uniqueIndex:idx_user_identities_issuer_subject"`
+	Subject string `gorm:"not null;uniqueIndex:idx_user_identities_issuer_subject"`
+	// Email is the email the provider reported when the identity was linked.
+	Email string
+}
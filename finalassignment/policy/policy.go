@@ -0,0 +1,86 @@
+// Package policy decides what an authenticated principal may do.
+package policy
+
+import (
+	"errors"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+)
+
+type Action string
+
+const (
+	Update Action = "update"
+	Delete Action = "delete"
+	// Suspend suspends a user or lifts their suspension.
+	Suspend Action = "suspend"
+	// ChangeRole changes the role of a user.
+	ChangeRole Action = "change_role"
+)
+
+var ErrForbidden = errors.New("You are not allowed to do this.")
+
+// Resource is what an action is done on.
+type Resource struct {
+	// OwnerID is the user the resource belongs to. A user owns themselves.
+	OwnerID uint
+	// OwnerRole is the role of the owner, only needed for actions on users.
+	OwnerRole string
+}
+
+func Photo(photo models.Photo) Resource {
+	return Resource{OwnerID: photo.UserID}
+}
+
+func Comment(comment models.Comment) Resource {
+	return Resource{OwnerID: comment.UserID}
+}
+
+func SocialMedia(socmed models.SocialMedia) Resource {
+	return Resource{OwnerID: socmed.UserID}
+}
+
+func Album(album models.Album) Resource {
+	return Resource{OwnerID: album.UserID}
+}
+
+func User(user models.User) Resource {
+	return Resource{OwnerID: user.ID, OwnerRole: user.Role}
+}
+
+// Can reports whether principal may do action on resource.
+//
+// Owners may update and delete what they own, and moderators may delete
+// anything. Moderators may suspend users less privileged than themselves,
+// and only admins may change the role of another user.
+func Can(principal middlewares.Principal, action Action, resource Resource) bool {
+	isOwner := principal.UserID == resource.OwnerID
+	switch action {
+	case Update:
+		return isOwner
+	case Delete:
+		return isOwner || principal.HasRole(models.RoleModerator)
+	case Suspend:
+		return !isOwner && principal.HasRole(models.RoleModerator) &&
+			principal.Rank() > models.RoleRank(resource.OwnerRole)
+	case ChangeRole:
+		return !isOwner && principal.HasRole(models.RoleAdmin)
+	default:
+		return false
+	}
+}
+
+// Authorize returns nil when principal may do action on resource. Otherwise
+// it returns database.ErrIllegalUpdate for changes to someone else's
+// resource and ErrForbidden for the rest.
+func Authorize(principal middlewares.Principal, action Action, resource Resource) error {
+	if Can(principal, action, resource) {
+		return nil
+	}
+	if action == Update || action == Delete {
+		return database.ErrIllegalUpdate
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,84 @@
+package policy_test
+
+import (
+	"errors"
+	"testing"
+
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/policy"
+)
+
+var actions = []policy.Action{policy.Update, policy.Delete, policy.Suspend, policy.ChangeRole}
+
+// TestCan checks every action of every role on themselves and on users of
+// every role.
+func TestCan(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		target policy.Resource
+		can    []policy.Action
+	}{
+		{"user on self", models.RoleUser, policy.Resource{OwnerID: 1, OwnerRole: models.RoleUser}, []policy.Action{policy.Update, policy.Delete}},
+		{"user on user", models.RoleUser, policy.Resource{OwnerID: 2, OwnerRole: models.RoleUser}, nil},
+		{"user on moderator", models.RoleUser, policy.Resource{OwnerID: 2, OwnerRole: models.RoleModerator}, nil},
+		{"user on admin", models.RoleUser, policy.Resource{OwnerID: 2, OwnerRole: models.RoleAdmin}, nil},
+
+		{"moderator on self", models.RoleModerator, policy.Resource{OwnerID: 1, OwnerRole: models.RoleModerator}, []policy.Action{policy.Update, policy.Delete}},
+		{"moderator on user", models.RoleModerator, policy.Resource{OwnerID: 2, OwnerRole: models.RoleUser}, []policy.Action{policy.Delete, policy.Suspend}},
+		{"moderator on moderator", models.RoleModerator, policy.Resource{OwnerID: 2, OwnerRole: models.RoleModerator}, []policy.Action{policy.Delete}},
+		{"moderator on admin", models.RoleModerator, policy.Resource{OwnerID: 2, OwnerRole: models.RoleAdmin}, []policy.Action{policy.Delete}},
+
+		{"admin on self", models.RoleAdmin, policy.Resource{OwnerID: 1, OwnerRole: models.RoleAdmin}, []policy.Action{policy.Update, policy.Delete}},
+		{"admin on user", models.RoleAdmin, policy.Resource{OwnerID: 2, OwnerRole: models.RoleUser}, []policy.Action{policy.Delete, policy.Suspend, policy.ChangeRole}},
+		{"admin on moderator", models.RoleAdmin, policy.Resource{OwnerID: 2, OwnerRole: models.RoleModerator}, []policy.Action{policy.Delete, policy.Suspend, policy.ChangeRole}},
+		{"admin on admin", models.RoleAdmin, policy.Resource{OwnerID: 2, OwnerRole: models.RoleAdmin}, []policy.Action{policy.Delete, policy.ChangeRole}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := middlewares.Principal{UserID: 1, Roles: []string{tt.role}}
+			for _, action := range actions {
+				want := false
+				for _, allowed := range tt.can {
+					want = want || allowed == action
+				}
+				if got := policy.Can(principal, action, tt.target); got != want {
+					t.Errorf("Can(%s) = %v, want %v", action, got, want)
+				}
+				err := policy.Authorize(principal, action, tt.target)
+				switch {
+				case want && err != nil:
+					t.Errorf("Authorize(%s) = %v, want nil", action, err)
+				case !want && (action == policy.Update || action == policy.Delete) && !errors.Is(err, database.ErrIllegalUpdate):
+					t.Errorf("Authorize(%s) = %v, want ErrIllegalUpdate", action, err)
+				case !want && (action == policy.Suspend || action == policy.ChangeRole) && !errors.Is(err, policy.ErrForbidden):
+					t.Errorf("Authorize(%s) = %v, want ErrForbidden", action, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCanResources(t *testing.T) {
+	user := middlewares.Principal{UserID: 1, Roles: []string{models.RoleUser}}
+	moderator := middlewares.Principal{UserID: 3, Roles: []string{models.RoleUser, models.RoleModerator}}
+	photo := policy.Photo(models.Photo{UserID: 1})
+	if !policy.Can(user, policy.Update, photo) || policy.Can(moderator, policy.Update, photo) {
+		t.Error("only the owner may update a photo")
+	}
+	if !policy.Can(moderator, policy.Delete, policy.Comment(models.Comment{UserID: 1})) {
+		t.Error("a moderator may delete any comment")
+	}
+	if policy.Can(user, policy.Delete, policy.SocialMedia(models.SocialMedia{UserID: 2})) {
+		t.Error("a user may delete someone else's social media")
+	}
+	// The most privileged role of a principal counts.
+	if !policy.Can(moderator, policy.Suspend, policy.User(models.User{Model: models.Model{ID: 1}, Role: models.RoleUser})) {
+		t.Error("a principal with the moderator role among others may not suspend")
+	}
+	if policy.Can(moderator, policy.Action("publish"), photo) {
+		t.Error("an unknown action is allowed")
+	}
+}
@@ -1,34 +1,122 @@
 package routers
 
 import (
+	"finalassignment.id/finalassignment/config"
 	"finalassignment.id/finalassignment/controllers"
+	"finalassignment.id/finalassignment/database"
 	"finalassignment.id/finalassignment/middlewares"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/links"
+	"finalassignment.id/finalassignment/utils/mailer"
+	"finalassignment.id/finalassignment/utils/storage"
+	"finalassignment.id/finalassignment/utils/variants"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func StartServer() *gin.Engine {
+func StartServer(repos database.Repositories, mail mailer.Mailer, blobs storage.BlobStore, worker *variants.Worker, checker *links.Checker, cfg *config.Config) *gin.Engine {
 	router := gin.Default()
-	commentsRoute := router.Group("comments", middlewares.JwtAuthMiddleware())
-	commentsRoute.POST("/", controllers.CreateComment)
-	commentsRoute.GET("/", controllers.GetAllComments)
-	commentsRoute.PUT("/:commentId", controllers.UpdateComment)
-	commentsRoute.DELETE("/:commentId", controllers.DeleteComment)
-	socmedsRoute := router.Group("socialmedias", middlewares.JwtAuthMiddleware())
-	socmedsRoute.POST("/", controllers.CreateSocialMedia)
-	socmedsRoute.GET("/", controllers.GetAllSocialMedias)
-	socmedsRoute.PUT("/:socialMediaId", controllers.UpdateSocialMedia)
-	socmedsRoute.DELETE("/:socialMediaId", controllers.DeleteSocialMedia)
+	// Entries were validated by config.Load. Without any, X-Forwarded-For
+	// is ignored so clients can't spoof the IP failed logins are counted
+	// under.
+	router.SetTrustedProxies(cfg.HTTP.TrustedProxies)
+	auth := middlewares.JwtAuthMiddleware(repos.Revocations, repos.Sessions)
+	// Photos, comments and social medias can also be used with API keys.
+	keyAuth := middlewares.AuthMiddleware(repos.Revocations, repos.Sessions, repos.APIKeys, repos.Users)
+	verified := middlewares.RequireVerifiedEmail(repos.Users)
+	if !cfg.Verification.Required {
+		verified = func(c *gin.Context) { c.Next() }
+	}
+	commentHandler := controllers.NewCommentHandler(repos.Comments, repos.Photos)
+	commentsRead := middlewares.RequireScope(models.ScopeCommentsRead)
+	commentsWrite := middlewares.RequireScope(models.ScopeCommentsWrite)
+	commentsRoute := router.Group("comments", keyAuth)
+	commentsRoute.POST("/", commentsWrite, verified, commentHandler.CreateComment)
+	commentsRoute.GET("/", commentsRead, commentHandler.GetAllComments)
+	commentsRoute.GET("/:commentId", commentsRead, commentHandler.GetComment)
+	commentsRoute.PUT("/:commentId", commentsWrite, commentHandler.UpdateComment)
+	commentsRoute.DELETE("/:commentId", commentsWrite, commentHandler.DeleteComment)
+	socmedHandler := controllers.NewSocialMediaHandler(repos.SocialMedias)
+	socmedsRead := middlewares.RequireScope(models.ScopeSocialMediasRead)
+	socmedsWrite := middlewares.RequireScope(models.ScopeSocialMediasWrite)
+	socmedsRoute := router.Group("socialmedias", keyAuth)
+	socmedsRoute.POST("/", socmedsWrite, socmedHandler.CreateSocialMedia)
+	socmedsRoute.GET("/", socmedsRead, socmedHandler.GetAllSocialMedias)
+	socmedsRoute.GET("/:socialMediaId", socmedsRead, socmedHandler.GetSocialMedia)
+	socmedsRoute.PUT("/:socialMediaId", socmedsWrite, socmedHandler.UpdateSocialMedia)
+	socmedsRoute.DELETE("/:socialMediaId", socmedsWrite, socmedHandler.DeleteSocialMedia)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	router.POST("users/register", controllers.RegisterUser)
-	router.POST("users/login", controllers.LoginUser)
-	router.PUT("users", middlewares.JwtAuthMiddleware(), controllers.UpdateUser)
-	router.DELETE("users", middlewares.JwtAuthMiddleware(), controllers.DeleteUser)
-	photosRoute := router.Group("photos", middlewares.JwtAuthMiddleware())
-	photosRoute.POST("/", controllers.CreatePhoto)
-	photosRoute.GET("/", controllers.GetAllPhotos)
-	photosRoute.PUT("/:photoId", controllers.UpdatePhoto)
-	photosRoute.DELETE("/:photoId", controllers.DeletePhoto)
+	router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+	userHandler := controllers.NewUserHandler(repos, mail, cfg)
+	router.POST("users/register", userHandler.RegisterUser)
+	router.POST("users/login", userHandler.LoginUser)
+	router.POST("users/login/2fa", userHandler.LoginTwoFactor)
+	router.POST("users/refresh", userHandler.RefreshToken)
+	router.POST("users/logout", auth, userHandler.LogoutUser)
+	router.GET("users/:userId", auth, userHandler.GetUser)
+	router.PUT("users", auth, userHandler.UpdateUser)
+	router.PUT("users/password", auth, userHandler.ChangePassword)
+	router.POST("users/password/forgot", userHandler.ForgotPassword)
+	router.POST("users/password/reset", userHandler.ResetPassword)
+	router.GET("users/verify", userHandler.VerifyEmail)
+	router.POST("users/verify/resend", auth, userHandler.ResendVerification)
+	router.POST("users/2fa/enroll", auth, userHandler.EnrollTwoFactor)
+	router.POST("users/2fa/confirm", auth, userHandler.ConfirmTwoFactor)
+	router.DELETE("users/2fa", auth, userHandler.DisableTwoFactor)
+	router.DELETE("users", auth, userHandler.DeleteUser)
+	router.GET("users/sessions", auth, userHandler.GetSessions)
+	router.DELETE("users/sessions/:sessionId", auth, userHandler.DeleteSession)
+	if cfg.OIDC.Issuer != "" {
+		router.GET("users/oidc/login", userHandler.StartOIDCLogin)
+		router.POST("users/oidc/link", auth, userHandler.StartOIDCLink)
+		router.GET("users/oidc/callback", userHandler.OIDCCallback)
+		router.GET("users/identities", auth, userHandler.GetIdentities)
+		router.DELETE("users/identities/:identityId", auth, userHandler.DeleteIdentity)
+	}
+	apiKeyHandler := controllers.NewAPIKeyHandler(repos.APIKeys)
+	router.POST("users/apikeys", auth, apiKeyHandler.CreateAPIKey)
+	router.GET("users/apikeys", auth, apiKeyHandler.GetAPIKeys)
+	router.DELETE("users/apikeys/:apiKeyId", auth, apiKeyHandler.DeleteAPIKey)
+	photoHandler := controllers.NewPhotoHandler(repos.Photos, repos.PhotoUploads, blobs, worker, checker, cfg.Storage)
+	photosRead := middlewares.RequireScope(models.ScopePhotosRead)
+	photosWrite := middlewares.RequireScope(models.ScopePhotosWrite)
+	photosRoute := router.Group("photos", keyAuth)
+	photosRoute.POST("/", photosWrite, verified, photoHandler.CreatePhoto)
+	photosRoute.GET("/", photosRead, photoHandler.GetAllPhotos)
+	photosRoute.POST("/uploads", photosWrite, verified, photoHandler.StartPhotoUpload)
+	photosRoute.GET("/uploads/:uploadId", photosRead, photoHandler.GetPhotoUpload)
+	photosRoute.PATCH("/uploads/:uploadId", photosWrite, photoHandler.UploadPhotoChunk)
+	photosRoute.GET("/:photoId", photosRead, photoHandler.GetPhoto)
+	photosRoute.PUT("/:photoId", photosWrite, photoHandler.UpdatePhoto)
+	photosRoute.DELETE("/:photoId", photosWrite, photoHandler.DeletePhoto)
+	photosRoute.GET("/:photoId/comments", commentsRead, commentHandler.GetPhotoComments)
+	photosRoute.POST("/:photoId/comments", commentsWrite, verified, commentHandler.CreatePhotoComment)
+	router.GET("uploads/*key", photoHandler.GetStoredPhoto)
+	albumHandler := controllers.NewAlbumHandler(repos.Albums, repos.Photos)
+	// Albums only hold photos, so API keys reach them with the photo scopes.
+	albumsRoute := router.Group("albums", keyAuth)
+	albumsRoute.POST("/", photosWrite, verified, albumHandler.CreateAlbum)
+	albumsRoute.GET("/", photosRead, albumHandler.GetAllAlbums)
+	albumsRoute.GET("/:albumId", photosRead, albumHandler.GetAlbum)
+	albumsRoute.PUT("/:albumId", photosWrite, albumHandler.UpdateAlbum)
+	albumsRoute.DELETE("/:albumId", photosWrite, albumHandler.DeleteAlbum)
+	albumsRoute.POST("/:albumId/photos", photosWrite, albumHandler.AddAlbumPhotos)
+	albumsRoute.DELETE("/:albumId/photos", photosWrite, albumHandler.RemoveAlbumPhotos)
+	albumsRoute.PUT("/:albumId/photos/order", photosWrite, albumHandler.MoveAlbumPhotos)
+	tagHandler := controllers.NewTagHandler(repos.Tags)
+	// Tags only list photos, so API keys reach them with the photo scope.
+	tagsRoute := router.Group("tags", keyAuth, photosRead)
+	tagsRoute.GET("/", tagHandler.GetTags)
+	tagsRoute.GET("/trending", tagHandler.GetTrendingTags)
+	tagsRoute.GET("/:name/photos", photoHandler.GetTagPhotos)
+	adminRoute := router.Group("admin", auth, middlewares.RequireRole(models.RoleModerator))
+	adminRoute.DELETE("/photos/:photoId", photoHandler.DeletePhoto)
+	adminRoute.DELETE("/comments/:commentId", commentHandler.DeleteComment)
+	adminRoute.DELETE("/socialmedias/:socialMediaId", socmedHandler.DeleteSocialMedia)
+	adminRoute.DELETE("/albums/:albumId", albumHandler.DeleteAlbum)
+	adminRoute.POST("/users/:userId/suspend", userHandler.SuspendUser)
+	adminRoute.DELETE("/users/:userId/suspend", userHandler.UnsuspendUser)
+	adminRoute.PUT("/users/:userId/role", middlewares.RequireRole(models.RoleAdmin), userHandler.UpdateUserRole)
 	return router
 }
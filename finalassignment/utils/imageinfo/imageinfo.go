@@ -0,0 +1,72 @@
+// Package imageinfo finds out which kind of image a stream of bytes holds,
+// and how large it is, without decoding all of it.
+package imageinfo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+)
+
+var ErrUnsupported = errors.New("Photos have to be JPEG, PNG or GIF images.")
+
+// Info is what Inspect found out about an image.
+type Info struct {
+	MIMEType string
+	Width    int
+	Height   int
+}
+
+// formats are the image formats accepted, by the MIME type
+// http.DetectContentType sniffs.
+var formats = map[string]struct{ name, extension string }{
+	"image/jpeg": {"jpeg", ".jpg"},
+	"image/png":  {"png", ".png"},
+	"image/gif":  {"gif", ".gif"},
+}
+
+// Inspect reads the start of r to sniff its type and decode its dimensions.
+// The returned reader yields the whole of r, including what Inspect read.
+func Inspect(r io.Reader) (Info, io.Reader, error) {
+	var head bytes.Buffer
+	tee := io.TeeReader(r, &head)
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(tee, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return Info{}, nil, ErrUnsupported
+		}
+		return Info{}, nil, err
+	}
+	mimeType := http.DetectContentType(sniff[:n])
+	format, ok := formats[mimeType]
+	if !ok {
+		return Info{}, nil, ErrUnsupported
+	}
+	config, name, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(sniff[:n]), tee))
+	if err != nil || name != format.name {
+		return Info{}, nil, fmt.Errorf("%w (%v)", ErrUnsupported, err)
+	}
+	info := Info{MIMEType: mimeType, Width: config.Width, Height: config.Height}
+	return info, io.MultiReader(&head, r), nil
+}
+
+// encodedFormats are the extensions of the formats images are re-encoded
+// in but not accepted as uploads.
+var encodedFormats = map[string]string{
+	"image/webp": ".webp",
+}
+
+// Extension returns the file extension of images of mimeType, such as .jpg.
+func Extension(mimeType string) string {
+	if extension, ok := encodedFormats[mimeType]; ok {
+		return extension
+	}
+	return formats[mimeType].extension
+}
@@ -0,0 +1,101 @@
+// Package jobs runs work on rows in the background, such as resizing
+// photos, without a separate queue service.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// queueSize is how many IDs can wait for a worker. IDs that don't fit are
+// left to the next sweep.
+const queueSize = 256
+
+// Queue hands IDs to a few workers running Process. The rows themselves
+// record what is left to do, so nothing is lost when the queue is full or
+// the server stops: Pending finds the leftovers at start and every sweep.
+type Queue struct {
+	// Name prefixes the errors logged.
+	Name    string
+	Workers int
+	// Process does the work on the row with id.
+	Process func(ctx context.Context, id uint) error
+	// Pending returns the IDs of the rows still to be processed.
+	Pending func() ([]uint, error)
+	// SweepInterval is how often Pending is called again.
+	SweepInterval time.Duration
+
+	once   sync.Once
+	queue  chan uint
+	mu     sync.Mutex
+	queued map[uint]bool
+}
+
+func (q *Queue) init() {
+	q.once.Do(func() {
+		q.queue = make(chan uint, queueSize)
+		q.queued = make(map[uint]bool)
+	})
+}
+
+// Start runs the workers until ctx is done, beginning with the pending rows.
+func (q *Queue) Start(ctx context.Context) {
+	q.init()
+	for i := 0; i < q.Workers; i++ {
+		go q.run(ctx)
+	}
+	go q.sweep(ctx)
+}
+
+// Enqueue asks for the row with id to be processed. It never blocks, and
+// IDs already waiting are not queued twice.
+func (q *Queue) Enqueue(id uint) {
+	q.init()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queued[id] {
+		return
+	}
+	select {
+	case q.queue <- id:
+		q.queued[id] = true
+	default:
+	}
+}
+
+func (q *Queue) sweep(ctx context.Context) {
+	ticker := time.NewTicker(q.SweepInterval)
+	defer ticker.Stop()
+	for {
+		ids, err := q.Pending()
+		if err != nil {
+			log.Printf("%s: %v", q.Name, err)
+		}
+		for _, id := range ids {
+			q.Enqueue(id)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-q.queue:
+			if err := q.Process(ctx, id); err != nil {
+				log.Printf("%s: %d: %v", q.Name, id, err)
+			}
+			q.mu.Lock()
+			delete(q.queued, id)
+			q.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Package links checks that the URLs photos are linked by lead to images,
+// without letting users make the server reach addresses it can't be asked to.
+package links
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/imageinfo"
+	"finalassignment.id/finalassignment/utils/jobs"
+	"finalassignment.id/finalassignment/utils/storage"
+	"finalassignment.id/finalassignment/utils/variants"
+	"github.com/google/uuid"
+)
+
+var ErrBlockedURL = errors.New("Photo URLs have to be public http or https URLs.")
+
+// errBlockedAddress is returned by the dialer for addresses links can't
+// point to, after their name was resolved.
+var errBlockedAddress = errors.New("links: blocked address")
+
+var errTooManyRedirects = errors.New("links: too many redirects")
+
+// sweepInterval is how often pending links and broken links due for another
+// look are queued.
+const sweepInterval = 5 * time.Minute
+
+// blockedNets are the ranges that are not public beyond what net.IP knows
+// about: shared, reserved and documentation ranges, and the IPv6 ranges
+// that embed IPv4 addresses.
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"2001::/32",
+	"2001:db8::/32",
+	"2002::/16",
+)
+
+// Checker fetches the links of photos in the background and records whether
+// they lead to an image, copying it into storage when cfg.Ingest is on.
+type Checker struct {
+	photos    database.PhotoRepository
+	blobs     storage.BlobStore
+	variants  *variants.Worker
+	cfg       config.Links
+	publicURL string
+	client    *http.Client
+	queue     *jobs.Queue
+}
+
+// NewChecker returns a Checker that stores the photos it copies in blobs,
+// linked under publicURL, and has their variants made by worker.
+func NewChecker(photos database.PhotoRepository, blobs storage.BlobStore, worker *variants.Worker, cfg config.Links, publicURL string) *Checker {
+	c := &Checker{
+		photos:    photos,
+		blobs:     blobs,
+		variants:  worker,
+		cfg:       cfg,
+		publicURL: publicURL,
+	}
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		// Control sees the address actually dialed, so names can't resolve
+		// to one address when validated and another when fetched.
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errBlockedAddress
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || (!cfg.AllowPrivate && blocked(ip)) {
+				return errBlockedAddress
+			}
+			return nil
+		},
+	}
+	c.client = &http.Client{
+		Transport: &http.Transport{
+			// Proxies from the environment would be dialed instead of the
+			// link, so they are ignored.
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   cfg.Timeout,
+			ResponseHeaderTimeout: cfg.Timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       time.Minute,
+		},
+		Timeout: cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return errTooManyRedirects
+			}
+			return c.Validate(req.URL.String())
+		},
+	}
+	c.queue = &jobs.Queue{
+		Name:    "links: photo",
+		Workers: cfg.Workers,
+		Process: c.process,
+		Pending: func() ([]uint, error) {
+			return photos.GetPhotoLinksToCheck(time.Now().Add(-cfg.RecheckInterval))
+		},
+		SweepInterval: sweepInterval,
+	}
+	return c
+}
+
+// Start runs the workers until ctx is done, beginning with the links left
+// pending.
+func (c *Checker) Start(ctx context.Context) {
+	c.queue.Start(ctx)
+}
+
+// Enqueue asks for the link of a photo to be checked. It never blocks.
+func (c *Checker) Enqueue(photoID uint) {
+	c.queue.Enqueue(photoID)
+}
+
+// Validate rejects URLs that can't be fetched safely before any request is
+// made. Names that resolve to private addresses are caught when the link is
+// fetched.
+func (c *Checker) Validate(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrBlockedURL
+	}
+	if c.cfg.AllowPrivate {
+		return nil
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedURL
+	}
+	if ip := net.ParseIP(host); ip != nil && blocked(ip) {
+		return ErrBlockedURL
+	}
+	return nil
+}
+
+// process checks the link of a photo, unless it was uploaded or its link
+// was checked since it was queued.
+func (c *Checker) process(ctx context.Context, photoID uint) error {
+	photo, err := c.photos.GetSinglePhoto(photoID)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if photo.StorageKey != "" || (photo.LinkStatus != models.LinkPending && photo.LinkStatus != models.LinkBroken) {
+		return nil
+	}
+	checkedAt := time.Now()
+	info, data, err := c.fetch(ctx, photo.PhotoUrl)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down, the link is checked again on the next start.
+			return ctx.Err()
+		}
+		return ignoreNotFound(c.photos.SetPhotoLink(photoID, photo.PhotoUrl, models.PhotoLink{
+			LinkStatus:    models.LinkBroken,
+			LinkCheckedAt: &checkedAt,
+			LinkError:     err.Error(),
+		}))
+	}
+	if data == nil {
+		return ignoreNotFound(c.photos.SetPhotoLink(photoID, photo.PhotoUrl, models.PhotoLink{
+			LinkStatus:    models.LinkOK,
+			LinkCheckedAt: &checkedAt,
+		}))
+	}
+	key := "photos/" + uuid.NewString() + imageinfo.Extension(info.MIMEType)
+	if err := c.blobs.Put(ctx, key, bytes.NewReader(data), int64(len(data)), info.MIMEType); err != nil {
+		return err
+	}
+	file := models.PhotoFile{
+		StorageKey: key,
+		MimeType:   info.MIMEType,
+		ByteSize:   int64(len(data)),
+		Width:      info.Width,
+		Height:     info.Height,
+	}
+	if err := c.photos.IngestPhotoLink(photoID, photo.PhotoUrl, c.publicURL+"/"+key, file, checkedAt); err != nil {
+		if err := c.blobs.Delete(ctx, key); err != nil {
+			return err
+		}
+		return ignoreNotFound(err)
+	}
+	c.variants.Enqueue(photoID)
+	return nil
+}
+
+// fetch gets the image rawURL leads to. Only its start is read unless it is
+// to be copied, then data holds all of it. The errors tell the owner of the
+// photo why the link is broken.
+func (c *Checker) fetch(ctx context.Context, rawURL string) (info imageinfo.Info, data []byte, err error) {
+	if err := c.Validate(rawURL); err != nil {
+		return info, nil, errors.New("the link is not a public http or https URL")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return info, nil, errors.New("the link is not a valid URL")
+	}
+	req.Header.Set("Accept", "image/jpeg, image/png, image/gif")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		switch {
+		case errors.Is(err, errBlockedAddress), errors.Is(err, ErrBlockedURL):
+			return info, nil, errors.New("the link leads to an address that is not public")
+		case errors.Is(err, errTooManyRedirects):
+			return info, nil, fmt.Errorf("the link redirects more than %d times", c.cfg.MaxRedirects)
+		case errors.As(err, &urlErr) && urlErr.Timeout():
+			return info, nil, errors.New("the link took too long to answer")
+		default:
+			return info, nil, errors.New("the link can't be reached")
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return info, nil, fmt.Errorf("the server answered %s", resp.Status)
+	}
+	tooLarge := fmt.Errorf("the photo is larger than %d bytes", c.cfg.MaxSize)
+	if resp.ContentLength > c.cfg.MaxSize {
+		return info, nil, tooLarge
+	}
+	body := io.LimitReader(resp.Body, c.cfg.MaxSize+1)
+	info, r, err := imageinfo.Inspect(body)
+	if err != nil {
+		if errors.Is(err, imageinfo.ErrUnsupported) {
+			return info, nil, errors.New("the link is not a JPEG, PNG or GIF image")
+		}
+		return info, nil, errors.New("the link can't be read")
+	}
+	if !c.cfg.Ingest {
+		return info, nil, nil
+	}
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return info, nil, errors.New("the link can't be read")
+	}
+	if int64(len(data)) > c.cfg.MaxSize {
+		return info, nil, tooLarge
+	}
+	return info, data, nil
+}
+
+// blocked tells whether links can't point to ip.
+func blocked(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, ipNet := range blockedNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipNet
+	}
+	return nets
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, database.ErrNotFound) {
+		// The photo was deleted or pointed elsewhere while it was checked.
+		return nil
+	}
+	return err
+}
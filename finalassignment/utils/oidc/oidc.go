@@ -0,0 +1,238 @@
+// Package oidc logs users in with an OpenID Connect provider, using the
+// authorization code flow with PKCE.
+package oidc
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("The identity provider returned an invalid ID token.")
+	ErrCodeRejected   = errors.New("The identity provider rejected the login, please start again.")
+)
+
+// Claims are the claims of an ID token the login uses.
+type Claims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// Provider is an OpenID Connect provider. Its metadata and keys are fetched
+// on first use, so the server starts even when the provider is down.
+type Provider struct {
+	cfg    config.OIDC
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]*rsa.PublicKey
+}
+
+// metadata is the part of the discovery document the login uses.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jwks struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+	} `json:"keys"`
+}
+
+func NewProvider(cfg config.OIDC) *Provider {
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Issuer identifies the provider, subjects are only unique per issuer.
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// AuthCodeURL returns the page of the provider users login on. state and
+// nonce come back in the redirect and the ID token, verifier is the PKCE
+// code verifier Exchange must be given.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	md, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	authURL, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: authorization endpoint: %w", err)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange trades the code the provider redirected back with for an ID
+// token, and returns its claims once verified against nonce.
+func (p *Provider) Exchange(code, verifier, nonce string) (*Claims, error) {
+	md, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.cfg.ClientID},
+	}
+	req, err := http.NewRequest(http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &body); err != nil {
+		// Providers answer 400 to codes that expired, were used already or
+		// don't match the verifier.
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.status == http.StatusBadRequest {
+			return nil, fmt.Errorf("%w (%v)", ErrCodeRejected, err)
+		}
+		return nil, err
+	}
+	return p.verify(md, body.IDToken, nonce)
+}
+
+func (p *Provider) verify(md *metadata, idToken, nonce string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}))
+	if _, err := parser.ParseWithClaims(idToken, claims, p.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrInvalidIDToken, err)
+	}
+	if !claims.VerifyIssuer(md.Issuer, true) || !claims.VerifyAudience(p.cfg.ClientID, true) ||
+		claims.Subject == "" || claims.Nonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+	return claims, nil
+}
+
+// keyFunc finds the key named by the kid header of token, fetching the keys
+// of the provider again once when it is unknown, as it may have rotated
+// them.
+func (p *Provider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, p.metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: jwks: %w", err)
+	}
+	var set jwks
+	if err := p.do(req, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing key: %v", kid)
+	}
+	return key, nil
+}
+
+// discover fetches the discovery document of the provider, or returns the
+// one fetched before.
+func (p *Provider) discover() (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	md := &metadata{}
+	if err := p.do(req, md); err != nil {
+		return nil, err
+	}
+	if md.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery document is for issuer %q, expected %q", md.Issuer, p.cfg.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document lacks an endpoint")
+	}
+	p.metadata = md
+	return md, nil
+}
+
+// do sends req and decodes the JSON response into v.
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("oidc: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{request: req.Method + " " + req.URL.Redacted(), status: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("oidc: %s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+type statusError struct {
+	request string
+	status  int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("oidc: %s answered %d %s", e.request, e.status, http.StatusText(e.status))
+}
@@ -0,0 +1,101 @@
+package oidc_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/utils/oidc"
+	"finalassignment.id/finalassignment/utils/oidc/oidctest"
+)
+
+const (
+	clientID    = "photos"
+	redirectURL = "http://localhost:8080/users/oidc/callback"
+)
+
+func newIssuer(t *testing.T) (*oidctest.Issuer, *oidc.Provider) {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer(clientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+	issuer.Subject, issuer.Email, issuer.EmailVerified = "subject-1", "user@example.com", true
+	return issuer, oidc.NewProvider(config.OIDC{Issuer: issuer.URL, ClientID: clientID, RedirectURL: redirectURL})
+}
+
+// login sends the user to the issuer and returns the code it redirected
+// back with.
+func login(t *testing.T, issuer *oidctest.Issuer, provider *oidc.Provider, verifier string) string {
+	t.Helper()
+	authURL, err := provider.AuthCodeURL("state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := parsed.Query()
+	if query.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("authorization URL %s lacks the S256 challenge of the verifier", authURL)
+	}
+	callback, err := issuer.Login(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callback.Query().Get("state") != "state-1" {
+		t.Errorf("got state %q back", callback.Query().Get("state"))
+	}
+	return callback.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	issuer, provider := newIssuer(t)
+	code := login(t, issuer, provider, "verifier-1")
+	claims, err := provider.Exchange(code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("got claims %+v", claims)
+	}
+	// Codes are single use.
+	if _, err := provider.Exchange(code, "verifier-1", "nonce-1"); !errors.Is(err, oidc.ErrCodeRejected) {
+		t.Errorf("got %v exchanging a code twice, want ErrCodeRejected", err)
+	}
+}
+
+func TestExchangeChecksPKCEVerifier(t *testing.T) {
+	issuer, provider := newIssuer(t)
+	code := login(t, issuer, provider, "verifier-1")
+	if _, err := provider.Exchange(code, "another-verifier", "nonce-1"); !errors.Is(err, oidc.ErrCodeRejected) {
+		t.Errorf("got %v, want ErrCodeRejected", err)
+	}
+}
+
+func TestExchangeChecksNonce(t *testing.T) {
+	issuer, provider := newIssuer(t)
+	code := login(t, issuer, provider, "verifier-1")
+	if _, err := provider.Exchange(code, "verifier-1", "another-nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("got %v, want ErrInvalidIDToken", err)
+	}
+	issuer.Nonce = "replayed-nonce"
+	code = login(t, issuer, provider, "verifier-1")
+	if _, err := provider.Exchange(code, "verifier-1", "nonce-1"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("got %v for an ID token with another nonce, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestDiscoveryChecksIssuer(t *testing.T) {
+	issuer, _ := newIssuer(t)
+	provider := oidc.NewProvider(config.OIDC{Issuer: issuer.URL + "/", ClientID: clientID, RedirectURL: redirectURL})
+	if _, err := provider.AuthCodeURL("state-1", "nonce-1", "verifier-1"); err == nil {
+		t.Error("got no error for a discovery document of another issuer")
+	}
+}
@@ -0,0 +1,212 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/utils/storage"
+)
+
+const (
+	accessKeyID     = "AKIDEXAMPLE"
+	secretAccessKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// s3Service is a stand-in for an S3 compatible service holding one bucket,
+// which checks the Signature Version 4 of every request.
+type s3Service struct {
+	*httptest.Server
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+func newS3Service(t *testing.T) *s3Service {
+	s := &s3Service{bucket: "photos", objects: make(map[string]object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *s3Service) store(secret string) *storage.S3Store {
+	return storage.NewS3Store(config.S3{
+		Endpoint:        s.URL,
+		Region:          "us-east-1",
+		Bucket:          s.bucket,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secret,
+	})
+}
+
+func (s *s3Service) object(key string) (object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[key]
+	return object, ok
+}
+
+func (s *s3Service) serve(w http.ResponseWriter, r *http.Request) {
+	if err := checkSignature(r, time.Now().UTC()); err != nil {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code><Message>"+err.Error()+"</Message></Error>", http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/"+s.bucket+"/")
+	if key == r.URL.Path {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			http.Error(w, "<Error><Code>IncompleteBody</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = object{data: data, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.data)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+var authorization = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=([0-9a-f]{64})$`)
+
+// checkSignature verifies the Signature Version 4 of r the way S3 does, as
+// written in the AWS documentation.
+func checkSignature(r *http.Request, now time.Time) error {
+	match := authorization.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return fmt.Errorf("malformed Authorization %q", r.Header.Get("Authorization"))
+	}
+	keyID, date, region, signedHeaders, signature := match[1], match[2], match[3], match[4], match[5]
+	if keyID != accessKeyID {
+		return fmt.Errorf("unknown access key %q", keyID)
+	}
+	amzDate, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || amzDate.Format("20060102") != date {
+		return fmt.Errorf("X-Amz-Date %q does not match the credential date %s", r.Header.Get("X-Amz-Date"), date)
+	}
+	if skew := now.Sub(amzDate); skew > 15*time.Minute || skew < -15*time.Minute {
+		return fmt.Errorf("request time %s is too far from %s", amzDate, now)
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		return errors.New("missing X-Amz-Content-Sha256")
+	}
+	var canonicalHeaders strings.Builder
+	signsHost := false
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value, signsHost = r.Host, true
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(value))
+	}
+	if !signsHost || !strings.Contains(signedHeaders, "x-amz-date") {
+		return fmt.Errorf("host and x-amz-date have to be signed, only %s are", signedHeaders)
+	}
+	canonicalRequest := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		canonicalHeaders.String() + "\n" + signedHeaders + "\n" + payloadHash
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = mac(key, part)
+	}
+	if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac(key, stringToSign)))) {
+		return errors.New("the signature does not match")
+	}
+	return nil
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func TestS3Store(t *testing.T) {
+	service := newS3Service(t)
+	store := service.store(secretAccessKey)
+	ctx := context.Background()
+	// Keys are escaped in the path, which has to be signed escaped.
+	key := "photos/a b+c=d~é.jpg"
+	data := []byte("not really a jpeg")
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := service.object(key); !bytes.Equal(got.data, data) || got.contentType != "image/jpeg" {
+		t.Errorf("stored %q as %q", got.data, got.contentType)
+	}
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	if err := store.Put(ctx, "photos/empty", bytes.NewReader(nil), 0, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.object("photos/empty"); !ok {
+		t.Error("the empty blob was not stored")
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("got %v after the blob was deleted, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing blob: %v", err)
+	}
+}
+
+func TestS3StoreSignature(t *testing.T) {
+	service := newS3Service(t)
+	store := service.store("not the secret")
+	err := store.Put(context.Background(), "photos/a.jpg", strings.NewReader("a"), 1, "image/jpeg")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("got %v with a wrong secret, want the 403 of the service", err)
+	}
+	if _, ok := service.object("photos/a.jpg"); ok {
+		t.Error("a request with a wrong signature stored a blob")
+	}
+}
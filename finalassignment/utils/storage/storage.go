@@ -0,0 +1,36 @@
+// Package storage keeps the bytes of uploaded photos.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"finalassignment.id/finalassignment/config"
+)
+
+var ErrNotFound = errors.New("storage: blob not found")
+
+// BlobStore keeps blobs under slash separated keys such as
+// photos/0b7c0c1e.jpg.
+type BlobStore interface {
+	// Put stores the size bytes of r under key, replacing any blob there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the blob under key, or ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Missing blobs are not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the BlobStore selected by cfg.Driver.
+func New(cfg config.Storage) (BlobStore, error) {
+	switch cfg.Driver {
+	case "fs":
+		return NewFSStore(cfg.Path)
+	case "s3":
+		return NewS3Store(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
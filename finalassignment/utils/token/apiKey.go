@@ -0,0 +1,35 @@
+package token
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyPrefix marks API keys so they are recognizable, e.g. by secret
+// scanners, when they leak.
+const apiKeyPrefix = "fa_"
+
+// APIKeyPrefixLength is how much of a key is kept in the clear to tell keys
+// apart.
+const APIKeyPrefixLength = len(apiKeyPrefix) + 8
+
+// GenerateAPIKey returns a new API key and the hash to store in its place.
+func GenerateAPIKey() (apiKey, hash string, err error) {
+	opaqueToken, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	apiKey = apiKeyPrefix + opaqueToken
+	return apiKey, HashOpaqueToken(apiKey), nil
+}
+
+// ExtractAPIKey returns the key of an "Authorization: ApiKey <key>" header,
+// and false when the request uses another scheme.
+func ExtractAPIKey(c *gin.Context) (string, bool) {
+	scheme, apiKey, ok := strings.Cut(c.Request.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "ApiKey") {
+		return "", false
+	}
+	return strings.TrimSpace(apiKey), true
+}
@@ -0,0 +1,46 @@
+package token
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const loginChallengeAudience = "login-challenge"
+
+var ErrInvalidChallenge = errors.New("The login challenge is invalid or has expired, please login again.")
+
+// GenerateLoginChallenge returns a signed token proving that userID gave
+// the right password, to be exchanged for an access token along with a
+// second factor. Its jti is to be consumed when it is answered, so it can
+// only be exchanged once.
+func GenerateLoginChallenge(userID uint, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return keys.sign(jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Audience:  jwt.ClaimStrings{loginChallengeAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	})
+}
+
+// ParseLoginChallenge returns the user a token made by
+// GenerateLoginChallenge was issued to, and its jti and expiry.
+func ParseLoginChallenge(tokenString string) (userID uint, jti string, expiresAt time.Time, err error) {
+	claims := &jwt.RegisteredClaims{}
+	if _, err = jwt.ParseWithClaims(tokenString, claims, keys.keyFunc); err != nil {
+		return 0, "", time.Time{}, ErrInvalidChallenge
+	}
+	if !claims.VerifyAudience(loginChallengeAudience, true) || claims.ID == "" || claims.ExpiresAt == nil {
+		return 0, "", time.Time{}, ErrInvalidChallenge
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 0)
+	if err != nil {
+		return 0, "", time.Time{}, ErrInvalidChallenge
+	}
+	return uint(id), claims.ID, claims.ExpiresAt.Time, nil
+}
@@ -0,0 +1,53 @@
+package token
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const emailVerificationAudience = "email-verification"
+
+var ErrInvalidVerification = errors.New("The email verification link is invalid or has expired.")
+
+// EmailClaims are the claims of email verification tokens. The subject is
+// the user ID.
+type EmailClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateEmailVerificationToken returns a signed token proving that whoever
+// presents it received mail sent to email.
+func GenerateEmailVerificationToken(userID uint, email string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := EmailClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{emailVerificationAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return keys.sign(claims)
+}
+
+// ParseEmailVerificationToken returns the user and the email a token made
+// by GenerateEmailVerificationToken was issued for.
+func ParseEmailVerificationToken(tokenString string) (userID uint, email string, err error) {
+	claims := &EmailClaims{}
+	if _, err = jwt.ParseWithClaims(tokenString, claims, keys.keyFunc); err != nil {
+		return 0, "", ErrInvalidVerification
+	}
+	if !claims.VerifyAudience(emailVerificationAudience, true) {
+		return 0, "", ErrInvalidVerification
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 0)
+	if err != nil {
+		return 0, "", ErrInvalidVerification
+	}
+	return uint(id), claims.Email, nil
+}
@@ -0,0 +1,178 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"finalassignment.id/finalassignment/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var ErrVerifyOnlyKey = errors.New("key can only verify tokens, it has no private part")
+
+// Key is a named key tokens are signed or verified with.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signKey is nil for keys that only verify tokens, e.g. a retired key
+	// whose private part has been destroyed.
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet holds every key tokens are verified with and the one new tokens are
+// signed with.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	KeyType   string `json:"kty" example:"RSA"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use" example:"sig"`
+	Algorithm string `json:"alg" example:"RS256"`
+	Curve     string `json:"crv,omitempty" example:"Ed25519"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeys reads the keys described by cfg.
+func LoadKeys(cfg config.JWT) (*KeySet, error) {
+	var keys []Key
+	if cfg.Secret != "" {
+		keys = append(keys, NewHMACKey(config.SecretKeyID, []byte(cfg.Secret)))
+	}
+	for _, keyCfg := range cfg.Keys {
+		content, err := os.ReadFile(keyCfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyCfg.ID, err)
+		}
+		key, err := ParseKey(keyCfg.ID, keyCfg.Algorithm, content)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyCfg.ID, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(cfg.SigningKeyID, keys...)
+}
+
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// ParseKey parses content as a key for algorithm, which is HS256, RS256 or
+// EdDSA. HS256 keys are the raw secret, the others are PEM encoded private
+// keys, or public keys for keys that only verify tokens.
+func ParseKey(id, algorithm string, content []byte) (Key, error) {
+	switch algorithm {
+	case "HS256":
+		if len(content) < 32 {
+			return Key{}, errors.New("HS256 secrets must be at least 32 bytes")
+		}
+		return NewHMACKey(id, content), nil
+	case "RS256":
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(content); err == nil {
+			return Key{ID: id, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(content)
+		if err != nil {
+			return Key{}, fmt.Errorf("not a PEM encoded RSA key: %w", err)
+		}
+		return Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: public}, nil
+	case "EdDSA":
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(content); err == nil {
+			privateKey := private.(ed25519.PrivateKey)
+			return Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: privateKey, verifyKey: privateKey.Public()}, nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(content)
+		if err != nil {
+			return Key{}, fmt.Errorf("not a PEM encoded Ed25519 key: %w", err)
+		}
+		return Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: public}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+// NewKeySet returns a KeySet verifying tokens with any of keys and signing
+// them with the key named signingKeyID.
+func NewKeySet(signingKeyID string, keys ...Key) (*KeySet, error) {
+	keySet := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for i := range keys {
+		if _, ok := keySet.keys[keys[i].ID]; ok {
+			return nil, fmt.Errorf("jwt key %q is defined more than once", keys[i].ID)
+		}
+		keySet.keys[keys[i].ID] = &keys[i]
+	}
+	signing, ok := keySet.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q is not defined", signingKeyID)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("jwt signing key %q: %w", signingKeyID, ErrVerifyOnlyKey)
+	}
+	keySet.signing = signing
+	return keySet, nil
+}
+
+// sign signs claims with the signing key, naming it in the kid header.
+func (keySet *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(keySet.signing.Method, claims)
+	token.Header["kid"] = keySet.signing.ID
+	return token.SignedString(keySet.signing.signKey)
+}
+
+// keyFunc finds the key named by the kid header of token. Tokens without kid
+// predate key rotation and were signed with the configured secret.
+func (keySet *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = config.SecretKeyID
+	}
+	key, ok := keySet.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing key: %v", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys of the set, sorted by key ID. HMAC keys are
+// secret and never published.
+func (keySet *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range keySet.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+	return jwks
+}
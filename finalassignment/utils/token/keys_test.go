@@ -0,0 +1,288 @@
+package token_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/utils/token"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// keyFiles are PEM files of an RSA and an Ed25519 key pair, and an HMAC
+// secret, written once for every test.
+type keyFiles struct {
+	dir                           string
+	rsaPrivate, rsaPublic         string
+	ed25519Private, ed25519Public string
+	secret                        string
+	rsaKey                        *rsa.PrivateKey
+	ed25519Key                    ed25519.PublicKey
+}
+
+func writeKeyFiles(t *testing.T) keyFiles {
+	t.Helper()
+	files := keyFiles{dir: t.TempDir()}
+	var err error
+	if files.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files.ed25519Key = public
+	files.rsaPrivate = files.writePEM(t, "rsa.pem", "PRIVATE KEY", files.rsaKey)
+	files.rsaPublic = files.writePEM(t, "rsa.pub.pem", "PUBLIC KEY", &files.rsaKey.PublicKey)
+	files.ed25519Private = files.writePEM(t, "ed25519.pem", "PRIVATE KEY", private)
+	files.ed25519Public = files.writePEM(t, "ed25519.pub.pem", "PUBLIC KEY", public)
+	files.secret = filepath.Join(files.dir, "hs256.key")
+	if err := os.WriteFile(files.secret, []byte(testSecret), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func (files keyFiles) writePEM(t *testing.T, name, blockType string, key interface{}) string {
+	t.Helper()
+	var der []byte
+	var err error
+	if blockType == "PUBLIC KEY" {
+		der, err = x509.MarshalPKIXPublicKey(key)
+	} else {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(files.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadKeys(t *testing.T, cfg config.JWT) *token.KeySet {
+	t.Helper()
+	keys, err := token.LoadKeys(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// signWith returns a token signed by the signing key of keys.
+func signWith(t *testing.T, keys *token.KeySet) string {
+	t.Helper()
+	token.Configure(keys, time.Hour, time.Hour)
+	signed, err := token.GenerateEmailVerificationToken(7, "alice@example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// verifyWith parses signed with keys and returns the error.
+func verifyWith(keys *token.KeySet, signed string) error {
+	token.Configure(keys, time.Hour, time.Hour)
+	userID, email, err := token.ParseEmailVerificationToken(signed)
+	if err == nil && (userID != 7 || email != "alice@example.com") {
+		err = errors.New("the claims changed")
+	}
+	return err
+}
+
+func header(t *testing.T, signed string) map[string]interface{} {
+	t.Helper()
+	parsed, _, err := new(jwt.Parser).ParseUnverified(signed, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Header
+}
+
+func TestLoadKeysErrors(t *testing.T) {
+	files := writeKeyFiles(t)
+	short := filepath.Join(files.dir, "short.key")
+	if err := os.WriteFile(short, []byte("too short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cfg  config.JWT
+		err  string
+	}{
+		{"missing file", config.JWT{Keys: []config.JWTKey{{ID: "a", Algorithm: "RS256", File: filepath.Join(files.dir, "missing.pem")}}, SigningKeyID: "a"}, `jwt key "a"`},
+		{"short secret", config.JWT{Keys: []config.JWTKey{{ID: "a", Algorithm: "HS256", File: short}}, SigningKeyID: "a"}, "at least 32 bytes"},
+		{"not RSA", config.JWT{Keys: []config.JWTKey{{ID: "a", Algorithm: "RS256", File: files.ed25519Private}}, SigningKeyID: "a"}, "not a PEM encoded RSA key"},
+		{"not Ed25519", config.JWT{Keys: []config.JWTKey{{ID: "a", Algorithm: "EdDSA", File: files.rsaPrivate}}, SigningKeyID: "a"}, "not a PEM encoded Ed25519 key"},
+		{"unknown algorithm", config.JWT{Keys: []config.JWTKey{{ID: "a", Algorithm: "ES256", File: files.rsaPrivate}}, SigningKeyID: "a"}, `unsupported algorithm "ES256"`},
+		{"duplicate", config.JWT{Secret: testSecret, Keys: []config.JWTKey{{ID: config.SecretKeyID, Algorithm: "RS256", File: files.rsaPrivate}}, SigningKeyID: config.SecretKeyID}, "defined more than once"},
+		{"unknown signing key", config.JWT{Secret: testSecret, SigningKeyID: "b"}, `jwt signing key "b" is not defined`},
+		{"public signing key", config.JWT{Keys: []config.JWTKey{{ID: "a", Algorithm: "RS256", File: files.rsaPublic}}, SigningKeyID: "a"}, token.ErrVerifyOnlyKey.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := token.LoadKeys(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("LoadKeys = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestKeyRoundTrip signs with every algorithm, checks the kid header names
+// the signing key and that the token verifies.
+func TestKeyRoundTrip(t *testing.T) {
+	files := writeKeyFiles(t)
+	keys := []config.JWTKey{
+		{ID: "hs", Algorithm: "HS256", File: files.secret},
+		{ID: "rs", Algorithm: "RS256", File: files.rsaPrivate},
+		{ID: "ed", Algorithm: "EdDSA", File: files.ed25519Private},
+	}
+	for _, key := range keys {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			keySet := loadKeys(t, config.JWT{Secret: testSecret, Keys: keys, SigningKeyID: key.ID})
+			signed := signWith(t, keySet)
+			h := header(t, signed)
+			if h["kid"] != key.ID || h["alg"] != key.Algorithm {
+				t.Errorf("got header %v, want kid %s and alg %s", h, key.ID, key.Algorithm)
+			}
+			if err := verifyWith(keySet, signed); err != nil {
+				t.Errorf("verifying: %v", err)
+			}
+			// Changing the signature breaks it.
+			tampered := signed[:len(signed)-4] + "AAAA"
+			if tampered == signed {
+				tampered = signed[:len(signed)-4] + "BBBB"
+			}
+			if err := verifyWith(keySet, tampered); err == nil {
+				t.Error("a tampered token verified")
+			}
+		})
+	}
+}
+
+// TestKeyRotation checks tokens of a retired key verify as long as its
+// public key is configured, and tokens of unknown keys never do.
+func TestKeyRotation(t *testing.T) {
+	files := writeKeyFiles(t)
+	before := loadKeys(t, config.JWT{
+		Secret:       testSecret,
+		Keys:         []config.JWTKey{{ID: "old", Algorithm: "RS256", File: files.rsaPrivate}},
+		SigningKeyID: "old",
+	})
+	old := signWith(t, before)
+	legacy := signWith(t, loadKeys(t, config.JWT{Secret: testSecret, SigningKeyID: config.SecretKeyID}))
+
+	after := loadKeys(t, config.JWT{
+		Secret: testSecret,
+		Keys: []config.JWTKey{
+			{ID: "new", Algorithm: "EdDSA", File: files.ed25519Private},
+			{ID: "old", Algorithm: "RS256", File: files.rsaPublic},
+		},
+		SigningKeyID: "new",
+	})
+	if h := header(t, signWith(t, after)); h["kid"] != "new" {
+		t.Errorf("signed with %v, want the new key", h["kid"])
+	}
+	if err := verifyWith(after, old); err != nil {
+		t.Errorf("a token of the retired key was refused: %v", err)
+	}
+	if err := verifyWith(after, legacy); err != nil {
+		t.Errorf("a token of the secret was refused: %v", err)
+	}
+
+	dropped := loadKeys(t, config.JWT{
+		Keys:         []config.JWTKey{{ID: "new", Algorithm: "EdDSA", File: files.ed25519Private}},
+		SigningKeyID: "new",
+	})
+	if err := verifyWith(dropped, old); err == nil {
+		t.Error("a token of a key that is no longer configured verified")
+	}
+
+	// A token naming a known kid with another algorithm is refused, so an
+	// RSA public key can't be used as an HMAC secret.
+	claims := jwt.RegisteredClaims{Subject: "7", Audience: jwt.ClaimStrings{"email-verification"}}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "old"
+	publicPEM, err := os.ReadFile(files.rsaPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := forged.SignedString(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWith(after, signed); err == nil {
+		t.Error("an HS256 token naming an RSA key verified")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	files := writeKeyFiles(t)
+	keySet := loadKeys(t, config.JWT{
+		Secret: testSecret,
+		Keys: []config.JWTKey{
+			{ID: "rs", Algorithm: "RS256", File: files.rsaPublic},
+			{ID: "hs", Algorithm: "HS256", File: files.secret},
+			{ID: "ed", Algorithm: "EdDSA", File: files.ed25519Private},
+		},
+		SigningKeyID: "ed",
+	})
+	jwks := keySet.JWKS()
+	want := token.JWKS{Keys: []token.JWK{
+		{
+			KeyType:   "OKP",
+			KeyID:     "ed",
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(files.ed25519Key),
+		},
+		{
+			KeyType:   "RSA",
+			KeyID:     "rs",
+			Use:       "sig",
+			Algorithm: "RS256",
+			N:         base64.RawURLEncoding.EncodeToString(files.rsaKey.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(files.rsaKey.E)).Bytes()),
+		},
+	}}
+	if len(jwks.Keys) != len(want.Keys) {
+		t.Fatalf("got keys %+v, want %+v", jwks.Keys, want.Keys)
+	}
+	for i := range want.Keys {
+		if jwks.Keys[i] != want.Keys[i] {
+			t.Errorf("key %d is %+v, want %+v", i, jwks.Keys[i], want.Keys[i])
+		}
+	}
+	encoded, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{testSecret, base64.RawURLEncoding.EncodeToString([]byte(testSecret)), `"oct"`, `"HS256"`, `"default"`} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("the JWKS %s contains %s", encoded, secret)
+		}
+	}
+
+	onlySecrets := loadKeys(t, config.JWT{Secret: testSecret, SigningKeyID: config.SecretKeyID})
+	if encoded, err := json.Marshal(onlySecrets.JWKS()); err != nil || string(encoded) != `{"keys":[]}` {
+		t.Errorf("the JWKS of HMAC keys is %s, %v", encoded, err)
+	}
+}
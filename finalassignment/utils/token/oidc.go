@@ -0,0 +1,55 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const oidcFlowAudience = "oidc-flow"
+
+var ErrInvalidOIDCFlow = errors.New("The login has expired or was started in another browser, please start again.")
+
+// OIDCFlow is what the callback of an OpenID Connect login checks the answer
+// of the provider against. It is kept in a cookie while the user is at the
+// provider.
+type OIDCFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// LinkUserID is the user linking an identity to their account, zero when
+	// logging in.
+	LinkUserID uint `json:"link_user_id,omitempty"`
+}
+
+type oidcFlowClaims struct {
+	OIDCFlow
+	jwt.RegisteredClaims
+}
+
+// GenerateOIDCFlowToken returns flow as a signed token.
+func GenerateOIDCFlowToken(flow OIDCFlow, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return keys.sign(oidcFlowClaims{
+		OIDCFlow: flow,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{oidcFlowAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+}
+
+// ParseOIDCFlowToken returns the flow a token made by GenerateOIDCFlowToken
+// holds.
+func ParseOIDCFlowToken(tokenString string) (OIDCFlow, error) {
+	claims := &oidcFlowClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, keys.keyFunc); err != nil {
+		return OIDCFlow{}, ErrInvalidOIDCFlow
+	}
+	if !claims.VerifyAudience(oidcFlowAudience, true) || claims.State == "" {
+		return OIDCFlow{}, ErrInvalidOIDCFlow
+	}
+	return claims.OIDCFlow, nil
+}
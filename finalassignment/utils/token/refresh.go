@@ -0,0 +1,42 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// GenerateRefreshToken returns a new opaque refresh token, the hash to store
+// in its place and when it expires.
+func GenerateRefreshToken() (refreshToken, hash string, expiresAt time.Time, err error) {
+	refreshToken, hash, err = GenerateOpaqueToken()
+	expiresAt = time.Now().Add(refreshTokenTTL)
+	return
+}
+
+// HashRefreshToken returns the value stored for refreshToken.
+func HashRefreshToken(refreshToken string) string {
+	return HashOpaqueToken(refreshToken)
+}
+
+// GenerateOpaqueToken returns a new random URL safe token and the hash to
+// store in its place.
+func GenerateOpaqueToken() (opaqueToken, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return
+	}
+	opaqueToken = base64.RawURLEncoding.EncodeToString(buf)
+	hash = HashOpaqueToken(opaqueToken)
+	return
+}
+
+// HashOpaqueToken returns the value stored for a token made by
+// GenerateOpaqueToken. They carry 256 bits of randomness, so an unsalted
+// SHA-256 is enough.
+func HashOpaqueToken(opaqueToken string) string {
+	sum := sha256.Sum256([]byte(opaqueToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,40 @@
+package token_test
+
+import (
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/utils/token"
+)
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token.Configure(nil, time.Minute, time.Hour)
+	before := time.Now()
+	refreshToken, hash, expiresAt, err := token.GenerateRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refreshToken) != 43 {
+		t.Errorf("got a %d character token, want 256 bits in unpadded base64", len(refreshToken))
+	}
+	if hash != token.HashRefreshToken(refreshToken) || hash == refreshToken {
+		t.Errorf("the hash %q is not the hash of the token", hash)
+	}
+	if expiresAt.Before(before.Add(time.Hour)) || expiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("the token expires at %v, want an hour from now", expiresAt)
+	}
+	other, otherHash, _, err := token.GenerateRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == refreshToken || otherHash == hash {
+		t.Error("two refresh tokens are the same")
+	}
+}
+
+func TestHashOpaqueToken(t *testing.T) {
+	// The SHA-256 of "abc" from FIPS 180-2.
+	if got := token.HashOpaqueToken("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("HashOpaqueToken = %s", got)
+	}
+}
@@ -2,62 +2,89 @@ package token
 
 import (
 	"errors"
-	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
-const key = "J50^_W3b-T0|<3^_K3y"
+var (
+	keys            *KeySet
+	tokenTTL        = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	ErrNoToken      = errors.New("Bearer token tidak ditemukan.")
+	ErrNotAccess    = errors.New("The token is not an access token.")
+)
+
+func init() {
+	// Sub-second iat lets a revocation of every token issued before now
+	// spare the token issued right after it, e.g. by logging in again.
+	jwt.TimePrecision = time.Microsecond
+}
 
-var ErrNoToken = errors.New("Bearer token tidak ditemukan.")
+// Configure sets the keys used to sign and verify tokens and the lifetimes
+// of newly generated access and refresh tokens. It must be called before
+// serving requests.
+func Configure(keySet *KeySet, ttl, refreshTTL time.Duration) {
+	keys = keySet
+	tokenTTL = ttl
+	refreshTokenTTL = refreshTTL
+}
 
-func TokenValid(c *gin.Context) error {
+// TTL returns the lifetime of access tokens made by GenerateToken.
+func TTL() time.Duration {
+	return tokenTTL
+}
+
+// Claims are the claims of the access tokens made by GenerateToken. The
+// registered jti and iat claims let a single token or every token issued to
+// a user before some point be revoked, sid every token of a session.
+type Claims struct {
+	UserID uint     `json:"user_id"`
+	Roles  []string `json:"roles,omitempty"`
+	// SessionID is zero for tokens issued before sessions were recorded.
+	SessionID uint `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func ExtractClaims(c *gin.Context) (*Claims, error) {
 	tokenString, err := ExtractToken(c)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(key), nil
-	})
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, keys.keyFunc)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
-func ExtractTokenID(c *gin.Context) (uint, error) {
-	tokenString, err := ExtractToken(c)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(key), nil
-	})
-	if err != nil {
-		return 0, err
+	// Tokens for other purposes, such as email verification, are signed
+	// with the same keys but always name an audience.
+	if len(claims.Audience) > 0 {
+		return nil, ErrNotAccess
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		uid, err := strconv.ParseUint(fmt.Sprintf("%.0f", claims["user_id"]), 10, 32)
-		if err != nil {
-			return 0, err
-		}
-		return uint(uid), nil
+	return claims, nil
+}
+func GenerateToken(userID uint, roles []string, sessionID uint) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:    userID,
+		Roles:     roles,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
 	}
-	return 0, nil
+	return keys.sign(claims)
 }
-func GenerateToken(userID uint) (string, error) {
-	claims := jwt.MapClaims{}
-	claims["user_id"] = userID
-	claims["exp"] = time.Now().Add(time.Hour * time.Duration(24)).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(key))
+
+// PublicKeys returns the public verification keys, for other services to
+// verify our tokens with.
+func PublicKeys() JWKS {
+	return keys.JWKS()
 }
 func ExtractToken(c *gin.Context) (string, error) {
 	bearerToken := c.Request.Header.Get("Authorization")
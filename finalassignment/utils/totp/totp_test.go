@@ -0,0 +1,101 @@
+package totp_test
+
+import (
+	"testing"
+	"time"
+
+	"finalassignment.id/finalassignment/utils/totp"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors, the ASCII
+// "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCode checks the SHA1 vectors of RFC 6238 appendix B, cut to the last
+// 6 of their 8 digits.
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("code at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+	if _, err := totp.Code("not base32!", 1); err == nil {
+		t.Error("a secret that is not base32 was accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := totp.Counter(now)
+	tests := []struct {
+		name    string
+		counter int64
+		ok      bool
+	}{
+		{"two steps before", current - 2, false},
+		{"one step before", current - 1, true},
+		{"current step", current, true},
+		{"one step after", current + 1, true},
+		{"two steps after", current + 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := totp.Code(rfcSecret, tt.counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counter, ok := totp.Validate(rfcSecret, code, now)
+			if ok != tt.ok {
+				t.Fatalf("Validate = %v, want %v", ok, tt.ok)
+			}
+			if ok && counter != tt.counter {
+				t.Errorf("Validate returned step %d, want %d", counter, tt.counter)
+			}
+		})
+	}
+	// Spaces are ignored, other lengths are refused.
+	if _, ok := totp.Validate(rfcSecret, "050 471", now); !ok {
+		t.Error("a code with a space was refused")
+	}
+	for _, code := range []string{"", "05047", "0504710", "abcdef"} {
+		if _, ok := totp.Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate accepted %q", code)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := totp.GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 19 || code[4] != '-' || code[9] != '-' || code[14] != '-' {
+			t.Errorf("recovery code %q is not formatted XXXX-XXXX-XXXX-XXXX", code)
+		}
+		normalized := totp.NormalizeRecoveryCode(code)
+		if seen[normalized] {
+			t.Errorf("recovery code %q was generated twice", code)
+		}
+		seen[normalized] = true
+	}
+	if got := totp.NormalizeRecoveryCode("abcd efgh-ijkl-MNOP"); got != "ABCDEFGHIJKLMNOP" {
+		t.Errorf("NormalizeRecoveryCode = %q", got)
+	}
+}
@@ -0,0 +1,204 @@
+package variants
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+)
+
+// exifHeader starts the APP1 segment holding the EXIF data of a JPEG.
+var exifHeader = []byte("Exif\x00\x00")
+
+// gpsIFDTag is the EXIF tag pointing to the GPS IFD.
+const gpsIFDTag = 0x8825
+
+// keptTags are the IFD0 tags variants keep: who made the photo, with what
+// and when. The orientation is left out, as the pixels are re-encoded as
+// decoded, and so are the thumbnail of IFD1 and the Exif sub-IFD, which
+// show the original photo and the camera's serial numbers.
+var keptTags = map[uint16]bool{
+	0x010F: true, // Make
+	0x0110: true, // Model
+	0x0131: true, // Software
+	0x0132: true, // DateTime
+	0x013B: true, // Artist
+	0x8298: true, // Copyright
+}
+
+// typeSizes are the sizes in bytes of the TIFF field types, by type number.
+var typeSizes = [...]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// exifSegment returns the payload of the EXIF APP1 segment of a JPEG, or nil
+// when it has none.
+func exifSegment(jpeg []byte) []byte {
+	if len(jpeg) < 2 || jpeg[0] != 0xFF || jpeg[1] != 0xD8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(jpeg) && jpeg[i] == 0xFF; {
+		marker := jpeg[i+1]
+		// The image data starts at SOS, metadata comes before it.
+		if marker == 0xDA {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(jpeg[i+2:]))
+		if length < 2 || i+2+length > len(jpeg) {
+			return nil
+		}
+		payload := jpeg[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			return append([]byte{}, payload...)
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+// withExif inserts exif as an APP1 segment right after the start of jpeg.
+func withExif(jpeg, exif []byte) []byte {
+	if len(exif) == 0 || len(exif)+2 > 0xFFFF {
+		return jpeg
+	}
+	out := make([]byte, 0, len(jpeg)+len(exif)+4)
+	out = append(out, jpeg[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(exif)+2))
+	out = append(out, exif...)
+	return append(out, jpeg[2:]...)
+}
+
+// byteOrder is the byte order of a TIFF header, read and written.
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// ifdEntry is a field of an IFD with its value, wherever it was stored.
+type ifdEntry struct {
+	tag, fieldType uint16
+	count          uint32
+	value          []byte
+}
+
+// minimalExif rebuilds an EXIF payload from the keptTags of its IFD0, and
+// its GPS IFD when keepGPS is set. It returns nil when nothing is kept or the
+// payload can't be parsed, so nothing slips through in data it didn't
+// understand.
+func minimalExif(exif []byte, keepGPS bool) []byte {
+	tiff := exif[len(exifHeader):]
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order byteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	entries, ok := readIFD(tiff, int(order.Uint32(tiff[4:])), order)
+	if !ok {
+		return nil
+	}
+	var kept, gps []ifdEntry
+	for _, entry := range entries {
+		switch {
+		case keptTags[entry.tag]:
+			kept = append(kept, entry)
+		case entry.tag == gpsIFDTag && keepGPS && entry.fieldType == 4 && entry.count == 1:
+			if gps, ok = readIFD(tiff, int(order.Uint32(entry.value)), order); !ok {
+				return nil
+			}
+		}
+	}
+	if len(gps) > 0 {
+		pointer := ifdEntry{tag: gpsIFDTag, fieldType: 4, count: 1, value: make([]byte, 4)}
+		kept = append(kept, pointer)
+		// The GPS IFD follows IFD0 and its values.
+		order.PutUint32(pointer.value, uint32(8+ifdSize(kept)))
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].tag < kept[j].tag })
+	out := append([]byte{}, exifHeader...)
+	out = append(out, tiff[:2]...)
+	out = order.AppendUint16(out, 42)
+	out = order.AppendUint32(out, 8)
+	out = appendIFD(out, len(exifHeader), kept, order)
+	if len(gps) > 0 {
+		out = appendIFD(out, len(exifHeader), gps, order)
+	}
+	return out
+}
+
+// readIFD returns the entries of the IFD at offset in tiff.
+func readIFD(tiff []byte, offset int, order binary.ByteOrder) ([]ifdEntry, bool) {
+	if offset < 8 || offset+2 > len(tiff) {
+		return nil, false
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	if offset+2+count*12 > len(tiff) {
+		return nil, false
+	}
+	entries := make([]ifdEntry, count)
+	for n := range entries {
+		field := tiff[offset+2+n*12:]
+		entry := ifdEntry{tag: order.Uint16(field), fieldType: order.Uint16(field[2:]), count: order.Uint32(field[4:])}
+		if int(entry.fieldType) >= len(typeSizes) || typeSizes[entry.fieldType] == 0 {
+			return nil, false
+		}
+		size := typeSizes[entry.fieldType] * int(entry.count)
+		value := field[8:12]
+		if size > 4 {
+			start := int(order.Uint32(field[8:]))
+			if start < 8 || size > len(tiff) || start+size > len(tiff) {
+				return nil, false
+			}
+			value = tiff[start : start+size]
+		}
+		entry.value = append([]byte{}, value[:size]...)
+		entries[n] = entry
+	}
+	return entries, true
+}
+
+// ifdSize is the size of an IFD of entries, values stored after it included.
+func ifdSize(entries []ifdEntry) int {
+	size := 2 + len(entries)*12 + 4
+	for _, entry := range entries {
+		if len(entry.value) > 4 {
+			size += len(entry.value) + len(entry.value)%2
+		}
+	}
+	return size
+}
+
+// appendIFD appends an IFD of entries with no next IFD to out, followed by
+// the values that don't fit in their entries. Offsets count from the TIFF
+// header, which starts at base in out.
+func appendIFD(out []byte, base int, entries []ifdEntry, order byteOrder) []byte {
+	data := len(out) - base + 2 + len(entries)*12 + 4
+	var values []byte
+	out = order.AppendUint16(out, uint16(len(entries)))
+	for _, entry := range entries {
+		out = order.AppendUint16(out, entry.tag)
+		out = order.AppendUint16(out, entry.fieldType)
+		out = order.AppendUint32(out, entry.count)
+		if len(entry.value) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, entry.value)
+			out = append(out, inline...)
+			continue
+		}
+		out = order.AppendUint32(out, uint32(data+len(values)))
+		values = append(values, entry.value...)
+		// Values start on a word boundary.
+		if len(values)%2 == 1 {
+			values = append(values, 0)
+		}
+	}
+	out = order.AppendUint32(out, 0)
+	return append(out, values...)
+}
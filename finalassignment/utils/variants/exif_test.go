@@ -0,0 +1,153 @@
+package variants
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"reflect"
+	"testing"
+)
+
+var (
+	gpsLatitude = bytes.Repeat([]byte{0x44, 0x33, 0x22, 0x11}, 6)
+	serial      = []byte("SN12345\x00")
+	thumbnail   = []byte("THMB")
+)
+
+// testExif returns an EXIF payload with a make, model and orientation in
+// IFD0, a serial number in the Exif sub-IFD, a GPS IFD and a thumbnail in
+// IFD1.
+func testExif() []byte {
+	le := binary.LittleEndian
+	tiff := make([]byte, 196)
+	field := func(at int, tag, fieldType uint16, count, value uint32) {
+		le.PutUint16(tiff[at:], tag)
+		le.PutUint16(tiff[at+2:], fieldType)
+		le.PutUint32(tiff[at+4:], count)
+		le.PutUint32(tiff[at+8:], value)
+	}
+	copy(tiff, "II")
+	le.PutUint16(tiff[2:], 42)
+	le.PutUint32(tiff[4:], 8)
+
+	le.PutUint16(tiff[8:], 5)
+	field(10, 0x010F, 2, 8, 74)
+	field(22, 0x0110, 2, 4, 0)
+	copy(tiff[30:], "X10\x00")
+	field(34, 0x0112, 3, 1, 6)
+	field(46, 0x8769, 4, 1, 82)
+	field(58, gpsIFDTag, 4, 1, 108)
+	le.PutUint32(tiff[70:], 162)
+	copy(tiff[74:], "Camera1\x00")
+
+	le.PutUint16(tiff[82:], 1)
+	field(84, 0xA431, 2, 8, 100)
+	copy(tiff[100:], serial)
+
+	le.PutUint16(tiff[108:], 2)
+	field(110, 0x0001, 2, 2, 0)
+	copy(tiff[118:], "N\x00")
+	field(122, 0x0002, 5, 3, 138)
+	copy(tiff[138:], gpsLatitude)
+
+	le.PutUint16(tiff[162:], 2)
+	field(164, 0x0201, 4, 1, 192)
+	field(176, 0x0202, 4, 1, uint32(len(thumbnail)))
+	copy(tiff[192:], thumbnail)
+	return append(append([]byte{}, exifHeader...), tiff...)
+}
+
+// ifd0 parses the IFD0 of exif, checking it is the only IFD of the chain.
+func ifd0(t *testing.T, exif []byte) (tiff []byte, entries []ifdEntry) {
+	t.Helper()
+	tiff = exif[len(exifHeader):]
+	offset := int(binary.LittleEndian.Uint32(tiff[4:]))
+	entries, ok := readIFD(tiff, offset, binary.LittleEndian)
+	if !ok {
+		t.Fatalf("can't read IFD0 of %q", exif)
+	}
+	if next := binary.LittleEndian.Uint32(tiff[offset+2+len(entries)*12:]); next != 0 {
+		t.Errorf("IFD0 is followed by an IFD at %d", next)
+	}
+	return tiff, entries
+}
+
+func TestMinimalExif(t *testing.T) {
+	exif := testExif()
+	for _, keepGPS := range []bool{false, true} {
+		minimal := minimalExif(exif, keepGPS)
+		for _, leak := range [][]byte{serial, thumbnail} {
+			if bytes.Contains(minimal, leak) {
+				t.Errorf("keepGPS %v: %q is kept", keepGPS, leak)
+			}
+		}
+		if bytes.Contains(minimal, gpsLatitude) != keepGPS {
+			t.Errorf("keepGPS %v: the GPS latitude is kept: %v", keepGPS, !keepGPS)
+		}
+
+		tiff, entries := ifd0(t, minimal)
+		var tags []uint16
+		for _, entry := range entries {
+			tags = append(tags, entry.tag)
+		}
+		want := []uint16{0x010F, 0x0110}
+		if keepGPS {
+			want = append(want, gpsIFDTag)
+		}
+		if !reflect.DeepEqual(tags, want) {
+			t.Fatalf("keepGPS %v: got IFD0 tags %#x, want %#x", keepGPS, tags, want)
+		}
+		if string(entries[0].value) != "Camera1\x00" || string(entries[1].value) != "X10\x00" {
+			t.Errorf("keepGPS %v: got make %q and model %q", keepGPS, entries[0].value, entries[1].value)
+		}
+		if keepGPS {
+			gps, ok := readIFD(tiff, int(binary.LittleEndian.Uint32(entries[2].value)), binary.LittleEndian)
+			if !ok || len(gps) != 2 || string(gps[0].value) != "N\x00" || !bytes.Equal(gps[1].value, gpsLatitude) {
+				t.Errorf("got GPS IFD %+v", gps)
+			}
+		}
+	}
+}
+
+func TestMinimalExifRejected(t *testing.T) {
+	orientationOnly := testExif()
+	binary.LittleEndian.PutUint16(orientationOnly[len(exifHeader)+8:], 1)
+	copy(orientationOnly[len(exifHeader)+10:], orientationOnly[len(exifHeader)+34:len(exifHeader)+46])
+
+	badOffset := testExif()
+	binary.LittleEndian.PutUint32(badOffset[len(exifHeader)+18:], 1000)
+
+	tests := map[string][]byte{
+		"short":           append(append([]byte{}, exifHeader...), "II*"...),
+		"byte order":      append(append([]byte{}, exifHeader...), "XX*\x00\x08\x00\x00\x00"...),
+		"value outside":   badOffset,
+		"nothing to keep": orientationOnly,
+		"IFD0 outside":    append(append([]byte{}, exifHeader...), "II*\x00\xFF\x00\x00\x00"...),
+	}
+	for name, exif := range tests {
+		if minimal := minimalExif(exif, true); minimal != nil {
+			t.Errorf("%s: got %q, want nil", name, minimal)
+		}
+	}
+}
+
+// TestWithExif checks a rebuilt EXIF segment reads back from the JPEG it is
+// inserted in, which still decodes.
+func TestWithExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	minimal := minimalExif(testExif(), false)
+	withMinimal := withExif(buf.Bytes(), minimal)
+	if got := exifSegment(withMinimal); !bytes.Equal(got, minimal) {
+		t.Errorf("got EXIF %q, want %q", got, minimal)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(withMinimal)); err != nil {
+		t.Error(err)
+	}
+	if exifSegment(buf.Bytes()) != nil {
+		t.Error("found EXIF data in a JPEG without any")
+	}
+}
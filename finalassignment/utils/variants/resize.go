@@ -0,0 +1,70 @@
+package variants
+
+import (
+	"image"
+	"image/draw"
+)
+
+// fit returns the size of a width by height image scaled down so its longest
+// side is at most size, keeping its aspect ratio.
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// resize scales img down to width by height by averaging the pixels each
+// new pixel covers, which keeps fine detail from aliasing.
+func resize(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	srcWidth, srcHeight := src.Rect.Dx(), src.Rect.Dy()
+	if width == srcWidth && height == srcHeight {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += uint64(row[i])
+					sum[1] += uint64(row[i+1])
+					sum[2] += uint64(row[i+2])
+					sum[3] += uint64(row[i+3])
+				}
+			}
+			n := uint64((y1 - y0) * (x1 - x0))
+			i := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				// Round to the nearest value instead of down.
+				dst.Pix[i+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// flatten lays img over white, for formats without transparency where
+// transparent pixels would otherwise turn black.
+func flatten(img *image.RGBA) *image.RGBA {
+	flat := image.NewRGBA(img.Rect)
+	draw.Draw(flat, flat.Rect, image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Rect, img, img.Rect.Min, draw.Over)
+	return flat
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,204 @@
+// Package variants makes the resized copies of uploaded photos in the
+// background, so lists can show small images instead of the originals.
+package variants
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"finalassignment.id/finalassignment/config"
+	"finalassignment.id/finalassignment/database"
+	"finalassignment.id/finalassignment/models"
+	"finalassignment.id/finalassignment/utils/imageinfo"
+	"finalassignment.id/finalassignment/utils/jobs"
+	"finalassignment.id/finalassignment/utils/storage"
+	"finalassignment.id/finalassignment/utils/webp"
+	"github.com/google/uuid"
+)
+
+// maxPixels is the largest photo resized. Decoding takes four bytes a
+// pixel, and a small file can claim a huge image.
+const maxPixels = 50_000_000
+
+// sweepInterval is how often photos left pending are queued again, such as
+// the ones of a server that stopped before it got to them.
+const sweepInterval = 5 * time.Minute
+
+// errUnprocessable marks photos that will never be resized, unlike storage
+// errors that are worth retrying.
+var errUnprocessable = errors.New("variants: photo can't be resized")
+
+// Worker makes the variants of the photos it is given, a few at a time.
+type Worker struct {
+	photos    database.PhotoRepository
+	blobs     storage.BlobStore
+	cfg       config.Variants
+	publicURL string
+	queue     *jobs.Queue
+}
+
+// NewWorker returns a Worker that keeps variants next to the photos in
+// blobs, linked under publicURL like them.
+func NewWorker(photos database.PhotoRepository, blobs storage.BlobStore, cfg config.Variants, publicURL string) *Worker {
+	w := &Worker{
+		photos:    photos,
+		blobs:     blobs,
+		cfg:       cfg,
+		publicURL: publicURL,
+	}
+	w.queue = &jobs.Queue{
+		Name:          "variants: photo",
+		Workers:       cfg.Workers,
+		Process:       w.process,
+		Pending:       photos.GetPendingVariantPhotoIDs,
+		SweepInterval: sweepInterval,
+	}
+	return w
+}
+
+// Start runs the workers until ctx is done, beginning with the photos left
+// pending.
+func (w *Worker) Start(ctx context.Context) {
+	w.queue.Start(ctx)
+}
+
+// Enqueue asks for the variants of a photo. It never blocks.
+func (w *Worker) Enqueue(photoID uint) {
+	w.queue.Enqueue(photoID)
+}
+
+// process makes and saves the variants of a pending photo.
+func (w *Worker) process(ctx context.Context, photoID uint) error {
+	photo, err := w.photos.GetSinglePhoto(photoID)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if photo.VariantsStatus != models.VariantsPending {
+		return nil
+	}
+	variants, err := w.generate(ctx, photo)
+	if err != nil {
+		if errors.Is(err, errUnprocessable) {
+			if err := w.photos.SetPhotoVariantsStatus(photoID, models.VariantsFailed); err != nil {
+				log.Printf("variants: photo %d: %v", photoID, err)
+			}
+		}
+		return err
+	}
+	if err := w.photos.SavePhotoVariants(photoID, variants); err != nil {
+		w.delete(ctx, variants)
+		if errors.Is(err, database.ErrNotFound) {
+			// The photo was deleted or replaced while it was resized.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// generate stores every size of photo in every format.
+func (w *Worker) generate(ctx context.Context, photo models.Photo) ([]models.PhotoVariant, error) {
+	if photo.Width*photo.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d is too large", errUnprocessable, photo.Width, photo.Height)
+	}
+	blob, err := w.blobs.Get(ctx, photo.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnprocessable, err)
+	}
+	var exif []byte
+	if photo.MimeType == "image/jpeg" {
+		if exif = exifSegment(data); exif != nil {
+			exif = minimalExif(exif, !w.cfg.StripGPS)
+		}
+	}
+	base := strings.TrimSuffix(photo.StorageKey, path.Ext(photo.StorageKey))
+	bounds := img.Bounds()
+	var variants []models.PhotoVariant
+	for _, size := range w.cfg.Sizes {
+		width, height := fit(bounds.Dx(), bounds.Dy(), size)
+		resized := resize(img, width, height)
+		for _, format := range w.cfg.Formats {
+			encoded, mimeType, err := w.encode(resized, format, exif)
+			if err != nil {
+				w.delete(ctx, variants)
+				return nil, err
+			}
+			name := fmt.Sprintf("%d_%s", size, format)
+			// Keys are new every time, so a copy of this worker racing for
+			// the same photo can't delete the variants the other saved.
+			key := base + "_" + name + "_" + uuid.NewString()[:8] + imageinfo.Extension(mimeType)
+			if err := w.blobs.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), mimeType); err != nil {
+				w.delete(ctx, variants)
+				return nil, err
+			}
+			variants = append(variants, models.PhotoVariant{
+				Name: name,
+				URL:  w.publicURL + "/" + key,
+				PhotoFile: models.PhotoFile{
+					StorageKey: key,
+					MimeType:   mimeType,
+					ByteSize:   int64(len(encoded)),
+					Width:      resized.Rect.Dx(),
+					Height:     resized.Rect.Dy(),
+				},
+			})
+		}
+	}
+	return variants, nil
+}
+
+// encode re-encodes img, which drops all the metadata of the photo but the
+// EXIF data rebuilt by minimalExif passed along for JPEG.
+func (w *Worker) encode(img *image.RGBA, format string, exif []byte) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: w.cfg.JPEGQuality}); err != nil {
+			return nil, "", err
+		}
+		return withExif(buf.Bytes(), exif), "image/jpeg", nil
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	case "webp":
+		if err := webp.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/webp", nil
+	default:
+		return nil, "", fmt.Errorf("variants: unknown format %q", format)
+	}
+}
+
+func (w *Worker) delete(ctx context.Context, variants []models.PhotoVariant) {
+	for _, variant := range variants {
+		if err := w.blobs.Delete(ctx, variant.StorageKey); err != nil {
+			log.Printf("variants: %v", err)
+		}
+	}
+}
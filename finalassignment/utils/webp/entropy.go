@@ -0,0 +1,387 @@
+package webp
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Alphabet sizes of the five prefix codes: green with the length prefixes
+// after it, red, blue, alpha and the distance prefixes.
+const (
+	numLiterals      = 256
+	numLengthCodes   = 24
+	numDistanceCodes = 40
+)
+
+// Limits of the backward references looked for.
+const (
+	minMatch  = 3
+	maxMatch  = 4096
+	window    = 1 << 16
+	maxChain  = 32
+	hashBits  = 16
+	maxLength = 15
+	// distanceOffset is added to distances, the codes below it name
+	// pixels around the current one instead.
+	distanceOffset = 120
+)
+
+// codeLengthOrder is the order the lengths of the code length code are
+// sent in.
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// token is a literal pixel, or a copy of the length pixels distance back
+// when length is not zero.
+type token struct {
+	pixel    uint32
+	length   int
+	distance int
+}
+
+// writeImage entropy codes the width pixels wide argb with a single group
+// of prefix codes and no color cache. The main image says it has no meta
+// prefix codes too.
+func writeImage(bw *bitWriter, argb []uint32, width int, main bool) {
+	tokens := backwardReferences(argb)
+	histograms := [5][]int{
+		make([]int, numLiterals+numLengthCodes),
+		make([]int, numLiterals),
+		make([]int, numLiterals),
+		make([]int, numLiterals),
+		make([]int, numDistanceCodes),
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			histograms[0][t.pixel>>8&0xff]++
+			histograms[1][t.pixel>>16&0xff]++
+			histograms[2][t.pixel&0xff]++
+			histograms[3][t.pixel>>24]++
+			continue
+		}
+		lengthCode, _, _ := prefixEncode(t.length)
+		distanceCode, _, _ := prefixEncode(t.distance + distanceOffset)
+		histograms[0][numLiterals+lengthCode]++
+		histograms[4][distanceCode]++
+	}
+
+	bw.writeBits(0, 1)
+	if main {
+		bw.writeBits(0, 1)
+	}
+	var codes [5]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bw, histogram)
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int(t.pixel>>8&0xff))
+			codes[1].write(bw, int(t.pixel>>16&0xff))
+			codes[2].write(bw, int(t.pixel&0xff))
+			codes[3].write(bw, int(t.pixel>>24))
+			continue
+		}
+		code, extraBits, extra := prefixEncode(t.length)
+		codes[0].write(bw, numLiterals+code)
+		bw.writeBits(extra, extraBits)
+		code, extraBits, extra = prefixEncode(t.distance + distanceOffset)
+		codes[4].write(bw, code)
+		bw.writeBits(extra, extraBits)
+	}
+}
+
+// backwardReferences turns argb into literals and copies of the longest
+// earlier runs of the same pixels found, greedily.
+func backwardReferences(argb []uint32) []token {
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, len(argb))
+	insert := func(i int) {
+		if i+minMatch <= len(argb) {
+			h := hash(argb[i:])
+			prev[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+	var tokens []token
+	for i := 0; i < len(argb); {
+		length, distance := 0, 0
+		if i+minMatch <= len(argb) {
+			chain := maxChain
+			for j := int(head[hash(argb[i:])]); j >= 0 && i-j <= window && chain > 0; j = int(prev[j]) {
+				chain--
+				if n := matchLength(argb, j, i); n > length {
+					length, distance = n, i-j
+					if n == maxMatch {
+						break
+					}
+				}
+			}
+		}
+		if length < minMatch {
+			tokens = append(tokens, token{pixel: argb[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, token{length: length, distance: distance})
+		for end := i + length; i < end; i++ {
+			insert(i)
+		}
+	}
+	return tokens
+}
+
+func hash(argb []uint32) uint32 {
+	h := argb[0]*0x9e3779b1 ^ argb[1]*0x85ebca6b ^ argb[2]*0xc2b2ae35
+	return h >> (32 - hashBits)
+}
+
+// matchLength returns how many pixels from i repeat the ones from j.
+func matchLength(argb []uint32, j, i int) int {
+	n := 0
+	for i+n < len(argb) && n < maxMatch && argb[j+n] == argb[i+n] {
+		n++
+	}
+	return n
+}
+
+// prefixEncode splits a length or distance into the prefix code sent for
+// it and the extra bits that follow.
+func prefixEncode(value int) (code int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	highest := bits.Len(uint(d)) - 1
+	extraBits = uint(highest - 1)
+	return 2*highest + d>>extraBits&1, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// prefixCode is a canonical prefix code, with its codes bit reversed to be
+// written least significant bit first.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint32
+}
+
+func (c prefixCode) write(bw *bitWriter, symbol int) {
+	bw.writeBits(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// writePrefixCode writes the prefix code made for histogram and returns it.
+// A lone symbol is sent as a simple code and then takes no bits at all.
+func writePrefixCode(bw *bitWriter, histogram []int) prefixCode {
+	used := 0
+	symbol := 0
+	for s, count := range histogram {
+		if count > 0 {
+			used++
+			symbol = s
+		}
+	}
+	if used <= 1 && symbol < numLiterals {
+		bw.writeBits(1, 1)
+		bw.writeBits(0, 1)
+		if symbol < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(symbol), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(symbol), 8)
+		}
+		return prefixCode{lengths: make([]uint8, len(histogram)), codes: make([]uint32, len(histogram))}
+	}
+	code := newPrefixCode(histogram, maxLength)
+	bw.writeBits(0, 1)
+	writeCodeLengths(bw, code.lengths)
+	return code
+}
+
+// writeCodeLengths sends the lengths of a normal prefix code, run length
+// encoded with the code length code.
+func writeCodeLengths(bw *bitWriter, lengths []uint8) {
+	type run struct {
+		symbol    int
+		extraBits uint
+		extra     uint32
+	}
+	var runs []run
+	for i := 0; i < len(lengths); {
+		value, n := lengths[i], 1
+		for i+n < len(lengths) && lengths[i+n] == value {
+			n++
+		}
+		i += n
+		if value == 0 {
+			for n >= 3 {
+				if n >= 11 {
+					r := min(n, 138)
+					runs = append(runs, run{18, 7, uint32(r - 11)})
+					n -= r
+				} else {
+					r := min(n, 10)
+					runs = append(runs, run{17, 3, uint32(r - 3)})
+					n -= r
+				}
+			}
+		} else {
+			runs = append(runs, run{symbol: int(value)})
+			n--
+			for n >= 3 {
+				r := min(n, 6)
+				runs = append(runs, run{16, 2, uint32(r - 3)})
+				n -= r
+			}
+		}
+		for ; n > 0; n-- {
+			runs = append(runs, run{symbol: int(value)})
+		}
+	}
+
+	histogram := make([]int, len(codeLengthOrder))
+	for _, r := range runs {
+		histogram[r.symbol]++
+	}
+	code := newPrefixCode(histogram, 7)
+	n := len(codeLengthOrder)
+	for n > 4 && code.lengths[codeLengthOrder[n-1]] == 0 {
+		n--
+	}
+	bw.writeBits(uint32(n-4), 4)
+	for _, symbol := range codeLengthOrder[:n] {
+		bw.writeBits(uint32(code.lengths[symbol]), 3)
+	}
+	// All the lengths of the alphabet follow.
+	bw.writeBits(0, 1)
+	for _, r := range runs {
+		code.write(bw, r.symbol)
+		bw.writeBits(r.extra, r.extraBits)
+	}
+}
+
+// newPrefixCode makes a prefix code for histogram with codes of at most
+// limit bits. Decoders reject codes that are not complete, so a lone symbol
+// is given a partner.
+func newPrefixCode(histogram []int, limit int) prefixCode {
+	counts := append([]int(nil), histogram...)
+	used := 0
+	for _, count := range counts {
+		if count > 0 {
+			used++
+		}
+	}
+	for s := 0; used < 2; s++ {
+		if counts[s] == 0 {
+			counts[s] = 1
+			used++
+		}
+	}
+	// Flattening the counts until the tree is shallow enough keeps the code
+	// complete, unlike cutting long codes short.
+	var lengths []uint8
+	for floor := 1; ; floor *= 2 {
+		lengths = huffmanLengths(counts, floor)
+		deepest := uint8(0)
+		for _, length := range lengths {
+			deepest = maxUint8(deepest, length)
+		}
+		if int(deepest) <= limit {
+			break
+		}
+	}
+	return prefixCode{lengths: lengths, codes: canonicalCodes(lengths)}
+}
+
+// huffmanLengths returns the code lengths of the Huffman tree of counts,
+// counting every used symbol as at least floor.
+func huffmanLengths(counts []int, floor int) []uint8 {
+	type node struct {
+		weight      int
+		left, right int
+	}
+	var nodes []node
+	var symbols []int
+	for s, count := range counts {
+		if count > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return max(counts[symbols[i]], floor) < max(counts[symbols[j]], floor)
+	})
+	for _, s := range symbols {
+		nodes = append(nodes, node{weight: max(counts[s], floor), left: -1, right: -1})
+	}
+	// Leaves and the internal nodes made from them are both in order of
+	// weight, so the two lightest are at the front of either.
+	leaf, internal := 0, len(nodes)
+	lightest := func() int {
+		if leaf < len(symbols) && (internal == len(nodes) || nodes[leaf].weight <= nodes[internal].weight) {
+			leaf++
+			return leaf - 1
+		}
+		internal++
+		return internal - 1
+	}
+	for i := 1; i < len(symbols); i++ {
+		a, b := lightest(), lightest()
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, left: a, right: b})
+	}
+	depths := make([]uint8, len(nodes))
+	for i := len(nodes) - 1; i >= len(symbols); i-- {
+		depths[nodes[i].left] = depths[i] + 1
+		depths[nodes[i].right] = depths[i] + 1
+	}
+	lengths := make([]uint8, len(counts))
+	for i, s := range symbols {
+		lengths[s] = depths[i]
+	}
+	return lengths
+}
+
+// canonicalCodes numbers the codes of lengths, shorter codes first and
+// then by symbol.
+func canonicalCodes(lengths []uint8) []uint32 {
+	var count [maxLength + 1]uint32
+	for _, length := range lengths {
+		count[length]++
+	}
+	count[0] = 0
+	var next [maxLength + 1]uint32
+	code := uint32(0)
+	for length := 1; length <= maxLength; length++ {
+		code = (code + count[length-1]) << 1
+		next[length] = code
+	}
+	codes := make([]uint32, len(lengths))
+	for s, length := range lengths {
+		if length > 0 {
+			codes[s] = bits.Reverse32(next[length]) >> (32 - length)
+			next[length]++
+		}
+	}
+	return codes
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxUint8(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,150 @@
+package webp
+
+// Transform types.
+const (
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+)
+
+// predictorBits is the log2 of the side of the blocks a predictor is
+// chosen for.
+const predictorBits = 4
+
+// predictors are the modes tried for each block: left, top, the average of
+// both, the one of them closer to the gradient, and the clamped gradient.
+var predictors = []uint32{1, 2, 7, 11, 12}
+
+// blocks returns how many predictor blocks cover size pixels.
+func blocks(size int) int {
+	return (size + 1<<predictorBits - 1) >> predictorBits
+}
+
+// predict chooses the predictor of every block of argb, the one leaving the
+// smallest residuals, and returns the modes as the image the predictor
+// transform is sent with, along with the residuals of all pixels.
+func predict(argb []uint32, width, height int) ([]uint32, []uint32) {
+	columns := blocks(width)
+	modes := make([]uint32, columns*blocks(height))
+	residuals := make([]uint32, len(argb))
+	for by := 0; by < blocks(height); by++ {
+		for bx := 0; bx < columns; bx++ {
+			best, bestCost := predictors[0], -1
+			for _, mode := range predictors {
+				cost := 0
+				forBlock(width, height, bx, by, func(x, y int) {
+					cost += residualCost(argb[y*width+x], predicted(argb, width, x, y, mode))
+				})
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[by*columns+bx] = 0xff000000 | best<<8
+			forBlock(width, height, bx, by, func(x, y int) {
+				residuals[y*width+x] = subPixels(argb[y*width+x], predicted(argb, width, x, y, best))
+			})
+		}
+	}
+	return modes, residuals
+}
+
+func forBlock(width, height, bx, by int, f func(x, y int)) {
+	for y := by << predictorBits; y < (by+1)<<predictorBits && y < height; y++ {
+		for x := bx << predictorBits; x < (bx+1)<<predictorBits && x < width; x++ {
+			f(x, y)
+		}
+	}
+}
+
+// residualCost estimates the bits the residual of pixel takes by the size
+// of its channels as signed bytes.
+func residualCost(pixel, prediction uint32) int {
+	residual := subPixels(pixel, prediction)
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		c := int(int8(residual >> shift))
+		if c < 0 {
+			c = -c
+		}
+		cost += c
+	}
+	return cost
+}
+
+// predicted returns the prediction of the pixel at x, y by mode. The first
+// row and column always use the pixel before them, the very first pixel
+// opaque black.
+func predicted(argb []uint32, width, x, y int, mode uint32) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[i-1]
+	case x == 0:
+		return argb[i-width]
+	}
+	left, top, topLeft := argb[i-1], argb[i-width], argb[i-width-1]
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	case 7:
+		return average2(left, top)
+	case 11:
+		return selectPixel(left, top, topLeft)
+	case 12:
+		return clampAddSubtractFull(left, top, topLeft)
+	default:
+		panic("webp: unknown predictor")
+	}
+}
+
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// selectPixel returns whichever of left and top is closer to the gradient
+// left + top - topLeft.
+func selectPixel(left, top, topLeft uint32) uint32 {
+	toLeft, toTop := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		l, t, tl := int(left>>shift&0xff), int(top>>shift&0xff), int(topLeft>>shift&0xff)
+		toLeft += abs(t - tl)
+		toTop += abs(l - tl)
+	}
+	if toLeft < toTop {
+		return left
+	}
+	return top
+}
+
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+	var p uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(a>>shift&0xff) + int(b>>shift&0xff) - int(c>>shift&0xff)
+		if v < 0 {
+			v = 0
+		} else if v > 0xff {
+			v = 0xff
+		}
+		p |= uint32(v) << shift
+	}
+	return p
+}
+
+// subPixels subtracts b from a channel by channel, modulo 256.
+func subPixels(a, b uint32) uint32 {
+	var p uint32
+	for shift := 0; shift < 32; shift += 8 {
+		p |= (a>>shift - b>>shift) & 0xff << shift
+	}
+	return p
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
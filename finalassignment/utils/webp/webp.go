@@ -0,0 +1,123 @@
+// Package webp encodes images as lossless WebP, the VP8L format of RFC 9649.
+//
+// Only what an encoder needs is implemented: the subtract green and
+// predictor transforms, backward references and a single group of prefix
+// codes. Files come out larger than the ones of libwebp, but every WebP
+// decoder reads them.
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// maxSize is the largest width and height VP8L can describe.
+const maxSize = 1 << 14
+
+var ErrTooLarge = errors.New("webp: images can be at most 16384 pixels wide and high")
+
+// Encode writes img to w as a lossless WebP file.
+func Encode(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		return ErrTooLarge
+	}
+	if width == 0 || height == 0 {
+		return errors.New("webp: images have to have pixels")
+	}
+	argb, opaque := pixels(img)
+
+	bw := &bitWriter{}
+	bw.writeBits(0x2f, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if opaque {
+		bw.writeBits(0, 1)
+	} else {
+		bw.writeBits(1, 1)
+	}
+	bw.writeBits(0, 3)
+
+	subtractGreen(argb)
+	bw.writeBits(1, 1)
+	bw.writeBits(transformSubtractGreen, 2)
+	modes, residuals := predict(argb, width, height)
+	bw.writeBits(1, 1)
+	bw.writeBits(transformPredictor, 2)
+	bw.writeBits(predictorBits-2, 3)
+	writeImage(bw, modes, blocks(width), false)
+	bw.writeBits(0, 1)
+
+	writeImage(bw, residuals, width, true)
+	data := bw.bytes()
+
+	header := make([]byte, 20)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+len(data)%2))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// pixels returns the non-premultiplied ARGB pixels of img row by row, and
+// whether all of them are opaque.
+func pixels(img image.Image) ([]uint32, bool) {
+	bounds := img.Bounds()
+	argb := make([]uint32, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			opaque = opaque && c.A == 0xff
+			argb = append(argb, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+	return argb, opaque
+}
+
+// subtractGreen takes the green of every pixel off its red and blue, which
+// tend to follow it.
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := p >> 8 & 0xff
+		red := (p>>16 - green) & 0xff
+		blue := (p - green) & 0xff
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// bitWriter packs bits starting at the least significant bit of each byte.
+type bitWriter struct {
+	buf   []byte
+	bits  uint64
+	nbits uint
+}
+
+func (w *bitWriter) writeBits(value uint32, n uint) {
+	w.bits |= uint64(value) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,88 @@
+package webp_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"finalassignment.id/finalassignment/utils/webp"
+	xwebp "golang.org/x/image/webp"
+)
+
+// roundTrip encodes img and checks the decoder of golang.org/x/image reads
+// every pixel of it back.
+func roundTrip(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	decoded, err := xwebp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("%s: decoding: %v", name, err)
+	}
+	bounds := img.Bounds()
+	if decoded.Bounds().Dx() != bounds.Dx() || decoded.Bounds().Dy() != bounds.Dy() {
+		t.Fatalf("%s: decoded %v, want %v", name, decoded.Bounds(), bounds)
+	}
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			want := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			got := color.NRGBAModel.Convert(decoded.At(decoded.Bounds().Min.X+x, decoded.Bounds().Min.Y+y))
+			if got != want {
+				t.Fatalf("%s: pixel %d,%d is %v, want %v", name, x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	// Sizes around the 16 pixel predictor blocks, and the single rows and
+	// columns the predictors treat apart.
+	for _, size := range []image.Point{{1, 1}, {1, 9}, {9, 1}, {2, 2}, {16, 16}, {17, 33}, {300, 200}} {
+		bounds := image.Rectangle{Max: size}
+		noise := image.NewNRGBA(bounds)
+		random.Read(noise.Pix)
+		for i := 3; i < len(noise.Pix); i += 4 {
+			// Transparent pixels keep their color in NRGBA.
+			noise.Pix[i] |= 1
+		}
+		roundTrip(t, "noise", noise)
+
+		flat := image.NewRGBA(bounds)
+		draw.Draw(flat, bounds, image.NewUniform(color.RGBA{10, 20, 30, 255}), image.Point{}, draw.Src)
+		roundTrip(t, "flat", flat)
+
+		gradient := image.NewRGBA(bounds)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				gradient.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x + 3*y), 255})
+			}
+		}
+		roundTrip(t, "gradient", gradient)
+
+		stripes := image.NewGray(bounds)
+		for i := range stripes.Pix {
+			stripes.Pix[i] = uint8(i / 7 % 3 * 100)
+		}
+		roundTrip(t, "stripes", stripes)
+	}
+	whole := image.NewNRGBA(image.Rect(0, 0, 50, 50))
+	random.Read(whole.Pix)
+	for i := 3; i < len(whole.Pix); i += 4 {
+		whole.Pix[i] = 0xff
+	}
+	roundTrip(t, "sub image", whole.SubImage(image.Rect(5, 7, 40, 30)))
+}
+
+func TestEncodeTooLarge(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 1<<14+1, 1))
+	if err := webp.Encode(&bytes.Buffer{}, img); !errors.Is(err, webp.ErrTooLarge) {
+		t.Errorf("got %v, want ErrTooLarge", err)
+	}
+}